@@ -3,13 +3,15 @@ package api
 import (
 	"context"
 	"errors"
-	"log/slog"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/jcooney/appts/domain"
 )
 
@@ -17,6 +19,9 @@ type AppointmentRequest struct {
 	FirstName string     `json:"firstName" validate:"required,max=50"`
 	LastName  string     `json:"lastName" validate:"required,max=50"`
 	VisitDate *VisitDate `json:"visitDate" validate:"required"`
+	HoldToken string     `json:"holdToken" validate:"required"`
+	Email     string     `json:"email,omitempty" validate:"omitempty,email,max=254"`
+	Phone     string     `json:"phone,omitempty" validate:"omitempty,e164"`
 }
 
 type VisitDate time.Time
@@ -46,15 +51,24 @@ func (v *VisitDate) Time() *time.Time {
 }
 
 type AppointmentResponse struct {
-	FirstName string     `json:"firstName"`
-	LastName  string     `json:"lastName"`
-	VisitDate *VisitDate `json:"visitDate"`
+	ID            uuid.UUID                `json:"id"`
+	FirstName     string                   `json:"firstName"`
+	LastName      string                   `json:"lastName"`
+	VisitDate     *VisitDate               `json:"visitDate"`
+	Email         string                   `json:"email,omitempty"`
+	Phone         string                   `json:"phone,omitempty"`
+	Status        domain.AppointmentStatus `json:"status,omitempty"`
+	AddToCalendar string                   `json:"addToCalendar"` // link to download the appointment as an .ics file
 }
 
 type AppointmentCreator interface {
 	Create(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error)
 }
 
+type AppointmentCanceller interface {
+	Cancel(ctx context.Context, id uuid.UUID) (*domain.Appointment, error)
+}
+
 func createAppointment(service AppointmentCreator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := &AppointmentRequest{}
@@ -66,30 +80,40 @@ func createAppointment(service AppointmentCreator) http.HandlerFunc {
 			return
 		}
 
-		appointment, err := service.Create(r.Context(), domain.NewAppointment(req.FirstName, req.LastName, req.VisitDate.Time()))
+		email, ok := bookingEmail(r, req.Email)
+		if !ok {
+			renderNotOwned(w, r)
+			return
+		}
+
+		appt := domain.NewAppointment(req.FirstName, req.LastName, req.VisitDate.Time())
+		appt.HoldToken = req.HoldToken
+		appt.Email = email
+		appt.Phone = req.Phone
+		appointment, err := service.Create(r.Context(), appt)
 		if err != nil {
-			if code, ok := errorMap[err]; ok {
-				renderErr := render.Render(w, r, &ErrResponse{
-					HTTPStatusCode: code,
-					StatusText:     http.StatusText(code),
-					ErrorText:      err.Error(),
-				})
-				if renderErr != nil {
-					return
-				}
-				return
-			} else {
-				renderErr := render.Render(w, r, errInternalServerError())
-				slog.Error("unknown error creating appointment:", "error", err)
-				if renderErr != nil {
-					slog.Warn("error rendering response:", "render error", renderErr)
-					return
-				}
-				return
-			}
+			renderServiceError(w, r, err, "unknown error creating appointment:")
+			return
 		}
 		render.Status(r, http.StatusCreated)
-		_ = render.Render(w, r, NewAppointmentResponse(appointment))
+		_ = render.Render(w, r, NewAppointmentResponse(appointment, publicURL(r)))
+	}
+}
+
+// cancelAnyAppointment lets staff cancel any booking, and patients their own, by its public ID.
+func cancelAnyAppointment(service AppointmentCanceller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("invalid appointment id")))
+			return
+		}
+		appt, err := service.Cancel(r.Context(), id)
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error cancelling appointment:")
+			return
+		}
+		_ = render.Render(w, r, NewAppointmentResponse(appt, publicURL(r)))
 	}
 }
 
@@ -110,10 +134,16 @@ func (a AppointmentResponse) Render(_ http.ResponseWriter, _ *http.Request) erro
 	return nil
 }
 
-func NewAppointmentResponse(appointment *domain.Appointment) AppointmentResponse {
+// NewAppointmentResponse links to the appointment's calendar download under baseURL, see publicURL.
+func NewAppointmentResponse(appointment *domain.Appointment, baseURL string) AppointmentResponse {
 	return AppointmentResponse{
-		FirstName: appointment.FirstName,
-		LastName:  appointment.LastName,
-		VisitDate: (*VisitDate)(appointment.VisitDate),
+		ID:            appointment.PublicID,
+		FirstName:     appointment.FirstName,
+		LastName:      appointment.LastName,
+		VisitDate:     (*VisitDate)(appointment.VisitDate),
+		Email:         appointment.Email,
+		Phone:         appointment.Phone,
+		Status:        appointment.Status,
+		AddToCalendar: calendarURL(baseURL, appointment),
 	}
 }
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jcooney/appts/api"
 	"github.com/jcooney/appts/domain"
 	"github.com/stretchr/testify/require"
@@ -32,6 +33,7 @@ func TestCreateAppointment(t *testing.T) {
 			request: api.AppointmentRequest{
 				LastName:  "Doe",
 				VisitDate: now,
+				HoldToken: "token",
 			},
 			wantStatus:  http.StatusBadRequest,
 			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'AppointmentRequest.FirstName' Error:Field validation for 'FirstName' failed on the 'required' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
@@ -41,6 +43,7 @@ func TestCreateAppointment(t *testing.T) {
 			request: api.AppointmentRequest{
 				FirstName: "John",
 				VisitDate: now,
+				HoldToken: "token",
 			},
 			wantStatus:  http.StatusBadRequest,
 			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'AppointmentRequest.LastName' Error:Field validation for 'LastName' failed on the 'required' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
@@ -50,23 +53,83 @@ func TestCreateAppointment(t *testing.T) {
 			request: api.AppointmentRequest{
 				FirstName: "John",
 				LastName:  "Doe",
+				HoldToken: "token",
 			},
 			wantStatus:  http.StatusBadRequest,
 			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'AppointmentRequest.VisitDate' Error:Field validation for 'VisitDate' failed on the 'required' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
 		},
 		{
-			name: "201 when all fields are present",
+			name: "400 when hold token is missing",
+			request: api.AppointmentRequest{
+				FirstName: "John",
+				LastName:  "Doe",
+				VisitDate: now,
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'AppointmentRequest.HoldToken' Error:Field validation for 'HoldToken' failed on the 'required' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name: "400 when email is invalid",
+			request: api.AppointmentRequest{
+				FirstName: "John",
+				LastName:  "Doe",
+				VisitDate: now,
+				HoldToken: "token",
+				Email:     "john.example.com",
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'AppointmentRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name: "400 when phone is not in E.164 format",
+			request: api.AppointmentRequest{
+				FirstName: "John",
+				LastName:  "Doe",
+				VisitDate: now,
+				HoldToken: "token",
+				Phone:     "07700 900123",
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'AppointmentRequest.Phone' Error:Field validation for 'Phone' failed on the 'e164' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name: "201 with contact details",
 			request: api.AppointmentRequest{
 				FirstName: "John",
 				LastName:  "Doe",
 				VisitDate: ptr.To(api.VisitDate(time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC))),
+				HoldToken: "token",
+				Email:     "john@example.com",
+				Phone:     "+447700900123",
 			},
 			wantStatus:  http.StatusCreated,
 			mockService: success{},
 			wantResponse: &api.AppointmentResponse{
+				ID:            testPublicID,
+				FirstName:     "John",
+				LastName:      "Doe",
+				VisitDate:     ptr.To(api.VisitDate(time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC))),
+				Email:         "john@example.com",
+				Phone:         "+447700900123",
+				AddToCalendar: "/appts/019b8e2a-6c00-7000-8000-000000000001.ics",
+			},
+		},
+		{
+			name: "201 when all fields are present",
+			request: api.AppointmentRequest{
 				FirstName: "John",
 				LastName:  "Doe",
 				VisitDate: ptr.To(api.VisitDate(time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC))),
+				HoldToken: "token",
+			},
+			wantStatus:  http.StatusCreated,
+			mockService: success{},
+			wantResponse: &api.AppointmentResponse{
+				ID:            testPublicID,
+				FirstName:     "John",
+				LastName:      "Doe",
+				VisitDate:     ptr.To(api.VisitDate(time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC))),
+				AddToCalendar: "/appts/019b8e2a-6c00-7000-8000-000000000001.ics",
 			},
 		},
 		{
@@ -75,6 +138,7 @@ func TestCreateAppointment(t *testing.T) {
 				FirstName: "John",
 				LastName:  "Doe",
 				VisitDate: ptr.To(api.VisitDate(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))), // Christmas, assuming it's a public holiday
+				HoldToken: "token",
 			},
 			wantStatus:  http.StatusInternalServerError,
 			wantErrBody: &api.ErrResponse{ErrorText: "internal server error", StatusText: "Internal Server Error", HTTPStatusCode: 500},
@@ -86,6 +150,7 @@ func TestCreateAppointment(t *testing.T) {
 				FirstName: "Jane",
 				LastName:  "Doe",
 				VisitDate: ptr.To(api.VisitDate(time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC))), // Assuming July 4th is already booked
+				HoldToken: "token",
 			},
 			wantStatus:  http.StatusConflict,
 			wantErrBody: &api.ErrResponse{ErrorText: "appointment date already taken", StatusText: "Conflict", HTTPStatusCode: 409},
@@ -97,6 +162,7 @@ func TestCreateAppointment(t *testing.T) {
 				FirstName: "Jane",
 				LastName:  "Doe",
 				VisitDate: ptr.To(api.VisitDate(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))), // Christmas
+				HoldToken: "token",
 			},
 			wantStatus:  http.StatusBadRequest,
 			mockService: publicHoliday{},
@@ -108,11 +174,24 @@ func TestCreateAppointment(t *testing.T) {
 				FirstName: "Jane",
 				LastName:  "Doe",
 				VisitDate: ptr.To(api.VisitDate(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))),
+				HoldToken: "token",
 			},
 			wantStatus:  http.StatusBadRequest,
 			wantErrBody: &api.ErrResponse{ErrorText: "cannot book appointment in the past", StatusText: "Bad Request", HTTPStatusCode: 400},
 			mockService: dateInPast{},
 		},
+		{
+			name: "409 when hold is not found or expired",
+			request: api.AppointmentRequest{
+				FirstName: "Jane",
+				LastName:  "Doe",
+				VisitDate: ptr.To(api.VisitDate(time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC))),
+				HoldToken: "expired",
+			},
+			wantStatus:  http.StatusConflict,
+			wantErrBody: &api.ErrResponse{ErrorText: "hold not found or expired", StatusText: "Conflict", HTTPStatusCode: 409},
+			mockService: holdNotFound{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -147,6 +226,9 @@ func TestCreateAppointment(t *testing.T) {
 				require.Equal(t, tt.wantResponse.FirstName, got.FirstName)
 				require.Equal(t, tt.wantResponse.LastName, got.LastName)
 				require.Equal(t, *tt.wantResponse.VisitDate, *got.VisitDate)
+				require.Equal(t, tt.wantResponse.Email, got.Email)
+				require.Equal(t, tt.wantResponse.Phone, got.Phone)
+				require.Equal(t, tt.wantResponse.AddToCalendar, got.AddToCalendar)
 			}
 			if tt.wantErrBody != nil {
 				all, err := io.ReadAll(resp.Body)
@@ -187,7 +269,7 @@ func TestCreateAppointmentJson(t *testing.T) {
 		},
 		{
 			name:        "200 when valid JSON",
-			requestBody: `{	"firstName": "John","lastName": "Doe", "visitDate": "2024-07-15" }`,
+			requestBody: `{	"firstName": "John","lastName": "Doe", "visitDate": "2024-07-15", "holdToken": "token" }`,
 			wantStatus:  http.StatusCreated,
 		},
 	}
@@ -231,9 +313,13 @@ func TestCreateAppointmentJson(t *testing.T) {
 type success struct{}
 
 func (s success) Create(_ context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	appt.ID = 1
+	appt.PublicID = testPublicID
 	return appt, nil
 }
 
+var testPublicID = uuid.MustParse("019b8e2a-6c00-7000-8000-000000000001")
+
 type unhandlerError struct{}
 
 func (u unhandlerError) Create(_ context.Context, _ *domain.Appointment) (*domain.Appointment, error) {
@@ -257,3 +343,9 @@ type dateInPast struct{}
 func (d dateInPast) Create(_ context.Context, _ *domain.Appointment) (*domain.Appointment, error) {
 	return nil, domain.ErrAppointmentInPast
 }
+
+type holdNotFound struct{}
+
+func (h holdNotFound) Create(_ context.Context, _ *domain.Appointment) (*domain.Appointment, error) {
+	return nil, domain.ErrHoldNotFound
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+)
+
+type AuditEntryResponse struct {
+	Action    domain.AuditAction   `json:"action"`
+	Actor     string               `json:"actor"`
+	RequestID string               `json:"requestId,omitempty"`
+	Before    *AppointmentResponse `json:"before,omitempty"` // absent when the appointment was booked
+	After     AppointmentResponse  `json:"after"`
+	At        time.Time            `json:"at"`
+}
+
+type AppointmentHistoryResponse struct {
+	ID      uuid.UUID            `json:"id"`
+	History []AuditEntryResponse `json:"history"`
+}
+
+type AppointmentHistorian interface {
+	History(ctx context.Context, id uuid.UUID) ([]*domain.AuditEntry, error)
+}
+
+// withRequestID hands the ID from middleware.RequestID to the domain, so audit entries can be traced to the request.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			r = r.WithContext(domain.WithRequestID(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func appointmentHistory(service AppointmentHistorian) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("invalid appointment id")))
+			return
+		}
+		entries, err := service.History(r.Context(), id)
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error getting appointment history:")
+			return
+		}
+		resp := AppointmentHistoryResponse{ID: id, History: make([]AuditEntryResponse, 0, len(entries))}
+		for _, entry := range entries {
+			resp.History = append(resp.History, NewAuditEntryResponse(entry, publicURL(r)))
+		}
+		_ = render.Render(w, r, resp)
+	}
+}
+
+func (a AppointmentHistoryResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewAuditEntryResponse(entry *domain.AuditEntry, baseURL string) AuditEntryResponse {
+	resp := AuditEntryResponse{
+		Action:    entry.Action,
+		Actor:     entry.Actor,
+		RequestID: entry.RequestID,
+		After:     NewAppointmentResponse(entry.After, baseURL),
+		At:        entry.CreatedAt,
+	}
+	if entry.Before != nil {
+		before := NewAppointmentResponse(entry.Before, baseURL)
+		resp.Before = &before
+	}
+	return resp
+}
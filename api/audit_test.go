@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestAppointmentHistory(t *testing.T) {
+	booked := &domain.Appointment{
+		PublicID:  uuid.MustParse("019b8e2a-6c00-7000-8000-000000000001"),
+		FirstName: "John",
+		LastName:  "Doe",
+		VisitDate: ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)),
+		Status:    domain.AppointmentStatusBooked,
+	}
+	cancelled := *booked
+	cancelled.Status = domain.AppointmentStatusCancelled
+	at := time.Date(2026, 1, 2, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		historian  *fakeHistorian
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "200 with every change, oldest first",
+			path: "/appts/019b8e2a-6c00-7000-8000-000000000001/history",
+			historian: &fakeHistorian{entries: []*domain.AuditEntry{
+				{Action: domain.AuditBooked, Actor: "api-key:3", RequestID: "host/abc-000001", After: booked, CreatedAt: at},
+				{Action: domain.AuditCancelled, Actor: "jwt:user-1", Before: booked, After: &cancelled, CreatedAt: at.Add(time.Hour)},
+			}},
+			wantStatus: http.StatusOK,
+			wantBody: `{"id":"019b8e2a-6c00-7000-8000-000000000001","history":[
+				{"action":"booked","actor":"api-key:3","requestId":"host/abc-000001","at":"2026-01-02T09:30:00Z",
+				 "after":{"id":"019b8e2a-6c00-7000-8000-000000000001","firstName":"John","lastName":"Doe","visitDate":"2026-01-05","status":"booked","addToCalendar":"/appts/019b8e2a-6c00-7000-8000-000000000001.ics"}},
+				{"action":"cancelled","actor":"jwt:user-1","at":"2026-01-02T10:30:00Z",
+				 "before":{"id":"019b8e2a-6c00-7000-8000-000000000001","firstName":"John","lastName":"Doe","visitDate":"2026-01-05","status":"booked","addToCalendar":"/appts/019b8e2a-6c00-7000-8000-000000000001.ics"},
+				 "after":{"id":"019b8e2a-6c00-7000-8000-000000000001","firstName":"John","lastName":"Doe","visitDate":"2026-01-05","status":"cancelled","addToCalendar":"/appts/019b8e2a-6c00-7000-8000-000000000001.ics"}}
+			]}`,
+		},
+		{
+			name:       "404 for an unknown appointment",
+			path:       "/appts/019b8e2a-6c00-7000-8000-000000000002/history",
+			historian:  &fakeHistorian{err: domain.ErrAppointmentNotFound},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":404,"status":"Not Found","error":"appointment not found"}`,
+		},
+		{
+			name:       "400 for an internal id",
+			path:       "/appts/1/history",
+			historian:  &fakeHistorian{},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"code":400,"status":"Bad Request","error":"invalid appointment id"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ChiHandler(api.Services{History: tt.historian}, nil))
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL + tt.path)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.wantBody, string(all))
+		})
+	}
+}
+
+func TestRequestIDReachesTheDomain(t *testing.T) {
+	historian := &fakeHistorian{}
+	ts := httptest.NewServer(api.ChiHandler(api.Services{History: historian}, nil))
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/appts/019b8e2a-6c00-7000-8000-000000000001/history", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-Id", "upstream-42")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "upstream-42", historian.requestID)
+}
+
+type fakeHistorian struct {
+	entries   []*domain.AuditEntry
+	err       error
+	requestID string
+}
+
+func (f *fakeHistorian) History(ctx context.Context, _ uuid.UUID) ([]*domain.AuditEntry, error) {
+	f.requestID = domain.RequestIDFromContext(ctx)
+	return f.entries, f.err
+}
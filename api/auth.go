@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+)
+
+type APIKeyRequest struct {
+	Name string      `json:"name" validate:"required,max=100"`
+	Role domain.Role `json:"role" validate:"required,oneof=admin receptionist patient integration"`
+}
+
+type APIKeyResponse struct {
+	ID        int32       `json:"id"`
+	Name      string      `json:"name"`
+	Role      domain.Role `json:"role"`
+	Prefix    string      `json:"prefix"`
+	Key       string      `json:"key,omitempty"` // only returned when the key is created
+	CreatedAt time.Time   `json:"createdAt"`
+	RevokedAt *time.Time  `json:"revokedAt,omitempty"`
+}
+
+type APIKeyListResponse struct {
+	APIKeys []APIKeyResponse `json:"apiKeys"`
+}
+
+type APIKeyManager interface {
+	Create(ctx context.Context, name string, role domain.Role) (*domain.APIKey, string, error)
+	List(ctx context.Context) ([]*domain.APIKey, error)
+	Revoke(ctx context.Context, id int32) error
+	Authenticate(ctx context.Context, key string) (*domain.Principal, error)
+}
+
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*domain.Principal, error)
+}
+
+// authenticate requires an `Authorization: Bearer` api key or JWT and stores the principal in the request context.
+// Bearer values starting with domain.APIKeyPrefix are api keys, anything else is a JWT and rejected when tokens is nil.
+func authenticate(keys APIKeyManager, tokens TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credential, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || credential == "" {
+				renderUnauthenticated(w, r)
+				return
+			}
+
+			var principal *domain.Principal
+			var err error
+			switch {
+			case strings.HasPrefix(credential, domain.APIKeyPrefix):
+				principal, err = keys.Authenticate(r.Context(), credential)
+			case tokens != nil:
+				principal, err = tokens.Verify(r.Context(), credential)
+			default:
+				err = domain.ErrUnauthenticated
+			}
+			if err != nil {
+				if errors.Is(err, domain.ErrUnauthenticated) {
+					slog.Debug("rejected credentials", "error", err)
+					renderUnauthenticated(w, r)
+					return
+				}
+				renderServiceError(w, r, err, "unknown error authenticating request:")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(domain.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// authorize rejects principals whose roles do not grant permission on any appointment, it must run after authenticate.
+func authorize(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := domain.PrincipalFromContext(r.Context())
+			if !ok {
+				renderUnauthenticated(w, r)
+				return
+			}
+			if !principal.Can(permission) {
+				_ = render.Render(w, r, errStatus(http.StatusForbidden, fmt.Errorf("%w: requires %s", domain.ErrForbidden, permission)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorizeAppointment rejects principals only granted permission on their own appointments when the appointment
+// named by the id URL parameter is someone else's, it must run after authorize.
+func authorizeAppointment(service AppointmentGetter, permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := domain.PrincipalFromContext(r.Context())
+			if !ok || principal.Scope(permission) == domain.ScopeAll {
+				next.ServeHTTP(w, r)
+				return
+			}
+			id, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil {
+				_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("invalid appointment id")))
+				return
+			}
+			appt, err := service.GetByPublicID(r.Context(), id)
+			if err != nil {
+				renderServiceError(w, r, err, "unknown error getting appointment:")
+				return
+			}
+			if !principal.CanAccess(permission, appt) {
+				renderNotOwned(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bookingEmail is the email to book under. Principals only granted booking their own appointments book under their
+// own email, which email defaults to and may not differ from.
+func bookingEmail(r *http.Request, email string) (string, bool) {
+	principal, ok := domain.PrincipalFromContext(r.Context())
+	if !ok || principal.Scope(domain.PermissionBook) == domain.ScopeAll {
+		return email, true
+	}
+	if principal.Email == "" || (email != "" && !strings.EqualFold(email, principal.Email)) {
+		return "", false
+	}
+	return principal.Email, true
+}
+
+// canAccess reports whether the request's principal may use permission on appt, always true when auth is disabled.
+func canAccess(r *http.Request, permission domain.Permission, appt *domain.Appointment) bool {
+	principal, ok := domain.PrincipalFromContext(r.Context())
+	return !ok || principal.CanAccess(permission, appt)
+}
+
+func renderNotOwned(w http.ResponseWriter, r *http.Request) {
+	_ = render.Render(w, r, errStatus(http.StatusForbidden, fmt.Errorf("%w: not your appointment", domain.ErrForbidden)))
+}
+
+func renderUnauthenticated(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	_ = render.Render(w, r, errStatus(http.StatusUnauthorized, domain.ErrUnauthenticated))
+}
+
+func createAPIKey(service APIKeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &APIKeyRequest{}
+		if err := render.Bind(r, req); err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+
+		key, secret, err := service.Create(r.Context(), req.Name, req.Role)
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error creating api key:")
+			return
+		}
+		resp := NewAPIKeyResponse(key)
+		resp.Key = secret
+		render.Status(r, http.StatusCreated)
+		_ = render.Render(w, r, resp)
+	}
+}
+
+func listAPIKeys(service APIKeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := service.List(r.Context())
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error listing api keys:")
+			return
+		}
+		resp := APIKeyListResponse{APIKeys: make([]APIKeyResponse, 0, len(keys))}
+		for _, key := range keys {
+			resp.APIKeys = append(resp.APIKeys, NewAPIKeyResponse(key))
+		}
+		_ = render.Render(w, r, resp)
+	}
+}
+
+func revokeAPIKey(service APIKeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("invalid api key id")))
+			return
+		}
+		if err := service.Revoke(r.Context(), int32(id)); err != nil {
+			renderServiceError(w, r, err, "unknown error revoking api key:")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (a *APIKeyRequest) Bind(_ *http.Request) error {
+	v := validator.New()
+	if err := v.Struct(a); err != nil {
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			return ve
+		}
+		return err
+	}
+	return nil
+}
+
+func (a APIKeyResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (a APIKeyListResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewAPIKeyResponse(key *domain.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Role:      key.Role,
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/ics"
+	"k8s.io/utils/ptr"
+)
+
+// calendarLookback is how far back the feed goes when no from date is given, keeping subscribed calendars small.
+const calendarLookback = 90 * 24 * time.Hour
+
+type AppointmentGetter interface {
+	GetByPublicID(ctx context.Context, id uuid.UUID) (*domain.Appointment, error)
+}
+
+// CalendarFeeds issues the tokens in calendar feed urls and resolves them back to the feed they grant.
+type CalendarFeeds interface {
+	Issue(ctx context.Context) (string, *domain.CalendarFeed, error)
+	Parse(token string) (*domain.CalendarFeed, error)
+}
+
+type CalendarFeedResponse struct {
+	URL       string    `json:"url"` // to subscribe to, the token in it is the only credential needed
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// createCalendarFeed issues a feed url of the bookings the caller may read, for calendar apps that cannot send an
+// Authorization header.
+func createCalendarFeed(feeds CalendarFeeds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, feed, err := feeds.Issue(r.Context())
+		if err != nil {
+			if errors.Is(err, domain.ErrForbidden) {
+				_ = render.Render(w, r, errStatus(http.StatusForbidden, err))
+				return
+			}
+			renderServiceError(w, r, err, "unknown error issuing calendar feed:")
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		_ = render.Render(w, r, CalendarFeedResponse{
+			URL:       fmt.Sprintf("%s/calendar/%s.ics", publicURL(r), token),
+			ExpiresAt: feed.ExpiresAt.UTC(),
+		})
+	}
+}
+
+// calendarFeed streams the matching appointments of the feed named by the token URL parameter as a subscribable
+// calendar. Every deployment serves the single clinic at location, so there is no location to choose.
+func calendarFeed(service AppointmentLister, feeds CalendarFeeds, location *domain.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed, err := feeds.Parse(chi.URLParam(r, "token"))
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error resolving calendar feed:")
+			return
+		}
+		filter, err := parseAppointmentFilter(r)
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+		if filter.From == nil {
+			filter.From = ptr.To(time.Now().Add(-calendarLookback).UTC().Truncate(24 * time.Hour))
+		}
+
+		stamp := time.Now()
+		var cal *ics.Writer
+		start := func() {
+			w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+			cal = ics.NewWriter(w, newCalendar(location))
+		}
+		err = service.List(r.Context(), filter, func(appt *domain.Appointment) error {
+			if !feed.Includes(appt) {
+				return nil
+			}
+			if cal == nil {
+				start()
+			}
+			return cal.WriteEvent(NewCalendarEvent(appt, location, stamp))
+		})
+		if err != nil && cal == nil {
+			renderListError(w, r, err, "unknown error rendering calendar feed:")
+			return
+		}
+		if err != nil {
+			slog.Error("error streaming calendar feed:", "error", err)
+			return
+		}
+		if cal == nil {
+			start()
+		}
+		if err := cal.Close(); err != nil {
+			slog.Warn("error writing calendar feed:", "error", err)
+		}
+	}
+}
+
+// appointmentICS serves a single appointment as a downloadable calendar file.
+func appointmentICS(service AppointmentGetter, location *domain.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("invalid appointment id")))
+			return
+		}
+		appt, err := service.GetByPublicID(r.Context(), id)
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error getting appointment:")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="appointment-%s.ics"`, appt.PublicID))
+		cal := ics.NewWriter(w, newCalendar(location))
+		if err := cal.WriteEvent(NewCalendarEvent(appt, location, time.Now())); err != nil {
+			slog.Warn("error writing appointment calendar:", "error", err)
+			return
+		}
+		if err := cal.Close(); err != nil {
+			slog.Warn("error writing appointment calendar:", "error", err)
+		}
+	}
+}
+
+func (c CalendarFeedResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func newCalendar(location *domain.Location) ics.Calendar {
+	cal := ics.Calendar{Name: "Appointments"}
+	if location != nil {
+		if location.Name != "" {
+			cal.Name = location.Name
+		}
+		cal.TimeZone = location.TimeZone.String()
+	}
+	return cal
+}
+
+type publicURLKey struct{}
+
+// withPublicURL makes the links in responses absolute, publicURL being the api as patients reach it.
+func withPublicURL(publicURL string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), publicURLKey{}, publicURL)))
+		})
+	}
+}
+
+// publicURL is the base links in responses are built from, without a trailing slash. It is empty when withPublicURL
+// is not in use, leaving links relative.
+func publicURL(r *http.Request) string {
+	base, _ := r.Context().Value(publicURLKey{}).(string)
+	return base
+}
+
+// calendarURL is the url of the single event download for an appointment.
+func calendarURL(baseURL string, appt *domain.Appointment) string {
+	return fmt.Sprintf("%s/appts/%s.ics", baseURL, appt.PublicID)
+}
+
+// NewCalendarEvent renders an appointment as an all-day event stamped with when the calendar was generated. Its UID only
+// depends on the appointment's public ID and its SEQUENCE goes up with every cancellation or reschedule, so calendar
+// clients replace rather than duplicate or ignore it when it changes. The visit date is a floating date, so it falls on
+// the same day wherever the calendar is viewed.
+func NewCalendarEvent(appt *domain.Appointment, location *domain.Location, stamp time.Time) ics.Event {
+	status := ics.StatusConfirmed
+	if appt.Status == domain.AppointmentStatusCancelled {
+		status = ics.StatusCancelled
+	}
+	event := ics.Event{
+		UID:          fmt.Sprintf("%s@appts", appt.PublicID),
+		Stamp:        stamp,
+		Sequence:     appt.Sequence,
+		LastModified: appt.UpdatedAt,
+		Date:         *appt.VisitDate,
+		Summary:      fmt.Sprintf("Appointment: %s %s", appt.FirstName, appt.LastName),
+		Status:       status,
+	}
+	if location != nil {
+		event.Location = location.String()
+	}
+	return event
+}
@@ -0,0 +1,290 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	location, err := domain.NewLocation("High Street Clinic", "1 High Street, London", "Europe/London")
+	require.NoError(t, err)
+	createdAt := time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC)
+	cancelledAt := time.Date(2025, 12, 3, 14, 0, 0, 0, time.UTC)
+	appts := []*domain.Appointment{
+		{ID: 1, PublicID: uuid.MustParse("019b8e2a-6c00-7000-8000-000000000001"), FirstName: "John", LastName: "Doe", Email: "john@example.com", VisitDate: ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)), Status: domain.AppointmentStatusBooked, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{ID: 2, PublicID: uuid.MustParse("019b8e2a-6c00-7000-8000-000000000002"), FirstName: "Jane", LastName: "Doe", VisitDate: ptr.To(time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)), Status: domain.AppointmentStatusCancelled, CreatedAt: createdAt, UpdatedAt: cancelledAt, Sequence: 1},
+	}
+	tests := []struct {
+		name         string
+		query        string
+		feeds        fakeFeeds
+		mockService  *listAppointments
+		wantStatus   int
+		wantContains []string
+		wantMissing  []string
+		wantErrBody  *api.ErrResponse
+	}{
+		{
+			name:        "renders booked and cancelled appointments",
+			query:       "?from=2026-01-01",
+			mockService: &listAppointments{appts: appts},
+			wantStatus:  http.StatusOK,
+			wantContains: []string{
+				"BEGIN:VCALENDAR\r\n",
+				"UID:019b8e2a-6c00-7000-8000-000000000001@appts\r\nDTSTAMP:",
+				"SEQUENCE:0\r\nLAST-MODIFIED:20251201T093000Z\r\nDTSTART;VALUE=DATE:20260105\r\nDTEND;VALUE=DATE:20260106\r\nSUMMARY:Appointment: John Doe\r\n",
+				"UID:019b8e2a-6c00-7000-8000-000000000002@appts\r\n",
+				"SEQUENCE:1\r\nLAST-MODIFIED:20251203T140000Z\r\n",
+				"STATUS:CANCELLED\r\n",
+				"END:VCALENDAR\r\n",
+			},
+		},
+		{
+			name:         "only the patient's own bookings in their feed",
+			feeds:        fakeFeeds{feed: &domain.CalendarFeed{Email: "John@Example.com"}},
+			mockService:  &listAppointments{appts: appts},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"SUMMARY:Appointment: John Doe\r\n"},
+			wantMissing:  []string{"SUMMARY:Appointment: Jane Doe\r\n"},
+		},
+		{
+			name:        "404 for an invalid or expired token",
+			feeds:       fakeFeeds{err: domain.ErrInvalidCalendarFeed},
+			wantStatus:  http.StatusNotFound,
+			wantErrBody: &api.ErrResponse{ErrorText: "invalid or expired calendar feed", StatusText: "Not Found", HTTPStatusCode: 404},
+		},
+		{
+			name:         "named after the clinic",
+			mockService:  &listAppointments{appts: appts},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"X-WR-CALNAME:High Street Clinic\r\n", "SUMMARY:Appointment: John Doe\r\n"},
+		},
+		{
+			name:         "empty calendar when nothing matches",
+			mockService:  &listAppointments{},
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"BEGIN:VCALENDAR\r\n", "END:VCALENDAR\r\n"},
+		},
+		{
+			name:        "400 when to is not a date",
+			query:       "?to=tomorrow",
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: `invalid to: parsing time "tomorrow" as "2006-01-02": cannot parse "tomorrow" as "2006"`, StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "500 when listing fails",
+			mockService: &listAppointments{err: errors.New("unhandled error")},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrBody: &api.ErrResponse{ErrorText: "internal server error", StatusText: "Internal Server Error", HTTPStatusCode: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.feeds.feed == nil && tt.feeds.err == nil {
+				tt.feeds.feed = &domain.CalendarFeed{}
+			}
+			ts := httptest.NewServer(api.ChiHandler(api.Services{Lister: tt.mockService, Feeds: tt.feeds}, location))
+			defer ts.Close()
+
+			requested := time.Now().Truncate(time.Second)
+			resp, err := http.Get(ts.URL + "/calendar/token.ics" + tt.query)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantErrBody != nil {
+				var gotErr api.ErrResponse
+				require.NoError(t, json.Unmarshal(all, &gotErr))
+				require.Equal(t, *tt.wantErrBody, gotErr)
+				return
+			}
+			require.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+			for _, want := range tt.wantContains {
+				require.Contains(t, string(all), want)
+			}
+			for _, missing := range tt.wantMissing {
+				require.NotContains(t, string(all), missing)
+			}
+			for _, line := range strings.Split(string(all), "\r\n") {
+				if stamp, ok := strings.CutPrefix(line, "DTSTAMP:"); ok {
+					generated, err := time.Parse("20060102T150405Z", stamp)
+					require.NoError(t, err)
+					require.False(t, generated.Before(requested), "DTSTAMP should be when the feed was generated")
+				}
+			}
+			require.NotNil(t, tt.mockService.gotFilter.From, "feed should default to a bounded window")
+			if tt.query == "" {
+				require.True(t, tt.mockService.gotFilter.From.Before(time.Now()))
+			}
+			require.True(t, strings.HasPrefix(string(all), "BEGIN:VCALENDAR"))
+		})
+	}
+}
+
+func TestCreateCalendarFeed(t *testing.T) {
+	expires := time.Date(2027, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		feeds      fakeFeeds
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "201 with the feed url under the public url",
+			feeds:      fakeFeeds{feed: &domain.CalendarFeed{ExpiresAt: expires}},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"url":"https://appts.example.com/calendar/token.ics","expiresAt":"2027-01-05T12:00:00Z"}`,
+		},
+		{
+			name:       "403 when the caller may not read bookings",
+			feeds:      fakeFeeds{err: fmt.Errorf("%w: requires appointments:read", domain.ErrForbidden)},
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"code":403,"status":"Forbidden","error":"forbidden: requires appointments:read"}`,
+		},
+		{
+			name:       "500 when issuing fails",
+			feeds:      fakeFeeds{err: errors.New("boom")},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"code":500,"status":"Internal Server Error","error":"internal server error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ChiHandler(api.Services{Feeds: tt.feeds, PublicURL: "https://appts.example.com"}, nil))
+			defer ts.Close()
+
+			resp, err := http.Post(ts.URL+"/calendar-feeds", "application/json", nil)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.wantBody, string(all))
+		})
+	}
+}
+
+func TestAppointmentICS(t *testing.T) {
+	location, err := domain.NewLocation("High Street Clinic", "1 High Street, London", "Europe/London")
+	require.NoError(t, err)
+	appt := &domain.Appointment{ID: 42, PublicID: uuid.MustParse("019b8e2a-6c00-7000-8000-00000000002a"), FirstName: "John", LastName: "Doe", VisitDate: ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)), Status: domain.AppointmentStatusBooked, CreatedAt: time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC)}
+	tests := []struct {
+		name         string
+		path         string
+		mockService  api.AppointmentGetter
+		wantStatus   int
+		wantContains []string
+		wantErrBody  *api.ErrResponse
+	}{
+		{
+			name:        "renders the appointment with the clinic location",
+			path:        "/appts/019b8e2a-6c00-7000-8000-00000000002a.ics",
+			mockService: getAppointment{appt: appt},
+			wantStatus:  http.StatusOK,
+			wantContains: []string{
+				"X-WR-CALNAME:High Street Clinic\r\nX-WR-TIMEZONE:Europe/London\r\n",
+				"UID:019b8e2a-6c00-7000-8000-00000000002a@appts\r\n",
+				"DTSTART;VALUE=DATE:20260105\r\n",
+				`LOCATION:High Street Clinic\, 1 High Street\, London` + "\r\n",
+			},
+		},
+		{
+			name:        "404 when the appointment does not exist",
+			path:        "/appts/019b8e2a-6c00-7000-8000-00000000002b.ics",
+			mockService: getAppointment{err: domain.ErrAppointmentNotFound},
+			wantStatus:  http.StatusNotFound,
+			wantErrBody: &api.ErrResponse{ErrorText: "appointment not found", StatusText: "Not Found", HTTPStatusCode: 404},
+		},
+		{
+			name:        "400 when the id is not a public id",
+			path:        "/appts/abc.ics",
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "invalid appointment id", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when given the internal id",
+			path:        "/appts/42.ics",
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "invalid appointment id", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ChiHandler(api.Services{Getter: tt.mockService}, location))
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL + tt.path)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantErrBody != nil {
+				var gotErr api.ErrResponse
+				require.NoError(t, json.Unmarshal(all, &gotErr))
+				require.Equal(t, *tt.wantErrBody, gotErr)
+				return
+			}
+			require.Equal(t, `attachment; filename="appointment-019b8e2a-6c00-7000-8000-00000000002a.ics"`, resp.Header.Get("Content-Disposition"))
+			for _, want := range tt.wantContains {
+				require.Contains(t, string(all), want)
+			}
+		})
+	}
+}
+
+type getAppointment struct {
+	appt *domain.Appointment
+	err  error
+}
+
+func (g getAppointment) GetByPublicID(_ context.Context, _ uuid.UUID) (*domain.Appointment, error) {
+	return g.appt, g.err
+}
+
+// fakeFeeds issues "token" for feed, and resolves any token to it.
+type fakeFeeds struct {
+	feed *domain.CalendarFeed
+	err  error
+}
+
+func (f fakeFeeds) Issue(_ context.Context) (string, *domain.CalendarFeed, error) {
+	if f.err != nil {
+		return "", nil, f.err
+	}
+	return "token", f.feed, nil
+}
+
+func (f fakeFeeds) Parse(_ string) (*domain.CalendarFeed, error) {
+	return f.feed, f.err
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/render"
@@ -11,6 +13,55 @@ var errorMap = map[error]int{
 	domain.ErrAppointmentOnPublicHoliday: http.StatusBadRequest,
 	domain.ErrAppointmentDateTaken:       http.StatusConflict,
 	domain.ErrAppointmentInPast:          http.StatusBadRequest,
+	domain.ErrHoldNotFound:               http.StatusConflict,
+	domain.ErrAppointmentNotFound:        http.StatusNotFound,
+	domain.ErrInvalidManageToken:         http.StatusUnauthorized,
+	domain.ErrInvalidCalendarFeed:        http.StatusNotFound,
+	domain.ErrAppointmentCancelled:       http.StatusConflict,
+	domain.ErrWebhookNotFound:            http.StatusNotFound,
+	domain.ErrWebhookDeliveryNotFound:    http.StatusNotFound,
+	domain.ErrWebhookDeliveryNotDead:     http.StatusConflict,
+	domain.ErrAPIKeyNotFound:             http.StatusNotFound,
+	domain.ErrInvalidRole:                http.StatusBadRequest,
+	domain.ErrPatientBookingLimit:        http.StatusConflict,
+}
+
+// rejectionReasons gives clients a stable code for why a single item of a bulk request was not booked.
+var rejectionReasons = map[error]string{
+	domain.ErrAppointmentOnPublicHoliday: "holiday",
+	domain.ErrAppointmentDateTaken:       "taken",
+	domain.ErrAppointmentInPast:          "past",
+	domain.ErrSeriesRolledBack:           "rolled-back",
+	domain.ErrPatientBookingLimit:        "patient-limit",
+}
+
+// rejection returns the reason code and error text reported for an item of a bulk request, hiding unknown errors the
+// same way renderServiceError does.
+func rejection(err error, msg string) (string, string) {
+	if reason, ok := rejectionReasons[err]; ok {
+		return reason, err.Error()
+	}
+	if errors.Is(err, domain.ErrInvalidImportRow) {
+		return "invalid", err.Error()
+	}
+	slog.Error(msg, "error", err)
+	return "error", "internal server error"
+}
+
+// renderServiceError maps known domain errors to their http status, anything else is logged and hidden behind a 500.
+func renderServiceError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if code, ok := errorMap[err]; ok {
+		_ = render.Render(w, r, &ErrResponse{
+			HTTPStatusCode: code,
+			StatusText:     http.StatusText(code),
+			ErrorText:      err.Error(),
+		})
+		return
+	}
+	slog.Error(msg, "error", err)
+	if renderErr := render.Render(w, r, errInternalServerError()); renderErr != nil {
+		slog.Warn("error rendering response:", "render error", renderErr)
+	}
 }
 
 func errInvalidRequest(err error) render.Renderer {
@@ -21,6 +72,14 @@ func errInvalidRequest(err error) render.Renderer {
 	}
 }
 
+func errStatus(code int, err error) render.Renderer {
+	return &ErrResponse{
+		HTTPStatusCode: code,
+		StatusText:     http.StatusText(code),
+		ErrorText:      err.Error(),
+	}
+}
+
 func errInternalServerError() render.Renderer {
 	return &ErrResponse{
 		HTTPStatusCode: http.StatusInternalServerError,
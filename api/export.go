@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+)
+
+type AppointmentLister interface {
+	List(ctx context.Context, filter domain.AppointmentFilter, fn func(*domain.Appointment) error) error
+}
+
+// ExportRow is a single exported appointment, CSV columns use the same names and order as the JSON keys.
+type ExportRow struct {
+	ID        uuid.UUID  `json:"id"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	VisitDate *VisitDate `json:"visitDate"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+var exportCSVHeader = []string{"id", "firstName", "lastName", "visitDate", "status", "createdAt"}
+
+// exportWriter writes one row at a time straight to the response.
+type exportWriter interface {
+	WriteHeader() error
+	Write(row ExportRow) error
+	Flush() error
+}
+
+func exportAppointments(service AppointmentLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAppointmentFilter(r)
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+		var out exportWriter
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="appointments.csv"`)
+			out = &csvExportWriter{w: csv.NewWriter(w)}
+		case "ndjson":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="appointments.ndjson"`)
+			out = &ndjsonExportWriter{enc: json.NewEncoder(w)}
+		default:
+			_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("format must be csv or ndjson, got %q", format)))
+			return
+		}
+
+		// the status line is only written with the first row, so errors before then can still be reported properly
+		started := false
+		err = service.List(r.Context(), filter, func(appt *domain.Appointment) error {
+			if !canAccess(r, domain.PermissionRead, appt) {
+				return nil
+			}
+			if !started {
+				started = true
+				if err := out.WriteHeader(); err != nil {
+					return err
+				}
+			}
+			return out.Write(NewExportRow(appt))
+		})
+		if err != nil && !started {
+			renderListError(w, r, err, "unknown error exporting appointments:")
+			return
+		}
+		if err != nil {
+			// too late to change the status, the truncated body is all the client will see
+			slog.Error("error streaming appointment export:", "error", err)
+			return
+		}
+		if !started {
+			if err := out.WriteHeader(); err != nil {
+				return
+			}
+		}
+		if err := out.Flush(); err != nil {
+			slog.Warn("error flushing appointment export:", "error", err)
+		}
+	}
+}
+
+// renderListError reports an error from listing appointments that happened before anything was streamed.
+func renderListError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, domain.ErrInvalidFilter) {
+		_ = render.Render(w, r, errInvalidRequest(err))
+		return
+	}
+	renderServiceError(w, r, err, msg)
+}
+
+// parseAppointmentFilter reads the from, to and status query parameters shared by endpoints returning appointments.
+func parseAppointmentFilter(r *http.Request) (domain.AppointmentFilter, error) {
+	var filter domain.AppointmentFilter
+	query := r.URL.Query()
+	var err error
+	if filter.From, err = parseDateParam(query.Get("from")); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = parseDateParam(query.Get("to")); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	if v := query.Get("status"); v != "" {
+		status := domain.AppointmentStatus(v)
+		if !status.Valid() {
+			return filter, fmt.Errorf("invalid status %q", v)
+		}
+		filter.Status = status
+	}
+	return filter, nil
+}
+
+func parseDateParam(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func NewExportRow(appt *domain.Appointment) ExportRow {
+	return ExportRow{
+		ID:        appt.PublicID,
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+		VisitDate: (*VisitDate)(appt.VisitDate),
+		Status:    string(appt.Status),
+		CreatedAt: appt.CreatedAt.UTC(),
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) WriteHeader() error {
+	return c.w.Write(exportCSVHeader)
+}
+
+func (c *csvExportWriter) Write(row ExportRow) error {
+	return c.w.Write([]string{
+		row.ID.String(),
+		csvSafe(row.FirstName),
+		csvSafe(row.LastName),
+		row.VisitDate.Time().Format(time.DateOnly),
+		row.Status,
+		row.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+// csvSafe stops spreadsheets running a patient supplied field as a formula, by prefixing any field starting with a
+// character they treat as one with a quote.
+func csvSafe(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+func (c *csvExportWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonExportWriter) WriteHeader() error {
+	return nil
+}
+
+func (n *ndjsonExportWriter) Write(row ExportRow) error {
+	return n.enc.Encode(row)
+}
+
+func (n *ndjsonExportWriter) Flush() error {
+	return nil
+}
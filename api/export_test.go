@@ -0,0 +1,162 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestExportAppointments(t *testing.T) {
+	createdAt := time.Date(2025, 12, 1, 9, 30, 0, 0, time.UTC)
+	appts := []*domain.Appointment{
+		{ID: 1, PublicID: uuid.MustParse("019b8e2a-6c00-7000-8000-000000000001"), FirstName: "John", LastName: "Doe", VisitDate: ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)), Status: domain.AppointmentStatusBooked, CreatedAt: createdAt},
+		{ID: 2, PublicID: uuid.MustParse("019b8e2a-6c00-7000-8000-000000000002"), FirstName: "Jane", LastName: "Doe, Jr", VisitDate: ptr.To(time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)), Status: domain.AppointmentStatusBooked, CreatedAt: createdAt},
+	}
+	tests := []struct {
+		name            string
+		query           string
+		mockService     *listAppointments
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantErrBody     *api.ErrResponse
+		wantFilter      domain.AppointmentFilter
+	}{
+		{
+			name:            "csv by default",
+			mockService:     &listAppointments{appts: appts},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv",
+			wantBody: "id,firstName,lastName,visitDate,status,createdAt\n" +
+				"019b8e2a-6c00-7000-8000-000000000001,John,Doe,2026-01-05,booked,2025-12-01T09:30:00Z\n" +
+				"019b8e2a-6c00-7000-8000-000000000002,Jane,\"Doe, Jr\",2026-01-06,booked,2025-12-01T09:30:00Z\n",
+		},
+		{
+			name: "csv fields that spreadsheets would run as formulas are quoted",
+			mockService: &listAppointments{appts: []*domain.Appointment{
+				{ID: 3, PublicID: uuid.MustParse("019b8e2a-6c00-7000-8000-000000000003"), FirstName: `=HYPERLINK("https://example.com","click")`, LastName: "-Doe", VisitDate: ptr.To(time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)), Status: domain.AppointmentStatusBooked, CreatedAt: createdAt},
+				{ID: 4, PublicID: uuid.MustParse("019b8e2a-6c00-7000-8000-000000000004"), FirstName: "@SUM(A1)", LastName: "\tDoe", VisitDate: ptr.To(time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)), Status: domain.AppointmentStatusBooked, CreatedAt: createdAt},
+			}},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv",
+			wantBody: "id,firstName,lastName,visitDate,status,createdAt\n" +
+				"019b8e2a-6c00-7000-8000-000000000003,\"'=HYPERLINK(\"\"https://example.com\"\",\"\"click\"\")\",'-Doe,2026-01-07,booked,2025-12-01T09:30:00Z\n" +
+				"019b8e2a-6c00-7000-8000-000000000004,'@SUM(A1),'\tDoe,2026-01-08,booked,2025-12-01T09:30:00Z\n",
+		},
+		{
+			name:            "csv header only when nothing matches",
+			query:           "?format=csv",
+			mockService:     &listAppointments{},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv",
+			wantBody:        "id,firstName,lastName,visitDate,status,createdAt\n",
+		},
+		{
+			name:            "ndjson with filters",
+			query:           "?format=ndjson&from=2026-01-01&to=2026-01-31&status=booked",
+			mockService:     &listAppointments{appts: appts[:1]},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantBody:        `{"id":"019b8e2a-6c00-7000-8000-000000000001","firstName":"John","lastName":"Doe","visitDate":"2026-01-05","status":"booked","createdAt":"2025-12-01T09:30:00Z"}` + "\n",
+			wantFilter: domain.AppointmentFilter{
+				From:   ptr.To(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+				To:     ptr.To(time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)),
+				Status: domain.AppointmentStatusBooked,
+			},
+		},
+		{
+			name:        "400 when format is unknown",
+			query:       "?format=xml",
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: `format must be csv or ndjson, got "xml"`, StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when from is not a date",
+			query:       "?from=yesterday",
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: `invalid from: parsing time "yesterday" as "2006-01-02": cannot parse "yesterday" as "2006"`, StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when status is unknown",
+			query:       "?status=lost",
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: `invalid status "lost"`, StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when from is after to",
+			query:       "?from=2026-02-01&to=2026-01-01",
+			mockService: &listAppointments{err: domain.ErrInvalidFilter},
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "from date must not be after to date", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "500 when listing fails before the first row",
+			mockService: &listAppointments{err: errors.New("unhandled error")},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrBody: &api.ErrResponse{ErrorText: "internal server error", StatusText: "Internal Server Error", HTTPStatusCode: 500},
+		},
+		{
+			name:            "truncated when listing fails after the first row",
+			query:           "?format=ndjson",
+			mockService:     &listAppointments{appts: appts[:1], err: errors.New("connection reset")},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantBody:        `{"id":"019b8e2a-6c00-7000-8000-000000000001","firstName":"John","lastName":"Doe","visitDate":"2026-01-05","status":"booked","createdAt":"2025-12-01T09:30:00Z"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ExportAppointmentsFunc(tt.mockService))
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL + "/appts/export" + tt.query)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantErrBody != nil {
+				var gotErr api.ErrResponse
+				require.NoError(t, json.Unmarshal(all, &gotErr))
+				require.Equal(t, *tt.wantErrBody, gotErr)
+				return
+			}
+			require.Equal(t, tt.wantContentType, resp.Header.Get("Content-Type"))
+			require.Equal(t, tt.wantBody, string(all))
+			require.Equal(t, tt.wantFilter, tt.mockService.gotFilter)
+		})
+	}
+}
+
+// listAppointments yields appts and then fails with err, if set.
+type listAppointments struct {
+	appts     []*domain.Appointment
+	err       error
+	gotFilter domain.AppointmentFilter
+}
+
+func (l *listAppointments) List(_ context.Context, filter domain.AppointmentFilter, fn func(*domain.Appointment) error) error {
+	l.gotFilter = filter
+	for _, appt := range l.appts {
+		if err := fn(appt); err != nil {
+			return err
+		}
+	}
+	return l.err
+}
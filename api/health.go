@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/jcooney/appts/domain"
+)
+
+// HealthCheckResponse leaves out why a check is failing, as probes are served without credentials. It is logged
+// instead.
+type HealthCheckResponse struct {
+	Status   string `json:"status"` // ok or failing
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type ReadinessResponse struct {
+	HTTPStatusCode int                            `json:"-"`
+	Status         string                         `json:"status"` // ready, not ready or draining
+	Checks         map[string]HealthCheckResponse `json:"checks"`
+}
+
+type HealthChecker interface {
+	Ready(ctx context.Context) *domain.HealthReport
+}
+
+// liveness only shows the process is serving http, so the orchestrator restarts it when it is not.
+func liveness(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, map[string]string{"status": "ok"})
+}
+
+// readiness reports each dependency and answers 503 while any required one is failing or the server is shutting down.
+func readiness(service HealthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = render.Render(w, r, NewReadinessResponse(service.Ready(r.Context())))
+	}
+}
+
+func (rr *ReadinessResponse) Render(_ http.ResponseWriter, r *http.Request) error {
+	render.Status(r, rr.HTTPStatusCode)
+	return nil
+}
+
+func NewReadinessResponse(report *domain.HealthReport) *ReadinessResponse {
+	resp := &ReadinessResponse{
+		HTTPStatusCode: http.StatusOK,
+		Status:         "ready",
+		Checks:         make(map[string]HealthCheckResponse, len(report.Checks)),
+	}
+	switch {
+	case errors.Is(report.Err, domain.ErrDraining):
+		resp.HTTPStatusCode = http.StatusServiceUnavailable
+		resp.Status = "draining"
+	case !report.Ready:
+		resp.HTTPStatusCode = http.StatusServiceUnavailable
+		resp.Status = "not ready"
+	}
+	for _, check := range report.Checks {
+		checkResp := HealthCheckResponse{Status: "ok", Optional: check.Optional}
+		if check.Err != nil {
+			checkResp.Status = "failing"
+			slog.Warn("readiness check failing:", "check", check.Name, "optional", check.Optional, "error", check.Err)
+		}
+		resp.Checks[check.Name] = checkResp
+	}
+	return resp
+}
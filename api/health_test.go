@@ -0,0 +1,96 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthProbes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		report     *domain.HealthReport
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "200 while the process is up",
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok"}`,
+		},
+		{
+			name: "200 when ready, with each dependency",
+			path: "/readyz",
+			report: &domain.HealthReport{Ready: true, Checks: []domain.HealthCheckResult{
+				{Name: "database"},
+				{Name: "migrations"},
+				{Name: "holidays", Optional: true, Err: errors.New("status code: 503")},
+			}},
+			wantStatus: http.StatusOK,
+			wantBody: `{"status":"ready","checks":{
+				"database":{"status":"ok"},
+				"migrations":{"status":"ok"},
+				"holidays":{"status":"failing","optional":true}
+			}}`,
+		},
+		{
+			name: "503 when a dependency is failing",
+			path: "/readyz",
+			report: &domain.HealthReport{Checks: []domain.HealthCheckResult{
+				{Name: "database", Err: errors.New("connection refused")},
+				{Name: "migrations", Err: errors.New("at migration 13, want 12")},
+			}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody: `{"status":"not ready","checks":{
+				"database":{"status":"failing"},
+				"migrations":{"status":"failing"}
+			}}`,
+		},
+		{
+			name:       "503 while draining",
+			path:       "/readyz",
+			report:     &domain.HealthReport{Err: domain.ErrDraining, Checks: []domain.HealthCheckResult{{Name: "database"}}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"status":"draining","checks":{"database":{"status":"ok"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ChiHandler(api.Services{
+				APIKeys: &fakeAPIKeys{},
+				Health:  fakeHealth{report: tt.report},
+			}, nil))
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL + tt.path)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.wantBody, string(all))
+		})
+	}
+}
+
+type fakeHealth struct {
+	report *domain.HealthReport
+}
+
+func (f fakeHealth) Ready(context.Context) *domain.HealthReport {
+	return f.report
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/jcooney/appts/domain"
+)
+
+type HoldRequest struct {
+	VisitDate *VisitDate `json:"visitDate" validate:"required"`
+}
+
+type HoldResponse struct {
+	Token     string     `json:"token"`
+	VisitDate *VisitDate `json:"visitDate"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+}
+
+type HoldCreator interface {
+	Create(ctx context.Context, date *time.Time) (*domain.Hold, error)
+}
+
+func createHold(service HoldCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &HoldRequest{}
+		if err := render.Bind(r, req); err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+
+		hold, err := service.Create(r.Context(), req.VisitDate.Time())
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error creating hold:")
+			return
+		}
+		render.Status(r, http.StatusCreated)
+		_ = render.Render(w, r, NewHoldResponse(hold))
+	}
+}
+
+func (h *HoldRequest) Bind(_ *http.Request) error {
+	v := validator.New()
+	if err := v.Struct(h); err != nil {
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			return ve
+		}
+		return err
+	}
+	return nil
+}
+
+func (h HoldResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewHoldResponse(hold *domain.Hold) HoldResponse {
+	return HoldResponse{
+		Token:     hold.Token,
+		VisitDate: (*VisitDate)(hold.VisitDate),
+		ExpiresAt: hold.ExpiresAt,
+	}
+}
@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestCreateHold(t *testing.T) {
+	visitDate := time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		request      api.HoldRequest
+		wantStatus   int
+		wantErrBody  *api.ErrResponse
+		mockService  api.HoldCreator
+		wantResponse *api.HoldResponse
+	}{
+		{
+			name:        "400 when date is missing",
+			request:     api.HoldRequest{},
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'HoldRequest.VisitDate' Error:Field validation for 'VisitDate' failed on the 'required' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "201 when date is free",
+			request:     api.HoldRequest{VisitDate: ptr.To(api.VisitDate(visitDate))},
+			wantStatus:  http.StatusCreated,
+			mockService: holdSuccess{},
+			wantResponse: &api.HoldResponse{
+				Token:     "token",
+				VisitDate: ptr.To(api.VisitDate(visitDate)),
+				ExpiresAt: visitDate.Add(-time.Hour),
+			},
+		},
+		{
+			name:        "409 when date is already held or booked",
+			request:     api.HoldRequest{VisitDate: ptr.To(api.VisitDate(visitDate))},
+			wantStatus:  http.StatusConflict,
+			wantErrBody: &api.ErrResponse{ErrorText: "appointment date already taken", StatusText: "Conflict", HTTPStatusCode: 409},
+			mockService: holdError{err: domain.ErrAppointmentDateTaken},
+		},
+		{
+			name:        "400 when date is a public holiday",
+			request:     api.HoldRequest{VisitDate: ptr.To(api.VisitDate(visitDate))},
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "cannot book appointment on public holiday", StatusText: "Bad Request", HTTPStatusCode: 400},
+			mockService: holdError{err: domain.ErrAppointmentOnPublicHoliday},
+		},
+		{
+			name:        "500 when mapping from unsupported service error",
+			request:     api.HoldRequest{VisitDate: ptr.To(api.VisitDate(visitDate))},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrBody: &api.ErrResponse{ErrorText: "internal server error", StatusText: "Internal Server Error", HTTPStatusCode: 500},
+			mockService: holdError{err: errors.New("unhandled error")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.CreateHoldFunc(tt.mockService))
+			defer ts.Close()
+
+			marshal, err := json.Marshal(tt.request)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("POST", ts.URL+"/holds", bytes.NewBuffer(marshal))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantResponse != nil {
+				var got api.HoldResponse
+				require.NoError(t, json.Unmarshal(all, &got))
+				require.Equal(t, tt.wantResponse.Token, got.Token)
+				require.Equal(t, *tt.wantResponse.VisitDate, *got.VisitDate)
+				require.True(t, tt.wantResponse.ExpiresAt.Equal(got.ExpiresAt))
+			}
+			if tt.wantErrBody != nil {
+				var gotErr api.ErrResponse
+				require.NoError(t, json.Unmarshal(all, &gotErr))
+				require.Equal(t, *tt.wantErrBody, gotErr)
+			}
+		})
+	}
+}
+
+type holdSuccess struct{}
+
+func (h holdSuccess) Create(_ context.Context, date *time.Time) (*domain.Hold, error) {
+	return &domain.Hold{Token: "token", VisitDate: date, ExpiresAt: date.Add(-time.Hour)}, nil
+}
+
+type holdError struct {
+	err error
+}
+
+func (h holdError) Create(_ context.Context, _ *time.Time) (*domain.Hold, error) {
+	return nil, h.err
+}
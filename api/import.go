@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/importer"
+)
+
+// maxImportBytes bounds the request body of a batch import.
+const maxImportBytes = 10 << 20
+
+// importFormats maps the accepted request content types to import formats.
+var importFormats = map[string]importer.Format{
+	"text/csv":             importer.FormatCSV,
+	"application/x-ndjson": importer.FormatNDJSON,
+	"application/ndjson":   importer.FormatNDJSON,
+}
+
+type ImportResponse struct {
+	DryRun    bool                `json:"dryRun"`
+	Committed bool                `json:"committed"`
+	Total     int                 `json:"total"`
+	Failed    int                 `json:"failed"`
+	Rows      []ImportRowResponse `json:"rows"`
+}
+
+type ImportRowResponse struct {
+	Line      int        `json:"line"`
+	VisitDate *VisitDate `json:"visitDate,omitempty"`
+	OK        bool       `json:"ok"`
+	Reason    string     `json:"reason,omitempty"` // see rejectionReasons, or "invalid" for rows that could not be read
+	ErrorText string     `json:"error,omitempty"`
+}
+
+type AppointmentImporter interface {
+	Import(ctx context.Context, rows []domain.ImportRow, dryRun bool) (*domain.ImportReport, error)
+}
+
+func importAppointments(service AppointmentImporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format, ok := importFormats[mediaType]
+		if !ok {
+			_ = render.Render(w, r, errStatus(http.StatusUnsupportedMediaType, fmt.Errorf("content type must be text/csv or application/x-ndjson")))
+			return
+		}
+		dryRun := false
+		if v := r.URL.Query().Get("dryRun"); v != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(v); err != nil {
+				_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("invalid dryRun: %w", err)))
+				return
+			}
+		}
+
+		rows, err := importer.Parse(http.MaxBytesReader(w, r.Body, maxImportBytes), format)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				_ = render.Render(w, r, errStatus(http.StatusRequestEntityTooLarge, err))
+				return
+			}
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+
+		report, err := service.Import(r.Context(), rows, dryRun)
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error importing appointments:")
+			return
+		}
+		switch {
+		case report.Committed:
+			render.Status(r, http.StatusCreated)
+		case report.DryRun:
+			render.Status(r, http.StatusOK)
+		default:
+			render.Status(r, http.StatusConflict)
+		}
+		_ = render.Render(w, r, NewImportResponse(report))
+	}
+}
+
+func (i ImportResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewImportResponse(report *domain.ImportReport) ImportResponse {
+	resp := ImportResponse{
+		DryRun:    report.DryRun,
+		Committed: report.Committed,
+		Total:     len(report.Rows),
+		Rows:      make([]ImportRowResponse, len(report.Rows)),
+	}
+	for i, row := range report.Rows {
+		rowResp := ImportRowResponse{Line: row.Line, OK: row.Err == nil}
+		if row.Appointment != nil {
+			rowResp.VisitDate = (*VisitDate)(row.Appointment.VisitDate)
+		}
+		if row.Err != nil {
+			resp.Failed++
+			rowResp.Reason, rowResp.ErrorText = rejection(row.Err, "unknown error importing appointment:")
+		}
+		resp.Rows[i] = rowResp
+	}
+	return resp
+}
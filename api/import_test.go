@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportAppointments(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		query        string
+		body         string
+		mockService  api.AppointmentImporter
+		wantStatus   int
+		wantErrBody  *api.ErrResponse
+		wantResponse *api.ImportResponse
+	}{
+		{
+			name:        "415 when content type is not supported",
+			contentType: "application/json",
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantErrBody: &api.ErrResponse{ErrorText: "content type must be text/csv or application/x-ndjson", StatusText: "Unsupported Media Type", HTTPStatusCode: 415},
+		},
+		{
+			name:        "400 when dry run is not a bool",
+			contentType: "text/csv",
+			query:       "?dryRun=maybe",
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: `invalid dryRun: strconv.ParseBool: parsing "maybe": invalid syntax`, StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "413 when body is too large",
+			contentType: "text/csv",
+			body:        strings.Repeat("a", 10<<20+1),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantErrBody: &api.ErrResponse{ErrorText: "read csv: http: request body too large", StatusText: "Request Entity Too Large", HTTPStatusCode: 413},
+		},
+		{
+			name:        "201 when every row is committed",
+			contentType: "text/csv; charset=utf-8",
+			body:        "firstName,lastName,visitDate\nJohn,Doe,2026-01-05\n",
+			mockService: importSuccess{},
+			wantStatus:  http.StatusCreated,
+			wantResponse: &api.ImportResponse{
+				Committed: true,
+				Total:     1,
+				Rows:      []api.ImportRowResponse{{Line: 2, OK: true}},
+			},
+		},
+		{
+			name:        "200 when dry run",
+			contentType: "application/x-ndjson",
+			query:       "?dryRun=true",
+			body:        `{"firstName":"John","lastName":"Doe","visitDate":"2026-01-05"}`,
+			mockService: importSuccess{},
+			wantStatus:  http.StatusOK,
+			wantResponse: &api.ImportResponse{
+				DryRun: true,
+				Total:  1,
+				Rows:   []api.ImportRowResponse{{Line: 1, OK: true}},
+			},
+		},
+		{
+			name:        "409 with per row reasons when rows fail",
+			contentType: "text/csv",
+			body:        "John,Doe,2026-01-05\nJane,Doe\nJim,Doe,2026-01-06\n",
+			mockService: importFailure{err: domain.ErrAppointmentDateTaken},
+			wantStatus:  http.StatusConflict,
+			wantResponse: &api.ImportResponse{
+				Total:  3,
+				Failed: 3,
+				Rows: []api.ImportRowResponse{
+					{Line: 1, Reason: "taken", ErrorText: "appointment date already taken"},
+					{Line: 2, Reason: "invalid", ErrorText: "invalid import row: expected 3 columns, got 2"},
+					{Line: 3, Reason: "taken", ErrorText: "appointment date already taken"},
+				},
+			},
+		},
+		{
+			name:        "500 when mapping from unsupported service error",
+			contentType: "text/csv",
+			body:        "John,Doe,2026-01-05\n",
+			mockService: importError{},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrBody: &api.ErrResponse{ErrorText: "internal server error", StatusText: "Internal Server Error", HTTPStatusCode: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Post("/appts:batch", api.ImportAppointmentsFunc(tt.mockService))
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			req, err := http.NewRequest("POST", ts.URL+"/appts:batch"+tt.query, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantErrBody != nil {
+				var gotErr api.ErrResponse
+				require.NoError(t, json.Unmarshal(all, &gotErr))
+				require.Equal(t, *tt.wantErrBody, gotErr)
+			}
+			if tt.wantResponse != nil {
+				var got api.ImportResponse
+				require.NoError(t, json.Unmarshal(all, &got))
+				for i := range got.Rows {
+					got.Rows[i].VisitDate = nil
+				}
+				require.Equal(t, *tt.wantResponse, got)
+			}
+		})
+	}
+}
+
+type importSuccess struct{}
+
+func (i importSuccess) Import(_ context.Context, rows []domain.ImportRow, dryRun bool) (*domain.ImportReport, error) {
+	return &domain.ImportReport{DryRun: dryRun, Committed: !dryRun, Rows: rows}, nil
+}
+
+// importFailure fails every readable row with err.
+type importFailure struct {
+	err error
+}
+
+func (i importFailure) Import(_ context.Context, rows []domain.ImportRow, dryRun bool) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{DryRun: dryRun, Rows: rows}
+	for j := range report.Rows {
+		if report.Rows[j].Err == nil {
+			report.Rows[j].Err = i.err
+		}
+	}
+	return report, nil
+}
+
+type importError struct{}
+
+func (i importError) Import(_ context.Context, _ []domain.ImportRow, _ bool) (*domain.ImportReport, error) {
+	return nil, errors.New("unhandled error")
+}
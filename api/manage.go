@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/jcooney/appts/domain"
+)
+
+type RescheduleRequest struct {
+	VisitDate *VisitDate `json:"visitDate" validate:"required"`
+}
+
+// ManageResponse is what a manage token holder sees of their appointment. It leaves out the internal ID.
+type ManageResponse struct {
+	FirstName string                   `json:"firstName"`
+	LastName  string                   `json:"lastName"`
+	VisitDate *VisitDate               `json:"visitDate"`
+	Status    domain.AppointmentStatus `json:"status"`
+}
+
+type AppointmentManager interface {
+	Get(ctx context.Context, token string) (*domain.Appointment, error)
+	Cancel(ctx context.Context, token string) (*domain.Appointment, error)
+	Reschedule(ctx context.Context, token string, date *time.Time) (*domain.Appointment, error)
+	Revoke(ctx context.Context, token string) error
+}
+
+func getManagedAppointment(service AppointmentManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appt, err := service.Get(r.Context(), chi.URLParam(r, "token"))
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error getting managed appointment:")
+			return
+		}
+		_ = render.Render(w, r, NewManageResponse(appt))
+	}
+}
+
+func rescheduleAppointment(service AppointmentManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &RescheduleRequest{}
+		if err := render.Bind(r, req); err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+		appt, err := service.Reschedule(r.Context(), chi.URLParam(r, "token"), req.VisitDate.Time())
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error rescheduling appointment:")
+			return
+		}
+		_ = render.Render(w, r, NewManageResponse(appt))
+	}
+}
+
+func cancelAppointment(service AppointmentManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		appt, err := service.Cancel(r.Context(), chi.URLParam(r, "token"))
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error cancelling appointment:")
+			return
+		}
+		_ = render.Render(w, r, NewManageResponse(appt))
+	}
+}
+
+func revokeManageToken(service AppointmentManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := service.Revoke(r.Context(), chi.URLParam(r, "token")); err != nil {
+			renderServiceError(w, r, err, "unknown error revoking manage token:")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (rr *RescheduleRequest) Bind(_ *http.Request) error {
+	v := validator.New()
+	if err := v.Struct(rr); err != nil {
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			return ve
+		}
+		return err
+	}
+	return nil
+}
+
+func (m ManageResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewManageResponse(appt *domain.Appointment) ManageResponse {
+	return ManageResponse{
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+		VisitDate: (*VisitDate)(appt.VisitDate),
+		Status:    appt.Status,
+	}
+}
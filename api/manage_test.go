@@ -0,0 +1,170 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestManage(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		requestBody string
+		mockService *fakeManager
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "200 without the id when viewing an appointment",
+			method:      http.MethodGet,
+			path:        "/manage/tok",
+			mockService: &fakeManager{},
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"firstName":"John","lastName":"Doe","visitDate":"2026-01-05","status":"booked"}`,
+		},
+		{
+			name:        "401 when the token is invalid",
+			method:      http.MethodGet,
+			path:        "/manage/tok",
+			mockService: &fakeManager{err: domain.ErrInvalidManageToken},
+			wantStatus:  http.StatusUnauthorized,
+			wantBody:    `{"code":401,"status":"Unauthorized","error":"invalid or expired manage token"}`,
+		},
+		{
+			name:        "200 when rescheduling",
+			method:      http.MethodPatch,
+			path:        "/manage/tok",
+			requestBody: `{"visitDate": "2026-01-12"}`,
+			mockService: &fakeManager{},
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"firstName":"John","lastName":"Doe","visitDate":"2026-01-12","status":"booked"}`,
+		},
+		{
+			name:        "400 when visit date is missing",
+			method:      http.MethodPatch,
+			path:        "/manage/tok",
+			requestBody: `{}`,
+			mockService: &fakeManager{},
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `{"code":400,"status":"Bad Request","error":"Key: 'RescheduleRequest.VisitDate' Error:Field validation for 'VisitDate' failed on the 'required' tag"}`,
+		},
+		{
+			name:        "409 when rescheduling onto a taken date",
+			method:      http.MethodPatch,
+			path:        "/manage/tok",
+			requestBody: `{"visitDate": "2026-01-12"}`,
+			mockService: &fakeManager{err: domain.ErrAppointmentDateTaken},
+			wantStatus:  http.StatusConflict,
+			wantBody:    `{"code":409,"status":"Conflict","error":"appointment date already taken"}`,
+		},
+		{
+			name:        "200 when cancelling",
+			method:      http.MethodDelete,
+			path:        "/manage/tok",
+			mockService: &fakeManager{},
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"firstName":"John","lastName":"Doe","visitDate":"2026-01-05","status":"cancelled"}`,
+		},
+		{
+			name:        "409 when already cancelled",
+			method:      http.MethodDelete,
+			path:        "/manage/tok",
+			mockService: &fakeManager{err: domain.ErrAppointmentCancelled},
+			wantStatus:  http.StatusConflict,
+			wantBody:    `{"code":409,"status":"Conflict","error":"appointment is cancelled"}`,
+		},
+		{
+			name:        "500 when cancelling fails",
+			method:      http.MethodDelete,
+			path:        "/manage/tok",
+			mockService: &fakeManager{err: errors.New("boom")},
+			wantStatus:  http.StatusInternalServerError,
+			wantBody:    `{"code":500,"status":"Internal Server Error","error":"internal server error"}`,
+		},
+		{
+			name:        "204 when revoking",
+			method:      http.MethodPost,
+			path:        "/manage/tok/revoke",
+			mockService: &fakeManager{},
+			wantStatus:  http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ChiHandler(api.Services{Manage: tt.mockService}, nil))
+			defer ts.Close()
+
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewBufferString(tt.requestBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantBody == "" {
+				require.Empty(t, all)
+				return
+			}
+			require.JSONEq(t, tt.wantBody, string(all))
+		})
+	}
+}
+
+type fakeManager struct {
+	err error
+}
+
+func (f *fakeManager) appointment() *domain.Appointment {
+	appt := domain.NewAppointment("John", "Doe", ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)))
+	appt.ID = 7
+	appt.Status = domain.AppointmentStatusBooked
+	return appt
+}
+
+func (f *fakeManager) Get(_ context.Context, _ string) (*domain.Appointment, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.appointment(), nil
+}
+
+func (f *fakeManager) Cancel(_ context.Context, _ string) (*domain.Appointment, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	appt := f.appointment()
+	appt.Status = domain.AppointmentStatusCancelled
+	return appt, nil
+}
+
+func (f *fakeManager) Reschedule(_ context.Context, _ string, date *time.Time) (*domain.Appointment, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	appt := f.appointment()
+	appt.VisitDate = date
+	return appt, nil
+}
+
+func (f *fakeManager) Revoke(_ context.Context, _ string) error {
+	return f.err
+}
@@ -0,0 +1,48 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jcooney/appts/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := &fakeMetrics{}
+	ts := httptest.NewServer(api.ChiHandler(api.Services{APIKeys: &fakeAPIKeys{}, Metrics: metrics}, nil))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			t.Logf("error closing response body: %v", err)
+		}
+	}(resp.Body)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "served without credentials")
+	all, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "appts_up 1\n", string(all))
+	require.Equal(t, 1, metrics.observed)
+}
+
+type fakeMetrics struct {
+	observed int
+}
+
+func (f *fakeMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.observed++
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *fakeMetrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("appts_up 1\n"))
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/jcooney/appts/domain"
+)
+
+type RateLimiter interface {
+	Allow(ctx context.Context, bucket string) (time.Duration, error)
+}
+
+// limitRate counts each request against the bucket named by bucketFor, answering 429 with a Retry-After once the
+// limit is exceeded. Requests bucketFor returns "" for are not counted.
+func limitRate(limiter RateLimiter, bucketFor func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bucket := bucketFor(r)
+			if bucket == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			retryAfter, err := limiter.Allow(r.Context(), bucket)
+			if err != nil {
+				if errors.Is(err, domain.ErrRateLimited) {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+					_ = render.Render(w, r, errStatus(http.StatusTooManyRequests, domain.ErrRateLimited))
+					return
+				}
+				renderServiceError(w, r, err, "unknown error checking rate limit:")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// principalBucket limits each api key or JWT subject separately, it counts nothing when auth is disabled.
+func principalBucket(r *http.Request) string {
+	principal, ok := domain.PrincipalFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	return "principal:" + principal.String()
+}
+
+// ipBucket limits each client address. That is the connection's peer, unless the peer is one of trusted, reverse
+// proxies whose X-Forwarded-For is believed. The client is then the last address in that header that is not itself a
+// trusted proxy, as anything before it was sent by the client and could be made up.
+func ipBucket(trusted []netip.Prefix) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		client, err := netip.ParseAddr(host)
+		if err != nil {
+			return "ip:" + host
+		}
+		client = client.Unmap()
+		if !isTrustedProxy(trusted, client) {
+			return "ip:" + client.String()
+		}
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = hop.Unmap()
+			if !isTrustedProxy(trusted, client) {
+				break
+			}
+		}
+		return "ip:" + client.String()
+	}
+}
+
+func isTrustedProxy(trusted []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimits(t *testing.T) {
+	tests := []struct {
+		name           string
+		ipLimiter      *fakeLimiter
+		keyLimiter     *fakeLimiter
+		wantStatus     int
+		wantBody       string
+		wantRetryAfter string
+	}{
+		{
+			name:       "201 while under both limits",
+			ipLimiter:  &fakeLimiter{},
+			keyLimiter: &fakeLimiter{},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:           "429 once the ip is over its limit",
+			ipLimiter:      &fakeLimiter{retryAfter: 14200 * time.Millisecond, err: domain.ErrRateLimited},
+			keyLimiter:     &fakeLimiter{},
+			wantStatus:     http.StatusTooManyRequests,
+			wantBody:       `{"code":429,"status":"Too Many Requests","error":"rate limit exceeded"}`,
+			wantRetryAfter: "15",
+		},
+		{
+			name:           "429 once the api key is over its limit",
+			ipLimiter:      &fakeLimiter{},
+			keyLimiter:     &fakeLimiter{retryAfter: time.Minute, err: domain.ErrRateLimited},
+			wantStatus:     http.StatusTooManyRequests,
+			wantBody:       `{"code":429,"status":"Too Many Requests","error":"rate limit exceeded"}`,
+			wantRetryAfter: "60",
+		},
+		{
+			name:       "500 when the limiter fails",
+			ipLimiter:  &fakeLimiter{err: errors.New("boom")},
+			keyLimiter: &fakeLimiter{},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"code":500,"status":"Internal Server Error","error":"internal server error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ChiHandler(api.Services{
+				Holds:      holdSuccess{},
+				APIKeys:    &fakeAPIKeys{},
+				IPLimiter:  tt.ipLimiter,
+				KeyLimiter: tt.keyLimiter,
+			}, nil))
+			defer ts.Close()
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/holds", bytes.NewBufferString(`{"visitDate": "2026-01-05"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer appts_valid")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			require.Equal(t, tt.wantRetryAfter, resp.Header.Get("Retry-After"))
+			if tt.wantBody != "" {
+				all, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.JSONEq(t, tt.wantBody, string(all))
+			}
+			require.Equal(t, []string{"ip:127.0.0.1"}, tt.ipLimiter.buckets)
+			if tt.ipLimiter.err == nil {
+				require.Equal(t, []string{"principal:api-key:3"}, tt.keyLimiter.buckets)
+			}
+		})
+	}
+}
+
+func TestRateLimits_ForwardedFor(t *testing.T) {
+	tests := []struct {
+		name         string
+		proxies      []netip.Prefix
+		forwardedFor []string
+		wantBucket   string
+	}{
+		{
+			name:         "ignored when the peer is not a trusted proxy",
+			forwardedFor: []string{"203.0.113.7"},
+			wantBucket:   "ip:127.0.0.1",
+		},
+		{
+			name:         "the client a trusted proxy forwarded for",
+			proxies:      []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+			forwardedFor: []string{"203.0.113.7"},
+			wantBucket:   "ip:203.0.113.7",
+		},
+		{
+			name:         "addresses the client sent are skipped",
+			proxies:      []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("10.0.0.0/8")},
+			forwardedFor: []string{"198.51.100.1, 203.0.113.7", "10.0.0.2"},
+			wantBucket:   "ip:203.0.113.7",
+		},
+		{
+			name:       "the peer when nothing was forwarded",
+			proxies:    []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+			wantBucket: "ip:127.0.0.1",
+		},
+		{
+			name:         "the peer when the header is malformed",
+			proxies:      []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+			forwardedFor: []string{"not-an-ip"},
+			wantBucket:   "ip:127.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeLimiter{}
+			ts := httptest.NewServer(api.ChiHandler(api.Services{
+				Holds:     holdSuccess{},
+				IPLimiter: limiter,
+				Proxies:   tt.proxies,
+			}, nil))
+			defer ts.Close()
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/holds", bytes.NewBufferString(`{"visitDate": "2026-01-05"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			for _, forwardedFor := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, []string{tt.wantBucket}, limiter.buckets)
+		})
+	}
+}
+
+type fakeLimiter struct {
+	retryAfter time.Duration
+	err        error
+	buckets    []string
+}
+
+func (f *fakeLimiter) Allow(_ context.Context, bucket string) (time.Duration, error) {
+	f.buckets = append(f.buckets, bucket)
+	return f.retryAfter, f.err
+}
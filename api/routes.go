@@ -2,18 +2,135 @@ package api
 
 import (
 	"net/http"
+	"net/netip"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jcooney/appts/domain"
 )
 
-func ChiHandler(service AppointmentCreator) http.Handler {
+// Metrics instruments every request and serves the results at /metrics.
+type Metrics interface {
+	Middleware(next http.Handler) http.Handler
+	Handler() http.Handler
+}
+
+// Services are the domain services the http handlers delegate to.
+type Services struct {
+	Appointments AppointmentCreator
+	Holds        HoldCreator
+	Series       SeriesCreator
+	Importer     AppointmentImporter
+	Lister       AppointmentLister
+	Getter       AppointmentGetter
+	Canceller    AppointmentCanceller
+	History      AppointmentHistorian
+	Webhooks     WebhookManager
+	Manage       AppointmentManager
+	Feeds        CalendarFeeds                   // optional, serves calendar feeds
+	PublicURL    string                          // optional, the api as patients reach it, which links are built from
+	APIKeys      APIKeyManager                   // authenticates every route a patient does not reach by link, nil disables auth
+	Tokens       TokenVerifier                   // optional, verifies JWT bearer tokens
+	KeyLimiter   RateLimiter                     // optional, limits bookings per api key or JWT subject
+	IPLimiter    RateLimiter                     // optional, limits bookings per client IP
+	Proxies      []netip.Prefix                  // optional, trusted reverse proxies the client IP is forwarded by
+	Health       HealthChecker                   // optional, serves /readyz
+	Metrics      Metrics                         // optional
+	Tracing      func(http.Handler) http.Handler // optional, starts a span for every request
+}
+
+// ChiHandler routes requests to services, location is the clinic shown on calendar events and may be nil.
+func ChiHandler(services Services, location *domain.Location) http.Handler {
 	r := chi.NewRouter()
 
+	if services.Tracing != nil {
+		r.Use(services.Tracing)
+	}
 	r.Use(middleware.RequestID)
+	r.Use(withRequestID)
+	if services.PublicURL != "" {
+		r.Use(withPublicURL(strings.TrimSuffix(services.PublicURL, "/")))
+	}
+	if services.Metrics != nil {
+		r.Use(services.Metrics.Middleware)
+	}
 	r.Use(middleware.Logger)
 
-	r.Post("/appts", CreateAppointmentFunc(service))
+	// Probes and scrapes come from inside the cluster and are served without credentials.
+	r.Get("/healthz", liveness)
+	if services.Health != nil {
+		r.Get("/readyz", readiness(services.Health))
+	}
+	if services.Metrics != nil {
+		r.Method(http.MethodGet, "/metrics", services.Metrics.Handler())
+	}
+
+	// Patients reach these through links carrying their own capability: a manage token or an unguessable public ID.
+	// Subscribed calendars cannot send credentials either, so feeds are reached by a signed token in their url.
+	r.Get("/appts/{id}.ics", AppointmentICSFunc(services.Getter, location))
+	if services.Feeds != nil {
+		r.Get("/calendar/{token}.ics", CalendarFeedFunc(services.Lister, services.Feeds, location))
+	}
+	r.Route("/manage/{token}", func(r chi.Router) {
+		r.Get("/", getManagedAppointment(services.Manage))
+		r.Patch("/", rescheduleAppointment(services.Manage))
+		r.Delete("/", cancelAppointment(services.Manage))
+		r.Post("/revoke", revokeManageToken(services.Manage))
+	})
+
+	// Every other route needs credentials whose role grants the route's permission.
+	requires := func(permission domain.Permission) func(http.Handler) http.Handler {
+		if services.APIKeys == nil {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return authorize(permission)
+	}
+	// Routes on a single appointment also check a principal only granted its own appointments owns this one.
+	requiresOwner := func(permission domain.Permission) []func(http.Handler) http.Handler {
+		if services.APIKeys == nil {
+			return nil
+		}
+		return []func(http.Handler) http.Handler{authorize(permission), authorizeAppointment(services.Getter, permission)}
+	}
+	// Booking routes are rate limited so a single client cannot take every free date.
+	booking := func(permission domain.Permission) []func(http.Handler) http.Handler {
+		middlewares := []func(http.Handler) http.Handler{requires(permission)}
+		if services.IPLimiter != nil {
+			middlewares = append(middlewares, limitRate(services.IPLimiter, ipBucket(services.Proxies)))
+		}
+		if services.KeyLimiter != nil {
+			middlewares = append(middlewares, limitRate(services.KeyLimiter, principalBucket))
+		}
+		return middlewares
+	}
+	r.Group(func(r chi.Router) {
+		if services.APIKeys != nil {
+			r.Use(authenticate(services.APIKeys, services.Tokens))
+		}
+		r.With(booking(domain.PermissionBook)...).Post("/appts", CreateAppointmentFunc(services.Appointments))
+		r.With(booking(domain.PermissionBook)...).Post("/appts/series", CreateSeriesFunc(services.Series))
+		r.With(booking(domain.PermissionBook)...).Post("/holds", CreateHoldFunc(services.Holds))
+		r.With(requires(domain.PermissionImport)).Post("/appts:batch", ImportAppointmentsFunc(services.Importer))
+		r.With(requires(domain.PermissionRead)).Get("/appts/export", ExportAppointmentsFunc(services.Lister))
+		if services.Feeds != nil {
+			r.With(requires(domain.PermissionRead)).Post("/calendar-feeds", createCalendarFeed(services.Feeds))
+		}
+		r.With(requiresOwner(domain.PermissionCancel)...).Delete("/appts/{id}", cancelAnyAppointment(services.Canceller))
+		r.With(requiresOwner(domain.PermissionRead)...).Get("/appts/{id}/history", appointmentHistory(services.History))
+		r.With(requires(domain.PermissionManageWebhooks)).Route("/webhooks", func(r chi.Router) {
+			r.Post("/", createWebhook(services.Webhooks))
+			r.Get("/", listWebhooks(services.Webhooks))
+			r.Delete("/{id}", deleteWebhook(services.Webhooks))
+			r.Get("/{id}/deliveries", webhookDeliveries(services.Webhooks))
+			r.Post("/{id}/deliveries/{deliveryID}/retry", retryWebhookDelivery(services.Webhooks))
+		})
+		r.With(requires(domain.PermissionManageAPIKeys)).Route("/admin/api-keys", func(r chi.Router) {
+			r.Post("/", createAPIKey(services.APIKeys))
+			r.Get("/", listAPIKeys(services.APIKeys))
+			r.Delete("/{id}", revokeAPIKey(services.APIKeys))
+		})
+	})
 
 	return r
 }
@@ -21,3 +138,27 @@ func ChiHandler(service AppointmentCreator) http.Handler {
 func CreateAppointmentFunc(service AppointmentCreator) http.HandlerFunc {
 	return createAppointment(service)
 }
+
+func CreateHoldFunc(service HoldCreator) http.HandlerFunc {
+	return createHold(service)
+}
+
+func CreateSeriesFunc(service SeriesCreator) http.HandlerFunc {
+	return createSeries(service)
+}
+
+func ImportAppointmentsFunc(service AppointmentImporter) http.HandlerFunc {
+	return importAppointments(service)
+}
+
+func ExportAppointmentsFunc(service AppointmentLister) http.HandlerFunc {
+	return exportAppointments(service)
+}
+
+func CalendarFeedFunc(service AppointmentLister, feeds CalendarFeeds, location *domain.Location) http.HandlerFunc {
+	return calendarFeed(service, feeds, location)
+}
+
+func AppointmentICSFunc(service AppointmentGetter, location *domain.Location) http.HandlerFunc {
+	return appointmentICS(service, location)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/jcooney/appts/domain"
+)
+
+type SeriesRequest struct {
+	FirstName string     `json:"firstName" validate:"required,max=50"`
+	LastName  string     `json:"lastName" validate:"required,max=50"`
+	StartDate *VisitDate `json:"startDate" validate:"required"`
+	RRule     string     `json:"rrule" validate:"required"`
+	Mode      string     `json:"mode" validate:"required,oneof=all-or-nothing best-effort"`
+	Email     string     `json:"email,omitempty" validate:"omitempty,email,max=254"`
+	Phone     string     `json:"phone,omitempty" validate:"omitempty,e164"`
+
+	recurrence *domain.Recurrence
+}
+
+type SeriesResponse struct {
+	Mode        string               `json:"mode"`
+	Booked      int                  `json:"booked"`
+	Failed      int                  `json:"failed"`
+	Occurrences []OccurrenceResponse `json:"occurrences"`
+}
+
+type OccurrenceResponse struct {
+	VisitDate *VisitDate `json:"visitDate"`
+	Booked    bool       `json:"booked"`
+	Reason    string     `json:"reason,omitempty"` // see rejectionReasons, set when not booked
+	ErrorText string     `json:"error,omitempty"`
+}
+
+type SeriesCreator interface {
+	Create(ctx context.Context, appt *domain.Appointment, rec *domain.Recurrence, mode domain.SeriesMode) ([]domain.OccurrenceResult, error)
+}
+
+func createSeries(service SeriesCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &SeriesRequest{}
+		if err := render.Bind(r, req); err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+
+		email, ok := bookingEmail(r, req.Email)
+		if !ok {
+			renderNotOwned(w, r)
+			return
+		}
+
+		appt := domain.NewAppointment(req.FirstName, req.LastName, req.StartDate.Time())
+		appt.Email = email
+		appt.Phone = req.Phone
+		results, err := service.Create(r.Context(), appt, req.recurrence, domain.SeriesMode(req.Mode))
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidRecurrence) {
+				_ = render.Render(w, r, errInvalidRequest(err))
+				return
+			}
+			renderServiceError(w, r, err, "unknown error creating appointment series:")
+			return
+		}
+
+		resp := NewSeriesResponse(req.Mode, results)
+		if resp.Booked > 0 {
+			render.Status(r, http.StatusCreated)
+		} else {
+			render.Status(r, http.StatusConflict)
+		}
+		_ = render.Render(w, r, resp)
+	}
+}
+
+func (s *SeriesRequest) Bind(_ *http.Request) error {
+	v := validator.New()
+	if err := v.Struct(s); err != nil {
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			return ve
+		}
+		return err
+	}
+	rec, err := domain.ParseRecurrence(s.RRule)
+	if err != nil {
+		return err
+	}
+	s.recurrence = rec
+	return nil
+}
+
+func (s SeriesResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewSeriesResponse(mode string, results []domain.OccurrenceResult) SeriesResponse {
+	resp := SeriesResponse{Mode: mode, Occurrences: make([]OccurrenceResponse, len(results))}
+	for i := range results {
+		occurrence := OccurrenceResponse{VisitDate: (*VisitDate)(&results[i].VisitDate), Booked: results[i].Err == nil}
+		if results[i].Err != nil {
+			resp.Failed++
+			occurrence.Reason, occurrence.ErrorText = rejection(results[i].Err, "unknown error creating series occurrence:")
+		} else {
+			resp.Booked++
+		}
+		resp.Occurrences[i] = occurrence
+	}
+	return resp
+}
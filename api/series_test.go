@@ -0,0 +1,156 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSeries(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockService  api.SeriesCreator
+		wantStatus   int
+		wantErrBody  *api.ErrResponse
+		wantResponse *api.SeriesResponse
+	}{
+		{
+			name:        "400 when mode is unknown",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=WEEKLY;COUNT=2", "mode": "sometimes"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'SeriesRequest.Mode' Error:Field validation for 'Mode' failed on the 'oneof' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when email is invalid",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=WEEKLY;COUNT=2", "mode": "best-effort", "email": "john"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'SeriesRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when phone is not E.164",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=WEEKLY;COUNT=2", "mode": "best-effort", "phone": "07700 900123"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "Key: 'SeriesRequest.Phone' Error:Field validation for 'Phone' failed on the 'e164' tag", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when rrule is unsupported",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=DAILY;COUNT=2", "mode": "best-effort"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: `invalid recurrence rule: unsupported FREQ "DAILY"`, StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "400 when rrule expands to too many occurrences",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=WEEKLY;UNTIL=20300101", "mode": "best-effort"}`,
+			mockService: seriesError{err: fmt.Errorf("%w: more than 52 occurrences", domain.ErrInvalidRecurrence)},
+			wantStatus:  http.StatusBadRequest,
+			wantErrBody: &api.ErrResponse{ErrorText: "invalid recurrence rule: more than 52 occurrences", StatusText: "Bad Request", HTTPStatusCode: 400},
+		},
+		{
+			name:        "500 when mapping from unsupported service error",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=WEEKLY;COUNT=2", "mode": "all-or-nothing"}`,
+			mockService: seriesError{err: errors.New("unhandled error")},
+			wantStatus:  http.StatusInternalServerError,
+			wantErrBody: &api.ErrResponse{ErrorText: "internal server error", StatusText: "Internal Server Error", HTTPStatusCode: 500},
+		},
+		{
+			name:        "201 with per occurrence reasons when some are booked",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=WEEKLY;COUNT=4", "mode": "best-effort", "email": "john@example.com", "phone": "+447700900123"}`,
+			mockService: seriesResults{errs: []error{nil, domain.ErrAppointmentOnPublicHoliday, domain.ErrAppointmentDateTaken, errors.New("boom")}},
+			wantStatus:  http.StatusCreated,
+			wantResponse: &api.SeriesResponse{
+				Mode:   "best-effort",
+				Booked: 1,
+				Failed: 3,
+				Occurrences: []api.OccurrenceResponse{
+					{Booked: true},
+					{Reason: "holiday", ErrorText: "cannot book appointment on public holiday"},
+					{Reason: "taken", ErrorText: "appointment date already taken"},
+					{Reason: "error", ErrorText: "internal server error"},
+				},
+			},
+		},
+		{
+			name:        "409 when nothing is booked",
+			requestBody: `{"firstName": "John", "lastName": "Doe", "startDate": "2026-01-05", "rrule": "FREQ=WEEKLY;COUNT=2", "mode": "all-or-nothing"}`,
+			mockService: seriesResults{errs: []error{domain.ErrSeriesRolledBack, domain.ErrAppointmentInPast}},
+			wantStatus:  http.StatusConflict,
+			wantResponse: &api.SeriesResponse{
+				Mode:   "all-or-nothing",
+				Failed: 2,
+				Occurrences: []api.OccurrenceResponse{
+					{Reason: "rolled-back", ErrorText: "not booked because another occurrence in the series failed"},
+					{Reason: "past", ErrorText: "cannot book appointment in the past"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.CreateSeriesFunc(tt.mockService))
+			defer ts.Close()
+
+			req, err := http.NewRequest("POST", ts.URL+"/appts/series", bytes.NewBufferString(tt.requestBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantErrBody != nil {
+				var gotErr api.ErrResponse
+				require.NoError(t, json.Unmarshal(all, &gotErr))
+				require.Equal(t, *tt.wantErrBody, gotErr)
+			}
+			if tt.wantResponse != nil {
+				var got api.SeriesResponse
+				require.NoError(t, json.Unmarshal(all, &got))
+				for i := range got.Occurrences {
+					got.Occurrences[i].VisitDate = nil
+				}
+				require.Equal(t, *tt.wantResponse, got)
+			}
+		})
+	}
+}
+
+type seriesError struct {
+	err error
+}
+
+func (s seriesError) Create(_ context.Context, _ *domain.Appointment, _ *domain.Recurrence, _ domain.SeriesMode) ([]domain.OccurrenceResult, error) {
+	return nil, s.err
+}
+
+// seriesResults returns one weekly occurrence per error, booked when the error is nil.
+type seriesResults struct {
+	errs []error
+}
+
+func (s seriesResults) Create(_ context.Context, appt *domain.Appointment, _ *domain.Recurrence, _ domain.SeriesMode) ([]domain.OccurrenceResult, error) {
+	results := make([]domain.OccurrenceResult, len(s.errs))
+	for i, err := range s.errs {
+		results[i] = domain.OccurrenceResult{VisitDate: appt.VisitDate.AddDate(0, 0, 7*i), Err: err}
+		if err == nil {
+			results[i].Appointment = appt
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/jcooney/appts/domain"
+)
+
+type WebhookRequest struct {
+	URL        string             `json:"url" validate:"required,url"`
+	EventTypes []domain.EventType `json:"eventTypes"` // empty subscribes to every event
+}
+
+type WebhookResponse struct {
+	ID         int32              `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []domain.EventType `json:"eventTypes"`
+	Secret     string             `json:"secret,omitempty"` // only returned when the webhook is created
+	CreatedAt  time.Time          `json:"createdAt"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int64                        `json:"id"`
+	EventID        int64                        `json:"eventId"`
+	EventType      domain.EventType             `json:"eventType"`
+	Status         domain.WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                        `json:"attempts"`
+	NextAttemptAt  *time.Time                   `json:"nextAttemptAt,omitempty"` // only while pending
+	LastStatusCode int32                        `json:"lastStatusCode,omitempty"`
+	LastError      string                       `json:"lastError,omitempty"`
+	CreatedAt      time.Time                    `json:"createdAt"`
+	UpdatedAt      time.Time                    `json:"updatedAt"`
+}
+
+type WebhookDeliveryLogResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+type WebhookManager interface {
+	Create(ctx context.Context, url string, eventTypes []domain.EventType) (*domain.WebhookSubscription, error)
+	List(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	Delete(ctx context.Context, id int32) error
+	Deliveries(ctx context.Context, subscriptionID int32, status domain.WebhookDeliveryStatus) ([]*domain.WebhookDelivery, error)
+	Retry(ctx context.Context, subscriptionID int32, deliveryID int64) (*domain.WebhookDelivery, error)
+}
+
+func createWebhook(service WebhookManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &WebhookRequest{}
+		if err := render.Bind(r, req); err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+
+		sub, err := service.Create(r.Context(), req.URL, req.EventTypes)
+		if err != nil {
+			renderWebhookError(w, r, err, "unknown error creating webhook:")
+			return
+		}
+		resp := NewWebhookResponse(sub)
+		resp.Secret = sub.Secret
+		render.Status(r, http.StatusCreated)
+		_ = render.Render(w, r, resp)
+	}
+}
+
+func listWebhooks(service WebhookManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subs, err := service.List(r.Context())
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error listing webhooks:")
+			return
+		}
+		resp := WebhookListResponse{Webhooks: make([]WebhookResponse, 0, len(subs))}
+		for _, sub := range subs {
+			resp.Webhooks = append(resp.Webhooks, NewWebhookResponse(sub))
+		}
+		_ = render.Render(w, r, resp)
+	}
+}
+
+func deleteWebhook(service WebhookManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := webhookID(r)
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+		if err := service.Delete(r.Context(), id); err != nil {
+			renderServiceError(w, r, err, "unknown error deleting webhook:")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// webhookDeliveries serves the delivery log of a webhook, optionally filtered with ?status=pending|delivered|dead.
+func webhookDeliveries(service WebhookManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := webhookID(r)
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+		status := domain.WebhookDeliveryStatus(r.URL.Query().Get("status"))
+		deliveries, err := service.Deliveries(r.Context(), id, status)
+		if err != nil {
+			renderWebhookError(w, r, err, "unknown error listing webhook deliveries:")
+			return
+		}
+		resp := WebhookDeliveryLogResponse{Deliveries: make([]WebhookDeliveryResponse, 0, len(deliveries))}
+		for _, delivery := range deliveries {
+			resp.Deliveries = append(resp.Deliveries, NewWebhookDeliveryResponse(delivery))
+		}
+		_ = render.Render(w, r, resp)
+	}
+}
+
+// retryWebhookDelivery requeues a dead-lettered delivery.
+func retryWebhookDelivery(service WebhookManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := webhookID(r)
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(err))
+			return
+		}
+		deliveryID, err := strconv.ParseInt(chi.URLParam(r, "deliveryID"), 10, 64)
+		if err != nil {
+			_ = render.Render(w, r, errInvalidRequest(fmt.Errorf("invalid delivery id")))
+			return
+		}
+		delivery, err := service.Retry(r.Context(), id, deliveryID)
+		if err != nil {
+			renderServiceError(w, r, err, "unknown error retrying webhook delivery:")
+			return
+		}
+		render.Status(r, http.StatusAccepted)
+		_ = render.Render(w, r, NewWebhookDeliveryResponse(delivery))
+	}
+}
+
+func renderWebhookError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, domain.ErrInvalidWebhook) {
+		_ = render.Render(w, r, errInvalidRequest(err))
+		return
+	}
+	renderServiceError(w, r, err, msg)
+}
+
+func webhookID(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook id")
+	}
+	return int32(id), nil
+}
+
+func (wr *WebhookRequest) Bind(_ *http.Request) error {
+	v := validator.New()
+	if err := v.Struct(wr); err != nil {
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			return ve
+		}
+		return err
+	}
+	return nil
+}
+
+func (wr WebhookResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (wl WebhookListResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (wd WebhookDeliveryResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (wl WebhookDeliveryLogResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+// NewWebhookResponse leaves out the signing secret, which is only shown once on creation.
+func NewWebhookResponse(sub *domain.WebhookSubscription) WebhookResponse {
+	eventTypes := sub.EventTypes
+	if eventTypes == nil {
+		eventTypes = []domain.EventType{}
+	}
+	return WebhookResponse{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  sub.CreatedAt,
+	}
+}
+
+func NewWebhookDeliveryResponse(delivery *domain.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if delivery.Status == domain.WebhookDeliveryPending {
+		resp.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return resp
+}
@@ -0,0 +1,192 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	created := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		requestBody string
+		mockService *fakeWebhooks
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "201 with the secret when creating a webhook",
+			method:      http.MethodPost,
+			path:        "/webhooks",
+			requestBody: `{"url": "https://billing.example.com/hooks", "eventTypes": ["AppointmentCreated"]}`,
+			mockService: &fakeWebhooks{},
+			wantStatus:  http.StatusCreated,
+			wantBody:    `{"id":1,"url":"https://billing.example.com/hooks","eventTypes":["AppointmentCreated"],"secret":"whsec_test","createdAt":"2026-01-05T12:00:00Z"}`,
+		},
+		{
+			name:        "400 when url is missing",
+			method:      http.MethodPost,
+			path:        "/webhooks",
+			requestBody: `{"eventTypes": ["AppointmentCreated"]}`,
+			mockService: &fakeWebhooks{},
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `{"code":400,"status":"Bad Request","error":"Key: 'WebhookRequest.URL' Error:Field validation for 'URL' failed on the 'required' tag"}`,
+		},
+		{
+			name:        "400 when the service rejects the webhook",
+			method:      http.MethodPost,
+			path:        "/webhooks",
+			requestBody: `{"url": "https://billing.example.com/hooks", "eventTypes": ["AppointmentEaten"]}`,
+			mockService: &fakeWebhooks{err: fmt.Errorf("%w: unknown event type %q", domain.ErrInvalidWebhook, "AppointmentEaten")},
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `{"code":400,"status":"Bad Request","error":"invalid webhook: unknown event type \"AppointmentEaten\""}`,
+		},
+		{
+			name:        "200 without secrets when listing webhooks",
+			method:      http.MethodGet,
+			path:        "/webhooks",
+			mockService: &fakeWebhooks{subs: []*domain.WebhookSubscription{{ID: 1, URL: "https://billing.example.com/hooks", Secret: "whsec_test", CreatedAt: created}}},
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"webhooks":[{"id":1,"url":"https://billing.example.com/hooks","eventTypes":[],"createdAt":"2026-01-05T12:00:00Z"}]}`,
+		},
+		{
+			name:        "500 when listing fails",
+			method:      http.MethodGet,
+			path:        "/webhooks",
+			mockService: &fakeWebhooks{err: errors.New("boom")},
+			wantStatus:  http.StatusInternalServerError,
+			wantBody:    `{"code":500,"status":"Internal Server Error","error":"internal server error"}`,
+		},
+		{
+			name:        "204 when deleting a webhook",
+			method:      http.MethodDelete,
+			path:        "/webhooks/1",
+			mockService: &fakeWebhooks{},
+			wantStatus:  http.StatusNoContent,
+		},
+		{
+			name:        "404 when deleting an unknown webhook",
+			method:      http.MethodDelete,
+			path:        "/webhooks/2",
+			mockService: &fakeWebhooks{err: domain.ErrWebhookNotFound},
+			wantStatus:  http.StatusNotFound,
+			wantBody:    `{"code":404,"status":"Not Found","error":"webhook not found"}`,
+		},
+		{
+			name:        "400 when webhook id is not a number",
+			method:      http.MethodDelete,
+			path:        "/webhooks/abc",
+			mockService: &fakeWebhooks{},
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `{"code":400,"status":"Bad Request","error":"invalid webhook id"}`,
+		},
+		{
+			name:   "200 with the delivery log",
+			method: http.MethodGet,
+			path:   "/webhooks/1/deliveries?status=pending",
+			mockService: &fakeWebhooks{deliveries: []*domain.WebhookDelivery{
+				{ID: 9, EventID: 3, EventType: domain.EventAppointmentCreated, Status: domain.WebhookDeliveryPending, Attempts: 2, NextAttemptAt: created.Add(time.Minute), LastStatusCode: 503, LastError: "unexpected status 503", CreatedAt: created, UpdatedAt: created},
+				{ID: 8, EventID: 2, EventType: domain.EventAppointmentCreated, Status: domain.WebhookDeliveryDelivered, Attempts: 1, NextAttemptAt: created, LastStatusCode: 200, CreatedAt: created, UpdatedAt: created},
+			}},
+			wantStatus: http.StatusOK,
+			wantBody: `{"deliveries":[` +
+				`{"id":9,"eventId":3,"eventType":"AppointmentCreated","status":"pending","attempts":2,"nextAttemptAt":"2026-01-05T12:01:00Z","lastStatusCode":503,"lastError":"unexpected status 503","createdAt":"2026-01-05T12:00:00Z","updatedAt":"2026-01-05T12:00:00Z"},` +
+				`{"id":8,"eventId":2,"eventType":"AppointmentCreated","status":"delivered","attempts":1,"lastStatusCode":200,"createdAt":"2026-01-05T12:00:00Z","updatedAt":"2026-01-05T12:00:00Z"}]}`,
+		},
+		{
+			name:        "400 when delivery status is unknown",
+			method:      http.MethodGet,
+			path:        "/webhooks/1/deliveries?status=lost",
+			mockService: &fakeWebhooks{err: fmt.Errorf("%w: unknown delivery status %q", domain.ErrInvalidWebhook, "lost")},
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `{"code":400,"status":"Bad Request","error":"invalid webhook: unknown delivery status \"lost\""}`,
+		},
+		{
+			name:        "202 when retrying a dead delivery",
+			method:      http.MethodPost,
+			path:        "/webhooks/1/deliveries/9/retry",
+			mockService: &fakeWebhooks{deliveries: []*domain.WebhookDelivery{{ID: 9, EventID: 3, EventType: domain.EventAppointmentCreated, Status: domain.WebhookDeliveryPending, NextAttemptAt: created, CreatedAt: created, UpdatedAt: created}}},
+			wantStatus:  http.StatusAccepted,
+			wantBody:    `{"id":9,"eventId":3,"eventType":"AppointmentCreated","status":"pending","attempts":0,"nextAttemptAt":"2026-01-05T12:00:00Z","createdAt":"2026-01-05T12:00:00Z","updatedAt":"2026-01-05T12:00:00Z"}`,
+		},
+		{
+			name:        "409 when retrying a delivery that is not dead",
+			method:      http.MethodPost,
+			path:        "/webhooks/1/deliveries/9/retry",
+			mockService: &fakeWebhooks{err: domain.ErrWebhookDeliveryNotDead},
+			wantStatus:  http.StatusConflict,
+			wantBody:    `{"code":409,"status":"Conflict","error":"only dead-lettered webhook deliveries can be retried"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(api.ChiHandler(api.Services{Webhooks: tt.mockService}, nil))
+			defer ts.Close()
+
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewBufferString(tt.requestBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func(Body io.ReadCloser) {
+				if err := Body.Close(); err != nil {
+					t.Logf("error closing response body: %v", err)
+				}
+			}(resp.Body)
+
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			all, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantBody == "" {
+				require.Empty(t, all)
+				return
+			}
+			require.JSONEq(t, tt.wantBody, string(all))
+		})
+	}
+}
+
+type fakeWebhooks struct {
+	subs       []*domain.WebhookSubscription
+	deliveries []*domain.WebhookDelivery
+	err        error
+}
+
+func (f *fakeWebhooks) Create(_ context.Context, url string, eventTypes []domain.EventType) (*domain.WebhookSubscription, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &domain.WebhookSubscription{ID: 1, URL: url, Secret: "whsec_test", EventTypes: eventTypes, CreatedAt: time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)}, nil
+}
+
+func (f *fakeWebhooks) List(_ context.Context) ([]*domain.WebhookSubscription, error) {
+	return f.subs, f.err
+}
+
+func (f *fakeWebhooks) Delete(_ context.Context, _ int32) error {
+	return f.err
+}
+
+func (f *fakeWebhooks) Deliveries(_ context.Context, _ int32, _ domain.WebhookDeliveryStatus) ([]*domain.WebhookDelivery, error) {
+	return f.deliveries, f.err
+}
+
+func (f *fakeWebhooks) Retry(_ context.Context, _ int32, _ int64) (*domain.WebhookDelivery, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.deliveries[0], nil
+}
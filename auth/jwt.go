@@ -0,0 +1,91 @@
+// Package auth verifies JWT bearer tokens issued by an external identity provider.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jcooney/appts/domain"
+)
+
+// Leeway absorbs clock skew between us and the identity provider when checking exp and nbf.
+const Leeway = 30 * time.Second
+
+// validMethods are the asymmetric algorithms accepted, symmetric ones would let anyone holding the JWKS forge tokens.
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Claims are the registered claims plus the optional display name, email and roles, roles unknown to us are ignored.
+type Claims struct {
+	jwt.RegisteredClaims
+	Name          string   `json:"name,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified *bool    `json:"email_verified,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+}
+
+type JWTVerifier struct {
+	keys   keyfunc.Keyfunc
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier checks tokens against the JWKS at jwks, either a file path or an http(s) URL. A URL is refreshed in
+// the background until ctx is done, and again whenever a token names a key ID it has not seen. issuer and audience
+// are only checked when set.
+func NewJWTVerifier(ctx context.Context, jwks string, issuer string, audience string) (*JWTVerifier, error) {
+	var keys keyfunc.Keyfunc
+	var err error
+	if strings.HasPrefix(jwks, "https://") || strings.HasPrefix(jwks, "http://") {
+		keys, err = keyfunc.NewDefaultCtx(ctx, []string{jwks})
+	} else {
+		var raw []byte
+		raw, err = os.ReadFile(jwks)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks: %w", err)
+		}
+		keys, err = keyfunc.NewJWKSetJSON(raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load jwks: %w", err)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(validMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(Leeway),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return &JWTVerifier{keys: keys, parser: jwt.NewParser(opts...)}, nil
+}
+
+// Verify returns the principal named by a valid token's sub claim, or domain.ErrUnauthenticated.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*domain.Principal, error) {
+	claims := &Claims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.keys.KeyfuncCtx(ctx)); err != nil {
+		return nil, unauthenticated(err)
+	}
+	if claims.Subject == "" {
+		return nil, unauthenticated(errors.New("token has no sub claim"))
+	}
+	principal := &domain.Principal{Kind: domain.PrincipalJWT, Subject: claims.Subject, Name: claims.Name, Roles: domain.ParseRoles(claims.Roles)}
+	// an email the identity provider says is unverified could be anyone's, and would hand over their appointments
+	if claims.EmailVerified == nil || *claims.EmailVerified {
+		principal.Email = claims.Email
+	}
+	return principal, nil
+}
+
+// unauthenticated wraps why a token was rejected while still matching domain.ErrUnauthenticated.
+func unauthenticated(reason error) error {
+	return fmt.Errorf("%w: %w", domain.ErrUnauthenticated, reason)
+}
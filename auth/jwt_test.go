@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, jwks(t, "key-1", &key.PublicKey), 0o600))
+
+	underTest, err := NewJWTVerifier(t.Context(), jwksPath, "https://idp.example.com", "appts")
+	require.NoError(t, err)
+
+	now := time.Now()
+	valid := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://idp.example.com",
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{"appts"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Name:  "Reception",
+		Email: "reception@example.com",
+		Roles: []string{"receptionist", "owner"},
+	}
+	tests := []struct {
+		name    string
+		token   string
+		want    *domain.Principal
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: sign(t, key, "key-1", valid),
+			want:  &domain.Principal{Kind: domain.PrincipalJWT, Subject: "user-1", Name: "Reception", Email: "reception@example.com", Roles: []domain.Role{domain.RoleReceptionist}},
+		},
+		{
+			name: "unverified email is ignored",
+			token: sign(t, key, "key-1", func() Claims {
+				c := valid
+				c.EmailVerified = ptr.To(false)
+				return c
+			}()),
+			want: &domain.Principal{Kind: domain.PrincipalJWT, Subject: "user-1", Name: "Reception", Roles: []domain.Role{domain.RoleReceptionist}},
+		},
+		{
+			name: "expired beyond the leeway",
+			token: sign(t, key, "key-1", func() Claims {
+				c := valid
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-Leeway - time.Minute))
+				return c
+			}()),
+			wantErr: domain.ErrUnauthenticated,
+		},
+		{
+			name: "no expiry",
+			token: sign(t, key, "key-1", func() Claims {
+				c := valid
+				c.ExpiresAt = nil
+				return c
+			}()),
+			wantErr: domain.ErrUnauthenticated,
+		},
+		{
+			name: "other issuer",
+			token: sign(t, key, "key-1", func() Claims {
+				c := valid
+				c.Issuer = "https://evil.example.com"
+				return c
+			}()),
+			wantErr: domain.ErrUnauthenticated,
+		},
+		{
+			name: "other audience",
+			token: sign(t, key, "key-1", func() Claims {
+				c := valid
+				c.Audience = jwt.ClaimStrings{"billing"}
+				return c
+			}()),
+			wantErr: domain.ErrUnauthenticated,
+		},
+		{
+			name: "no subject",
+			token: sign(t, key, "key-1", func() Claims {
+				c := valid
+				c.Subject = ""
+				return c
+			}()),
+			wantErr: domain.ErrUnauthenticated,
+		},
+		{
+			name:    "signed by an unknown key",
+			token:   sign(t, other, "key-1", valid),
+			wantErr: domain.ErrUnauthenticated,
+		},
+		{
+			name: "symmetric algorithm",
+			token: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid).SignedString([]byte("secret"))
+				require.NoError(t, err)
+				return token
+			}(),
+			wantErr: domain.ErrUnauthenticated,
+		},
+		{
+			name:    "not a jwt",
+			token:   "nope",
+			wantErr: domain.ErrUnauthenticated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := underTest.Verify(t.Context(), tt.token)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewJWTVerifier_URL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(jwks(t, "key-1", &key.PublicKey))
+	}))
+	defer server.Close()
+
+	underTest, err := NewJWTVerifier(t.Context(), server.URL, "", "")
+	require.NoError(t, err)
+	got, err := underTest.Verify(t.Context(), sign(t, key, "key-1", Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "user-1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", got.Subject)
+
+	_, err = NewJWTVerifier(t.Context(), filepath.Join(t.TempDir(), "missing.json"), "", "")
+	require.Error(t, err)
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, kid string, claims Claims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func jwks(t *testing.T, kid string, key *ecdsa.PublicKey) []byte {
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	raw, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "EC",
+		"crv": "P-256",
+		"kid": kid,
+		"alg": "ES256",
+		"use": "sig",
+		"x":   base64.RawURLEncoding.EncodeToString(x),
+		"y":   base64.RawURLEncoding.EncodeToString(y),
+	}}})
+	require.NoError(t, err)
+	return raw
+}
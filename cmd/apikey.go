@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/jcooney/appts/config"
+	"github.com/jcooney/appts/domain"
+)
+
+const apiKeyUsage = "usage: apikey create [-role admin|receptionist|patient|integration] [-config file] <name>"
+
+// runAPIKey implements `apikey create [-role <role>] <name>`, printing the new key. It is how the first key is
+// issued, so the role defaults to admin, after which keys can be managed through /admin/api-keys.
+func runAPIKey(ctx context.Context, args []string) int {
+	if len(args) < 1 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	role := flags.String("role", string(domain.RoleAdmin), "admin, receptionist, patient or integration")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+	name := strings.Join(flags.Args(), " ")
+	cfg, err := config.Load(*configPath, os.LookupEnv)
+	if err == nil {
+		err = cfg.Require(databaseSettings(cfg)...)
+	}
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		return 1
+	}
+
+	repo, closeRepo, err := connect(ctx, cfg)
+	if err != nil {
+		slog.Error("error opening the store", "error", err)
+		return 1
+	}
+	defer closeRepo()
+
+	key, secret, err := domain.NewAPIKeyService(repo).Create(ctx, name, domain.Role(*role))
+	if err != nil {
+		slog.Error("error creating api key", "error", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "created %s api key %d %q, it will not be shown again\n", key.Role, key.ID, key.Name)
+	fmt.Println(secret)
+	return 0
+}
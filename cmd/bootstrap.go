@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jcooney/appts/config"
+	"github.com/jcooney/appts/schema"
+)
+
+// runBootstrap implements `bootstrap [-config file]`, connecting as MIGRATE_DB_URL to create the role DB_URL signs in
+// as, with DB_URL's password or that in DB_PASSWORD_FILE. It runs once before the first `migrate up`, and again to
+// rotate the password. Platforms that manage roles themselves skip it and create the role as described in the README.
+func runBootstrap(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: bootstrap [-config file]")
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath, os.LookupEnv)
+	if err == nil && cfg.Database.Backend != config.BackendPostgres {
+		err = fmt.Errorf("DB_BACKEND=%s has no database role to bootstrap", cfg.Database.Backend)
+	}
+	if err == nil {
+		err = cfg.Require("DB_URL", "MIGRATE_DB_URL")
+	}
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		return 1
+	}
+	app, err := pgx.ParseConfig(cfg.Database.URL)
+	if err != nil {
+		slog.Error("invalid DB_URL", "error", err)
+		return 1
+	}
+	if app.Password == "" {
+		slog.Error("DB_URL has no password, set one or DB_PASSWORD_FILE")
+		return 1
+	}
+
+	conn, err := pgx.Connect(ctx, cfg.Database.MigrateURL)
+	if err != nil {
+		slog.Error("unable to connect to database", "error", err)
+		return 1
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+	if err := schema.Bootstrap(ctx, conn, app.User, app.Password); err != nil {
+		slog.Error("error bootstrapping database role", "error", err)
+		return 1
+	}
+	slog.Info("database role ready", "role", app.User)
+	return 0
+}
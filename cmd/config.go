@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jcooney/appts/config"
+)
+
+// configFlag adds -config to flags, defaulting to CONFIG_FILE. Environment variables override the file's settings.
+func configFlag(flags *flag.FlagSet) *string {
+	return flags.String("config", os.Getenv("CONFIG_FILE"), "YAML config file, environment variables take precedence")
+}
+
+// printRedacted prints cfg for --print-config, followed by loadErr when it is invalid, returning the exit code.
+func printRedacted(cfg *config.Config, loadErr error) int {
+	out, err := cfg.Redacted()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	_, _ = os.Stdout.Write(out)
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, loadErr)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/jcooney/appts/domain"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// latestMigration returns the highest version in src, the version the database should be at, and closes src.
+func latestMigration(src source.Driver) (uint, error) {
+	defer func() { _ = src.Close() }()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("first migration: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("next migration after %d: %w", version, err)
+		}
+		version = next
+	}
+}
+
+type migrationVersioner interface {
+	MigrationVersion(ctx context.Context) (uint, bool, error)
+}
+
+// migrationCheck fails while the database is dirty or behind the version this build expects, e.g. before `migrate up`
+// has run for this release. A database a newer release has already migrated passes, so replicas of the old release
+// keep serving during a rolling deploy.
+func migrationCheck(repo migrationVersioner, want uint) domain.HealthCheck {
+	return func(ctx context.Context) error {
+		version, dirty, err := repo.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+		if version < want {
+			return fmt.Errorf("at migration %d, want at least %d", version, want)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jcooney/appts/config"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/importer"
+	"github.com/jcooney/appts/publichols"
+)
+
+// runImport implements `import [-format csv|ndjson] [-dry-run] [-config file] <file|->`, returning the process exit code. It is
+// non-zero unless every row was valid, and for a real run committed.
+func runImport(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "csv or ndjson, defaults to the file extension")
+	dryRun := flags.Bool("dry-run", false, "validate every row against the database without committing")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import [-format csv|ndjson] [-dry-run] [-config file] <file|->")
+		return 2
+	}
+	cfg, err := config.Load(*configPath, os.LookupEnv)
+	if err == nil {
+		err = cfg.Require(databaseSettings(cfg)...)
+	}
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		return 1
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			slog.Error("error opening import file", "error", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	rows, err := importer.Parse(in, importer.Format(*format))
+	if err != nil {
+		slog.Error("error reading import file", "error", err)
+		return 1
+	}
+
+	publicHolidayGetter, err := publichols.NewPublicHolidayGetter(cfg.Holidays.URL, cfg.Holidays.Country)
+	if err != nil {
+		slog.Error("error initialising public holiday checker client", "error", err)
+		return 1
+	}
+	repo, closeRepo, err := connect(ctx, cfg)
+	if err != nil {
+		slog.Error("error opening the store", "error", err)
+		return 1
+	}
+	defer closeRepo()
+
+	creator := domain.NewAppointmentCreatorService(repo, publicHolidayGetter, time.Now)
+	report, err := domain.NewAppointmentImportService(creator, repo).Import(ctx, rows, *dryRun)
+	if err != nil {
+		slog.Error("error importing appointments", "error", err)
+		return 1
+	}
+
+	failed := 0
+	for _, row := range report.Rows {
+		if row.Err != nil {
+			failed++
+			fmt.Printf("line %d: %v\n", row.Line, row.Err)
+		}
+	}
+	fmt.Printf("%d rows, %d failed, dry run: %t, committed: %t\n", len(report.Rows), failed, report.DryRun, report.Committed)
+	if failed > 0 || (!report.DryRun && !report.Committed) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/config"
+	"github.com/jcooney/appts/domain"
+)
+
+// rateLimiter builds a limiter counting in repo, returning nil when the limit is off.
+func rateLimiter(repo domain.RateLimitRepository, limit config.RateLimit) api.RateLimiter {
+	if limit.Off {
+		return nil
+	}
+	return domain.NewRateLimiter(repo, limit.RateLimit, time.Now)
+}
@@ -3,58 +3,216 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jcooney/appts/api"
+	"github.com/jcooney/appts/auth"
+	"github.com/jcooney/appts/config"
 	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/metrics"
+	"github.com/jcooney/appts/notify"
+	"github.com/jcooney/appts/outbox"
 	"github.com/jcooney/appts/publichols"
-	"github.com/jcooney/appts/repository"
+	"github.com/jcooney/appts/reminder"
+	"github.com/jcooney/appts/tracing"
+	"github.com/jcooney/appts/webhook"
 )
 
+const (
+	outboxPollInterval = 2 * time.Second
+	outboxBatchSize    = 100
+	webhookInterval    = 5 * time.Second
+	webhookBatchSize   = 20
+	webhookTimeout     = 10 * time.Second
+)
+
+const usage = `usage: appts <command> [arguments]
+
+commands:
+  serve      run the api, the default when no command is given
+  bootstrap  create the database role the api connects as
+  migrate    apply or roll back database migrations
+  import     bulk import bookings from a file
+  apikey     issue an api key`
+
 func main() {
-	slog.Info("Starting appointment service")
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	migrationDBURL, ok := os.LookupEnv("MIGRATE_DB_URL")
-	if !ok {
-		log.Fatal("DB_URL environment variable not set")
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	var code int
+	switch command {
+	case "serve":
+		code = runServe(ctx, args)
+	case "bootstrap":
+		code = runBootstrap(ctx, args)
+	case "migrate":
+		code = runMigrate(ctx, args)
+	case "import":
+		code = runImport(ctx, args)
+	case "apikey":
+		code = runAPIKey(ctx, args)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		code = 2
+	}
+	stop()
+	os.Exit(code)
+}
+
+// runServe implements `serve [-config file] [--print-config]`. The database must already be migrated, see
+// runMigrate, until it is /readyz reports the migrations as failing. DB_BACKEND=memory needs no database at all.
+func runServe(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	configPath := configFlag(flags)
+	printConfig := flags.Bool("print-config", false, "print the config, with secrets redacted, and exit")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath, os.LookupEnv)
+	if err == nil {
+		err = cfg.Require(append(databaseSettings(cfg), "MANAGE_TOKEN_SECRET")...)
+	}
+	if *printConfig {
+		return printRedacted(cfg, err)
 	}
-	m, err := migrate.New("file://schema/ddl", migrationDBURL)
 	if err != nil {
-		log.Fatalf("error initialising migrations: %v", err)
+		slog.Error("error loading config", "error", err)
+		return 1
 	}
-	err = m.Up()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) { // o_O
-		log.Fatalf("error running migrations: %v", err)
+	serve(ctx, cfg)
+	return 0
+}
+
+func serve(ctx context.Context, cfg *config.Config) {
+	slog.Info("Starting appointment service")
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatalf("error initialising tracing: %v", err)
 	}
 
-	publicHolidayGetter, err := publichols.NewPublicHolidayGetter("https://date.nager.at")
+	appMetrics := metrics.New()
+	holidayClient, err := publichols.NewPublicHolidayGetter(cfg.Holidays.URL, cfg.Holidays.Country,
+		publichols.WithHTTPClient(&http.Client{Transport: tracing.Transport(http.DefaultTransport)}))
 	if err != nil {
 		log.Fatalf("error initialising public holiday checker client: %v", err)
 	}
+	publicHolidayGetter := domain.NewHolidaySourceHealth(appMetrics.HolidayChecker(holidayClient))
 
-	dbURL, ok := os.LookupEnv("DB_URL")
-	if !ok {
-		log.Fatal("DB_URL environment variable not set")
+	health := domain.NewHealthService(healthCheckTimeout)
+	repo := openStore(ctx, cfg, health, appMetrics)
+	health.AddOptionalCheck("holidays", publicHolidayGetter.Check)
+	location, err := domain.NewLocation(cfg.Clinic.Name, cfg.Clinic.Address, cfg.Clinic.TimeZone)
+	if err != nil {
+		log.Fatalf("invalid CLINIC_TIMEZONE: %v", err)
 	}
-	pool, err := pgxpool.New(ctx, dbURL)
+
+	signer, err := domain.NewManageTokenSigner([]byte(cfg.Manage.TokenSecret))
 	if err != nil {
-		log.Fatalf("unable to connect to database: %v", err)
+		log.Fatalf("invalid MANAGE_TOKEN_SECRET: %v", err)
 	}
-	repo := repository.NewRepository(pool)
-	service := domain.NewAppointmentCreatorService(repo, publicHolidayGetter, time.Now)
-	server := &http.Server{Addr: "0.0.0.0:3333", Handler: api.ChiHandler(service)}
+
+	var feeds api.CalendarFeeds
+	if cfg.CalendarFeeds.Secret != "" {
+		feeds, err = domain.NewCalendarFeedService([]byte(cfg.CalendarFeeds.Secret), time.Now, cfg.CalendarFeeds.TTL)
+		if err != nil {
+			log.Fatalf("invalid CALENDAR_FEED_SECRET: %v", err)
+		}
+	}
+
+	var tokens api.TokenVerifier
+	if cfg.JWT.JWKS != "" {
+		tokens, err = auth.NewJWTVerifier(ctx, cfg.JWT.JWKS, cfg.JWT.Issuer, cfg.JWT.Audience)
+		if err != nil {
+			log.Fatalf("invalid JWT_JWKS: %v", err)
+		}
+	}
+
+	notifiers := reminderNotifiers(cfg)
+
+	uncapped := domain.NewAppointmentCreatorService(repo, publicHolidayGetter, time.Now)
+	service := uncapped
+	if cfg.Bookings.MaxPerPatient > 0 {
+		service = uncapped.WithPatientLimit(repo, int(cfg.Bookings.MaxPerPatient))
+	}
+	service = service.WithObserver(appMetrics)
+	holdService := domain.NewHoldCreatorService(repo, publicHolidayGetter, time.Now, cfg.Bookings.HoldTTL)
+	seriesService := domain.NewAppointmentSeriesService(service, repo)
+	importService := domain.NewAppointmentImportService(uncapped, repo) // staff migrating existing bookings
+	listService := domain.NewAppointmentListService(repo)
+	getterService := domain.NewAppointmentGetterService(repo)
+	manageService := domain.NewManageService(repo, signer, publicHolidayGetter, time.Now, cfg.Manage.TokenTTL)
+	server := &http.Server{Addr: cfg.Server.Addr, Handler: api.ChiHandler(api.Services{
+		Appointments: service,
+		Holds:        holdService,
+		Series:       seriesService,
+		Importer:     importService,
+		Lister:       listService,
+		Getter:       getterService,
+		Canceller:    domain.NewAppointmentCancelService(repo),
+		History:      domain.NewAuditService(repo),
+		Webhooks:     domain.NewWebhookService(repo),
+		Manage:       manageService,
+		Feeds:        feeds,
+		PublicURL:    cfg.Server.PublicURL,
+		APIKeys:      domain.NewAPIKeyService(repo),
+		Tokens:       tokens,
+		KeyLimiter:   rateLimiter(repo, cfg.Bookings.RateLimitPerKey),
+		IPLimiter:    rateLimiter(repo, cfg.Bookings.RateLimitPerIP),
+		Proxies:      cfg.Server.TrustedProxies,
+		Health:       health,
+		Metrics:      appMetrics,
+		Tracing:      tracing.Middleware,
+	}, location)}
+
+	publishers := outbox.Publishers{webhook.NewFanout(repo)}
+	confirmations, err := confirmationSender(cfg, repo, manageService, location)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if confirmations != nil {
+		publishers = append(publishers, notify.NewConfirmationQueue(repo))
+	}
+	relay := outbox.NewRelay(repo, publishers, outboxPollInterval, outboxBatchSize)
+	relayDone := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(relayDone)
+	}()
+	dispatcher := webhook.NewDispatcher(repo, &http.Client{Timeout: webhookTimeout}, webhookInterval, webhookBatchSize, time.Now)
+	dispatcherDone := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(dispatcherDone)
+	}()
+
+	confirmationsDone := make(chan struct{})
+	go func() {
+		if confirmations != nil {
+			confirmations.Run(ctx)
+		}
+		close(confirmationsDone)
+	}()
+
+	scheduler := reminder.NewScheduler(repo, notifiers, cfg.Reminders.Leads, location, reminderInterval, time.Now)
+	schedulerDone := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(schedulerDone)
+	}()
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -64,12 +222,24 @@ func main() {
 
 	<-ctx.Done()
 
+	// Fail readiness first so the orchestrator stops routing here before the listener closes.
+	slog.Info("Draining", "delay", cfg.Server.DrainDelay)
+	health.Drain()
+	time.Sleep(cfg.Server.DrainDelay)
+
 	slog.Info("Shutting down server")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Fatal(err)
 	}
+	<-relayDone
+	<-dispatcherDone
+	<-confirmationsDone
+	<-schedulerDone
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("error flushing traces", "error", err)
+	}
 	slog.Info("Server gracefully stopped")
 }
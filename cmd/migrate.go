@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jcooney/appts/config"
+	"github.com/jcooney/appts/repository/sqlite"
+	"github.com/jcooney/appts/schema"
+)
+
+const migrateUsage = "usage: migrate [-config file] up [N] | down [N] | version | force <version>"
+
+// runMigrate implements `migrate [-config file] up [N] | down [N] | version | force <version>` against MIGRATE_DB_URL,
+// or the file at SQLITE_PATH with its own migrations, so the schema is changed as a deploy step rather than by every
+// replica as it starts. up applies every pending migration unless N is given, down rolls back one unless N is given,
+// and force records a version after a failed migration has been fixed by hand, clearing the dirty flag.
+func runMigrate(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 || flags.NArg() > 2 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	action := flags.Arg(0)
+	var n int
+	if flags.NArg() == 2 {
+		var err error
+		n, err = strconv.Atoi(flags.Arg(1))
+		if err != nil || (n < 1 && action != "force") {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+	}
+	if action == "force" && flags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	cfg, err := config.Load(*configPath, os.LookupEnv)
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		return 1
+	}
+	m, err := newMigrate(cfg)
+	if err != nil {
+		slog.Error("error initialising migrations", "error", err)
+		return 1
+	}
+	defer func() { _, _ = m.Close() }()
+	go func() {
+		<-ctx.Done()
+		m.GracefulStop <- true // finish the migration in progress, then stop
+	}()
+
+	switch action {
+	case "up":
+		if n == 0 {
+			err = m.Up()
+		} else {
+			err = m.Steps(n)
+		}
+	case "down":
+		err = m.Steps(-max(n, 1))
+	case "force":
+		err = m.Force(n)
+	case "version":
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		slog.Error("error running migrations", "action", action, "error", err)
+		return 1
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Println("no migrations applied")
+	case err != nil:
+		slog.Error("error reading migration version", "error", err)
+		return 1
+	case dirty:
+		fmt.Printf("%d (dirty)\n", version)
+		return 1
+	default:
+		fmt.Println(version)
+	}
+	return 0
+}
+
+// newMigrate returns the migrations of cfg.Database.Backend, applied to its database.
+func newMigrate(cfg *config.Config) (*migrate.Migrate, error) {
+	switch cfg.Database.Backend {
+	case config.BackendPostgres:
+		if err := cfg.Require("MIGRATE_DB_URL"); err != nil {
+			return nil, err
+		}
+		return schema.NewPostgresMigrate(cfg.Database.MigrateURL)
+	case config.BackendSQLite:
+		if err := cfg.Require("SQLITE_PATH"); err != nil {
+			return nil, err
+		}
+		db, err := sqlite.Open(cfg.Database.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return schema.NewSQLiteMigrate(db)
+	}
+	return nil, fmt.Errorf("DB_BACKEND=%s has no migrations", cfg.Database.Backend)
+}
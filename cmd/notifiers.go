@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/jcooney/appts/config"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/notify"
+)
+
+const (
+	reminderInterval      = time.Minute
+	smsTimeout            = 10 * time.Second
+	smtpTimeout           = 30 * time.Second
+	confirmationInterval  = 5 * time.Second
+	confirmationBatchSize = 20
+)
+
+// reminderNotifiers builds the notifiers named in REMINDER_NOTIFIERS, which the config has checked are configured.
+func reminderNotifiers(cfg *config.Config) []domain.Notifier {
+	var notifiers []domain.Notifier
+	for _, name := range cfg.Reminders.Notifiers {
+		switch name {
+		case "log":
+			notifiers = append(notifiers, notify.Log{})
+		case "email":
+			notifiers = append(notifiers, emailNotifier(cfg.SMTP))
+		case "sms":
+			notifiers = append(notifiers, notify.NewSMS(cfg.SMS.GatewayURL, cfg.SMS.GatewayToken, &http.Client{Timeout: smsTimeout}))
+		}
+	}
+	return notifiers
+}
+
+// emailNotifier sends through SMTP_ADDR, authenticating with SMTP_USERNAME and SMTP_PASSWORD when a username is set.
+func emailNotifier(cfg config.SMTP) *notify.Email {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := strings.Cut(cfg.Addr, ":")
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return notify.NewEmail(cfg.Addr, cfg.From, auth, smtpTimeout)
+}
+
+// confirmationSender emails the booking confirmations queued in repo when SMTP_ADDR is set, using the templates in
+// CONFIRMATION_TEMPLATE_DIR, or its subdirectory for the clinic, in place of the built in ones. It returns nil when
+// email is not configured.
+func confirmationSender(cfg *config.Config, repo store, manage *domain.ManageService, location *domain.Location) (*notify.Confirmations, error) {
+	if cfg.SMTP.Addr == "" {
+		return nil, nil
+	}
+	templates, err := notify.LoadConfirmationTemplates(cfg.Confirmations.TemplateDir, location)
+	if err != nil {
+		return nil, fmt.Errorf("load confirmation templates: %w", err)
+	}
+	links := publicLinks{baseURL: strings.TrimSuffix(cfg.Server.PublicURL, "/"), manage: manage}
+	return notify.NewConfirmations(repo, repo, emailNotifier(cfg.SMTP), templates, location, links, confirmationInterval, confirmationBatchSize, time.Now), nil
+}
+
+// publicLinks points patients at the api as reached from outside, PUBLIC_URL.
+type publicLinks struct {
+	baseURL string
+	manage  *domain.ManageService
+}
+
+// CancelURL issues a fresh manage token, so every confirmation carries a working link.
+func (l publicLinks) CancelURL(ctx context.Context, appt *domain.Appointment) (string, error) {
+	token, err := l.manage.Issue(ctx, appt.ID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/manage/%s", l.baseURL, token), nil
+}
+
+func (l publicLinks) CalendarURL(appt *domain.Appointment) string {
+	return fmt.Sprintf("%s/appts/%s.ics", l.baseURL, appt.PublicID)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jcooney/appts/config"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/metrics"
+	"github.com/jcooney/appts/notify"
+	"github.com/jcooney/appts/outbox"
+	"github.com/jcooney/appts/reminder"
+	"github.com/jcooney/appts/repository"
+	"github.com/jcooney/appts/repository/memory"
+	"github.com/jcooney/appts/repository/sqlite"
+	"github.com/jcooney/appts/schema"
+	"github.com/jcooney/appts/tracing"
+	"github.com/jcooney/appts/webhook"
+)
+
+// store is every repository method serve uses, implemented by each DB_BACKEND.
+type store interface {
+	domain.AppointmentPersistorRepository
+	domain.AppointmentTransactor
+	domain.AppointmentListerRepository
+	domain.AppointmentGetterRepository
+	domain.LimitedAppointmentPersistor
+	domain.HoldPersistorRepository
+	domain.ManageRepository
+	domain.AuditRepository
+	domain.APIKeyRepository
+	domain.RateLimitRepository
+	domain.WebhookRepository
+	outbox.Store
+	webhook.Store
+	webhook.FanoutStore
+	reminder.Store
+	notify.ConfirmationQueueStore
+	notify.ConfirmationStore
+}
+
+// databaseSettings names the settings cfg.Database.Backend needs to reach its database.
+func databaseSettings(cfg *config.Config) []string {
+	switch cfg.Database.Backend {
+	case config.BackendPostgres:
+		return []string{"DB_URL"}
+	case config.BackendSQLite:
+		return []string{"SQLITE_PATH"}
+	}
+	return nil
+}
+
+// openStore returns the repository for cfg.Database.Backend, registering the health checks of a database and the
+// metrics of a postgres pool. An in-memory store starts with an admin api key, printed to stderr.
+func openStore(ctx context.Context, cfg *config.Config, health *domain.HealthService, appMetrics *metrics.Metrics) store {
+	switch cfg.Database.Backend {
+	case config.BackendMemory:
+		slog.Warn("Using the in-memory store, nothing is kept once the service stops")
+		repo := memory.NewRepository()
+		// `apikey create` cannot reach a store inside this process, so the first key is issued here.
+		key, secret, err := domain.NewAPIKeyService(repo).Create(ctx, "local development", domain.RoleAdmin)
+		if err != nil {
+			log.Fatalf("error creating api key: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "created %s api key %d %q for the in-memory store: %s\n", key.Role, key.ID, key.Name, secret)
+		return repo
+	case config.BackendSQLite:
+		src, err := schema.SQLiteSource()
+		if err != nil {
+			log.Fatalf("error reading migrations: %v", err)
+		}
+		wantMigration, err := latestMigration(src)
+		if err != nil {
+			log.Fatalf("error reading migrations: %v", err)
+		}
+		db, err := sqlite.Open(cfg.Database.SQLitePath)
+		if err != nil {
+			log.Fatalf("invalid SQLITE_PATH: %v", err)
+		}
+		repo := sqlite.NewRepository(db)
+		health.AddCheck("database", db.PingContext)
+		health.AddCheck("migrations", migrationCheck(repo, wantMigration))
+		return repo
+	}
+
+	src, err := schema.PostgresSource()
+	if err != nil {
+		log.Fatalf("error reading migrations: %v", err)
+	}
+	wantMigration, err := latestMigration(src)
+	if err != nil {
+		log.Fatalf("error reading migrations: %v", err)
+	}
+	poolConfig, err := pgxpool.ParseConfig(cfg.Database.URL)
+	if err != nil {
+		log.Fatalf("invalid DB_URL: %v", err)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		log.Fatalf("unable to connect to database: %v", err)
+	}
+	appMetrics.Register(metrics.NewPoolCollector(pool))
+	repo := repository.NewRepository(pool)
+	health.AddCheck("database", pool.Ping)
+	health.AddCheck("migrations", migrationCheck(repo, wantMigration))
+	return repo
+}
+
+// connect opens the postgres or SQLite repository for a command that runs once, returning a func that closes it.
+// The in-memory store cannot be reached from outside the serving process.
+func connect(ctx context.Context, cfg *config.Config) (store, func(), error) {
+	switch cfg.Database.Backend {
+	case config.BackendPostgres:
+		pool, err := pgxpool.New(ctx, cfg.Database.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to connect to database: %w", err)
+		}
+		return repository.NewRepository(pool), pool.Close, nil
+	case config.BackendSQLite:
+		db, err := sqlite.Open(cfg.Database.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sqlite.NewRepository(db), func() { _ = db.Close() }, nil
+	}
+	return nil, nil, fmt.Errorf("DB_BACKEND=%s keeps nothing outside the serving process", cfg.Database.Backend)
+}
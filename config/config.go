@@ -0,0 +1,169 @@
+// Package config loads the service's settings from defaults, an optional YAML file and the environment, in that order
+// of precedence, and validates them before anything is started.
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// Config is every setting the service reads. Each field names its YAML key within its section, the environment
+// variable that overrides it and its default. Secrets are redacted when the config is printed.
+type Config struct {
+	Server        Server        `yaml:"server"`
+	Database      Database      `yaml:"database"`
+	Holidays      Holidays      `yaml:"holidays"`
+	Clinic        Clinic        `yaml:"clinic"`
+	Bookings      Bookings      `yaml:"bookings"`
+	Manage        Manage        `yaml:"manage"`
+	CalendarFeeds CalendarFeeds `yaml:"calendarFeeds"`
+	JWT           JWT           `yaml:"jwt"`
+	Reminders     Reminders     `yaml:"reminders"`
+	SMTP          SMTP          `yaml:"smtp"`
+	SMS           SMS           `yaml:"sms"`
+	Confirmations Confirmations `yaml:"confirmations"`
+	Tracing       Tracing       `yaml:"tracing"`
+}
+
+type Server struct {
+	Addr            string         `yaml:"addr" env:"LISTEN_ADDR" default:"0.0.0.0:3333"`
+	PublicURL       string         `yaml:"publicURL" env:"PUBLIC_URL" default:"http://localhost:3333"` // the api as patients reach it
+	ShutdownTimeout time.Duration  `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+	DrainDelay      time.Duration  `yaml:"drainDelay" env:"DRAIN_DELAY" default:"5s"` // how long /readyz fails before the listener closes
+	TrustedProxies  []netip.Prefix `yaml:"trustedProxies" env:"TRUSTED_PROXIES"`      // CIDRs of reverse proxies whose X-Forwarded-For is believed
+}
+
+// Database URLs may leave out their password and have it read from a file instead, such as a mounted Docker or
+// Kubernetes secret. The sqlite backend keeps everything in the file at SQLitePath, and the memory backend in the
+// process, both ignoring the rest.
+type Database struct {
+	Backend             string `yaml:"backend" env:"DB_BACKEND" default:"postgres"` // postgres, sqlite or memory
+	URL                 string `yaml:"url" env:"DB_URL" secret:"url"`
+	PasswordFile        string `yaml:"passwordFile" env:"DB_PASSWORD_FILE"`
+	MigrateURL          string `yaml:"migrateURL" env:"MIGRATE_DB_URL" secret:"url"` // a role allowed to run the DDL
+	MigratePasswordFile string `yaml:"migratePasswordFile" env:"MIGRATE_DB_PASSWORD_FILE"`
+	SQLitePath          string `yaml:"sqlitePath" env:"SQLITE_PATH"`
+}
+
+type Holidays struct {
+	URL     string `yaml:"url" env:"HOLIDAYS_URL" default:"https://date.nager.at"`
+	Country string `yaml:"country" env:"HOLIDAYS_COUNTRY" default:"GB"` // ISO 3166-1 alpha-2
+}
+
+type Clinic struct {
+	Name     string `yaml:"name" env:"CLINIC_NAME"`
+	Address  string `yaml:"address" env:"CLINIC_ADDRESS"`
+	TimeZone string `yaml:"timeZone" env:"CLINIC_TIMEZONE" default:"Europe/London"`
+}
+
+type Bookings struct {
+	HoldTTL         time.Duration `yaml:"holdTTL" env:"HOLD_TTL" default:"10m"`
+	MaxPerPatient   Limit         `yaml:"maxPerPatient" env:"MAX_PATIENT_BOOKINGS" default:"4"`
+	RateLimitPerKey RateLimit     `yaml:"rateLimitPerKey" env:"RATE_LIMIT_PER_KEY" default:"30/1m"`
+	RateLimitPerIP  RateLimit     `yaml:"rateLimitPerIP" env:"RATE_LIMIT_PER_IP" default:"60/1m"`
+}
+
+type Manage struct {
+	TokenSecret string        `yaml:"tokenSecret" env:"MANAGE_TOKEN_SECRET" secret:"true"`
+	TokenTTL    time.Duration `yaml:"tokenTTL" env:"MANAGE_TOKEN_TTL" default:"2160h"`
+}
+
+// CalendarFeeds are issued and served when Secret is set.
+type CalendarFeeds struct {
+	Secret string        `yaml:"secret" env:"CALENDAR_FEED_SECRET" secret:"true"`
+	TTL    time.Duration `yaml:"ttl" env:"CALENDAR_FEED_TTL" default:"8760h"`
+}
+
+// JWT bearer tokens are accepted when JWKS is set.
+type JWT struct {
+	JWKS     string `yaml:"jwks" env:"JWT_JWKS"`
+	Issuer   string `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience string `yaml:"audience" env:"JWT_AUDIENCE"`
+}
+
+type Reminders struct {
+	Leads     []time.Duration `yaml:"leads" env:"REMINDER_LEADS" default:"24h"`         // before a visit to send reminders at
+	Notifiers []string        `yaml:"notifiers" env:"REMINDER_NOTIFIERS" default:"log"` // log, email and/or sms
+}
+
+// SMTP sends reminder and confirmation emails when Addr is set.
+type SMTP struct {
+	Addr     string `yaml:"addr" env:"SMTP_ADDR"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+type SMS struct {
+	GatewayURL   string `yaml:"gatewayURL" env:"SMS_GATEWAY_URL"`
+	GatewayToken string `yaml:"gatewayToken" env:"SMS_GATEWAY_TOKEN" secret:"true"`
+}
+
+type Confirmations struct {
+	TemplateDir string `yaml:"templateDir" env:"CONFIRMATION_TEMPLATE_DIR"` // overrides the built in templates
+}
+
+// Tracing picks the span exporter, the OTLP exporter itself reads the standard OTEL_EXPORTER_OTLP_* variables.
+type Tracing struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none"`
+}
+
+// Database backends.
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendMemory   = "memory"
+)
+
+// Limit is a positive cap, or 0 when it is "off".
+type Limit int
+
+func (l *Limit) UnmarshalText(text []byte) error {
+	if string(text) == "off" {
+		*l = 0
+		return nil
+	}
+	n, err := strconv.Atoi(string(text))
+	if err != nil || n < 1 {
+		return fmt.Errorf("%q is not a positive integer or off", text)
+	}
+	*l = Limit(n)
+	return nil
+}
+
+func (l Limit) MarshalText() ([]byte, error) {
+	if l == 0 {
+		return []byte("off"), nil
+	}
+	return []byte(strconv.Itoa(int(l))), nil
+}
+
+// RateLimit is a domain.RateLimit such as "30/1m", or "off".
+type RateLimit struct {
+	domain.RateLimit
+	Off bool
+}
+
+func (r *RateLimit) UnmarshalText(text []byte) error {
+	if string(text) == "off" {
+		*r = RateLimit{Off: true}
+		return nil
+	}
+	limit, err := domain.ParseRateLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*r = RateLimit{RateLimit: limit}
+	return nil
+}
+
+func (r RateLimit) MarshalText() ([]byte, error) {
+	if r.Off {
+		return []byte("off"), nil
+	}
+	return fmt.Appendf(nil, "%d/%s", r.Requests, r.Per), nil
+}
@@ -0,0 +1,347 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jcooney/appts/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidConfig = fmt.Errorf("invalid config")
+
+// setting is a single field of Config along with where it is read from.
+type setting struct {
+	section string
+	key     string
+	env     string
+	def     string
+	secret  string // "true" to redact the value, "url" to redact only a URL's password
+	value   reflect.Value
+}
+
+func (s setting) name() string {
+	return s.section + "." + s.key
+}
+
+func settings(c *Config) []setting {
+	var all []setting
+	sections := reflect.ValueOf(c).Elem()
+	for i := range sections.NumField() {
+		section := sections.Type().Field(i)
+		fields := sections.Field(i)
+		for j := range fields.NumField() {
+			field := fields.Type().Field(j)
+			all = append(all, setting{
+				section: section.Tag.Get("yaml"),
+				key:     field.Tag.Get("yaml"),
+				env:     field.Tag.Get("env"),
+				def:     field.Tag.Get("default"),
+				secret:  field.Tag.Get("secret"),
+				value:   fields.Field(j),
+			})
+		}
+	}
+	return all
+}
+
+// Load reads the YAML file at path, which may be empty for none, and then lookupEnv, over the defaults. The config is
+// returned alongside any error so it can still be printed, every problem found is joined into the error.
+func Load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	file := map[string]string{}
+	if path != "" {
+		var err error
+		if file, err = readFile(path); err != nil {
+			return &Config{}, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+	}
+
+	c := &Config{}
+	var errs []error
+	for _, s := range settings(c) {
+		raw, from := s.def, "default"
+		if v, ok := file[s.name()]; ok {
+			raw, from = v, path
+			delete(file, s.name())
+		}
+		if v, ok := lookupEnv(s.env); ok {
+			raw, from = v, s.env
+		}
+		if err := set(s.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", s.env, from, err))
+		}
+	}
+	for key := range file {
+		errs = append(errs, fmt.Errorf("%s: unknown setting %s", path, key))
+	}
+	if err := withPasswordFile(&c.Database.URL, c.Database.PasswordFile); err != nil {
+		errs = append(errs, fmt.Errorf("DB_PASSWORD_FILE: %w", err))
+	}
+	if err := withPasswordFile(&c.Database.MigrateURL, c.Database.MigratePasswordFile); err != nil {
+		errs = append(errs, fmt.Errorf("MIGRATE_DB_PASSWORD_FILE: %w", err))
+	}
+	errs = append(errs, c.validate()...)
+	if len(errs) > 0 {
+		return c, fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return c, nil
+}
+
+// readFile flattens the file's sections into "section.key" settings, joining lists with commas as the environment
+// would.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var sections map[string]map[string]any
+	if err := yaml.Unmarshal(b, &sections); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	file := map[string]string{}
+	for section, values := range sections {
+		for key, v := range values {
+			switch v := v.(type) {
+			case nil:
+				file[section+"."+key] = ""
+			case []any:
+				items := make([]string, 0, len(v))
+				for _, item := range v {
+					items = append(items, fmt.Sprint(item))
+				}
+				file[section+"."+key] = strings.Join(items, ",")
+			default:
+				file[section+"."+key] = fmt.Sprint(v)
+			}
+		}
+	}
+	return file, nil
+}
+
+func set(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(raw)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case []string:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	case []time.Duration:
+		var ds []time.Duration
+		for _, item := range splitList(raw) {
+			d, err := time.ParseDuration(item)
+			if err != nil {
+				return err
+			}
+			ds = append(ds, d)
+		}
+		v.Set(reflect.ValueOf(ds))
+	case []netip.Prefix:
+		var prefixes []netip.Prefix
+		for _, item := range splitList(raw) {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+		}
+		v.Set(reflect.ValueOf(prefixes))
+	default:
+		panic(fmt.Sprintf("config: unsupported setting type %s", v.Type()))
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for item := range strings.SplitSeq(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// withPasswordFile sets the password of the database url, when there is one, to the contents of path less any
+// trailing newline.
+func withPasswordFile(dbURL *string, path string) error {
+	if path == "" || *dbURL == "" {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(*dbURL)
+	if err != nil || u.Scheme == "" {
+		return errors.New("the database url must be a postgres:// url to take its password from a file")
+	}
+	u.User = url.UserPassword(u.User.Username(), strings.TrimRight(string(b), "\r\n"))
+	*dbURL = u.String()
+	return nil
+}
+
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// validate checks every value is usable, whether a setting is needed at all is up to the command, see Require.
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.Server.Addr)
+	check(err == nil, "LISTEN_ADDR: %q is not a host:port", c.Server.Addr)
+	check(isAbsoluteURL(c.Server.PublicURL), "PUBLIC_URL: %q is not an absolute url", c.Server.PublicURL)
+	check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	check(c.Server.DrainDelay >= 0, "DRAIN_DELAY must not be negative")
+
+	backends := []string{BackendPostgres, BackendSQLite, BackendMemory}
+	check(slices.Contains(backends, c.Database.Backend), "DB_BACKEND: %q is not one of %s", c.Database.Backend, strings.Join(backends, ", "))
+
+	check(isAbsoluteURL(c.Holidays.URL), "HOLIDAYS_URL: %q is not an absolute url", c.Holidays.URL)
+	check(countryCode.MatchString(c.Holidays.Country), "HOLIDAYS_COUNTRY: %q is not an ISO 3166-1 alpha-2 code", c.Holidays.Country)
+	_, err = time.LoadLocation(c.Clinic.TimeZone)
+	check(err == nil, "CLINIC_TIMEZONE: %v", err)
+
+	check(c.Bookings.HoldTTL > 0, "HOLD_TTL must be positive")
+	check(c.Manage.TokenTTL > 0, "MANAGE_TOKEN_TTL must be positive")
+	check(c.Manage.TokenSecret == "" || len(c.Manage.TokenSecret) >= 32, "MANAGE_TOKEN_SECRET must be at least 32 bytes")
+	check(c.CalendarFeeds.TTL > 0, "CALENDAR_FEED_TTL must be positive")
+	check(c.CalendarFeeds.Secret == "" || len(c.CalendarFeeds.Secret) >= 32, "CALENDAR_FEED_SECRET must be at least 32 bytes")
+
+	check(len(c.Reminders.Leads) > 0, "REMINDER_LEADS must list at least one duration")
+	for _, lead := range c.Reminders.Leads {
+		check(lead >= time.Minute, "REMINDER_LEADS: %s is less than a minute", lead)
+	}
+	for _, notifier := range c.Reminders.Notifiers {
+		switch notifier {
+		case "log":
+		case "email":
+			check(c.SMTP.Addr != "" && c.SMTP.From != "", "REMINDER_NOTIFIERS: email needs SMTP_ADDR and SMTP_FROM")
+		case "sms":
+			check(c.SMS.GatewayURL != "", "REMINDER_NOTIFIERS: sms needs SMS_GATEWAY_URL")
+		default:
+			check(false, "REMINDER_NOTIFIERS: unknown notifier %q", notifier)
+		}
+	}
+	check(c.SMTP.Addr == "" || c.SMTP.From != "", "SMTP_FROM is required with SMTP_ADDR")
+
+	exporters := []string{tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterConsole}
+	check(slices.Contains(exporters, c.Tracing.Exporter), "OTEL_TRACES_EXPORTER: %q is not one of %s", c.Tracing.Exporter, strings.Join(exporters, ", "))
+	return errs
+}
+
+// Require checks the settings named by their environment variables are set, as each command needs a different few.
+func (c *Config) Require(envs ...string) error {
+	var errs []error
+	for _, s := range settings(c) {
+		if slices.Contains(envs, s.env) && s.value.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required", s.env))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// Redacted renders the config as YAML that Load would read back, annotated with each setting's environment variable.
+// Secrets that are set are replaced with REDACTED, and URLs only lose their password.
+func (c *Config) Redacted() ([]byte, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+	for _, s := range settings(c) {
+		if section == nil || root.Content[len(root.Content)-2].Value != s.section {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.section}, section)
+		}
+		value, err := format(s)
+		if err != nil {
+			return nil, fmt.Errorf("format %s: %w", s.name(), err)
+		}
+		value.LineComment = s.env
+		section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.key}, value)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+const redacted = "REDACTED"
+
+func format(s setting) (*yaml.Node, error) {
+	scalar := func(v string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	}
+	if m, ok := s.value.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return scalar(string(text)), err
+	}
+	switch v := s.value.Interface().(type) {
+	case string:
+		switch {
+		case v == "" || s.secret == "":
+			return scalar(v), nil
+		case s.secret == "url":
+			if u, err := url.Parse(v); err == nil && u.User != nil {
+				return scalar(u.Redacted()), nil
+			}
+		}
+		return scalar(redacted), nil
+	case time.Duration:
+		return scalar(v.String()), nil
+	case []string:
+		return sequence(v, scalar), nil
+	case []time.Duration:
+		items := make([]string, 0, len(v))
+		for _, d := range v {
+			items = append(items, d.String())
+		}
+		return sequence(items, scalar), nil
+	case []netip.Prefix:
+		items := make([]string, 0, len(v))
+		for _, prefix := range v {
+			items = append(items, prefix.String())
+		}
+		return sequence(items, scalar), nil
+	}
+	return nil, fmt.Errorf("unsupported setting type %s", s.value.Type())
+}
+
+func sequence(items []string, scalar func(string) *yaml.Node) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, item := range items {
+		seq.Content = append(seq.Content, scalar(item))
+	}
+	return seq
+}
@@ -6,23 +6,73 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"k8s.io/utils/ptr"
 )
 
 var ErrAppointmentOnPublicHoliday = fmt.Errorf("cannot book appointment on public holiday")
 var ErrAppointmentDateTaken = fmt.Errorf("appointment date already taken")
 var ErrAppointmentInPast = fmt.Errorf("cannot book appointment in the past")
+var ErrHoldNotFound = fmt.Errorf("hold not found or expired")
+var ErrAppointmentNotFound = fmt.Errorf("appointment not found")
+var ErrPatientBookingLimit = fmt.Errorf("patient already has the maximum number of upcoming appointments")
+
+// tracer starts spans around domain operations, it is a no-op until a tracer provider is installed.
+var tracer = otel.Tracer("github.com/jcooney/appts/domain")
+
+type AppointmentStatus string
+
+const (
+	AppointmentStatusBooked    AppointmentStatus = "booked"
+	AppointmentStatusCancelled AppointmentStatus = "cancelled"
+)
+
+func (s AppointmentStatus) Valid() bool {
+	return s == AppointmentStatusBooked || s == AppointmentStatusCancelled
+}
 
 type Appointment struct {
+	ID        int32     // assigned by the repository, internal only
+	PublicID  uuid.UUID // assigned by the repository, a UUIDv7 that identifies the appointment outside the service
 	FirstName string
 	LastName  string
 	VisitDate *time.Time
+	Email     string // optional, used for reminders
+	Phone     string // optional, E.164, used for reminders
+	Status    AppointmentStatus
+	CreatedAt time.Time
+	UpdatedAt time.Time // when the appointment was last cancelled or rescheduled, or CreatedAt if it never was
+	Sequence  int32     // incremented by every change that calendar clients must pick up, the iCalendar SEQUENCE
+	HoldToken string    // token of the hold on VisitDate being converted into this appointment, if any
 }
 
 type AppointmentPersistorRepository interface {
 	CreateAppointment(ctx context.Context, appt *Appointment) (*Appointment, error)
 }
 
+// PatientLimit caps the booked appointments on or after From that a single patient may have. Appointments belong to
+// the same patient when they have the same email, ignoring case, or the same phone. Only appointments with neither
+// fall back to the same first and last name, ignoring case, so different people sharing a name are told apart.
+type PatientLimit struct {
+	Max  int
+	From time.Time
+}
+
+// LimitedAppointmentPersistor books appointments only while the patient is under limit, counting their appointments
+// in the same transaction as the booking and under a lock on the patient, so concurrent bookings for one patient cannot
+// all slip under it. Patients at the limit are refused with ErrPatientBookingLimit.
+type LimitedAppointmentPersistor interface {
+	CreateAppointmentWithinLimit(ctx context.Context, appt *Appointment, limit PatientLimit) (*Appointment, error)
+}
+
+// BookingObserver is told the outcome of every booking attempt, e.g. to count them.
+type BookingObserver interface {
+	Booked(appt *Appointment)
+	Rejected(err error)
+}
+
 type PublicHolidayChecker interface {
 	IsPublicHoliday(context.Context, *time.Time) (bool, error)
 }
@@ -39,6 +89,10 @@ type AppointmentCreatorService struct {
 	repo    AppointmentPersistorRepository
 	checker PublicHolidayChecker
 	nowFunc func() time.Time
+
+	limited   LimitedAppointmentPersistor // nil when patients are not capped
+	maxActive int
+	observer  BookingObserver // optional
 }
 
 func NewAppointmentCreatorService(repo AppointmentPersistorRepository, checker PublicHolidayChecker, nowFunc func() time.Time) *AppointmentCreatorService {
@@ -49,28 +103,95 @@ func NewAppointmentCreatorService(repo AppointmentPersistorRepository, checker P
 	}
 }
 
+// WithPatientLimit returns a copy of the service that books through repo, refusing to book more than maxActive
+// upcoming appointments for the same patient.
+func (s *AppointmentCreatorService) WithPatientLimit(repo LimitedAppointmentPersistor, maxActive int) *AppointmentCreatorService {
+	c := *s
+	c.limited = repo
+	c.maxActive = maxActive
+	return &c
+}
+
+// WithObserver returns a copy of the service that reports every booking attempt to observer.
+func (s *AppointmentCreatorService) WithObserver(observer BookingObserver) *AppointmentCreatorService {
+	c := *s
+	c.observer = observer
+	return &c
+}
+
+// withRepo returns a copy of the service that persists through repo, e.g. one bound to a transaction. The patient cap
+// is enforced through repo too when it can be, so bookings made earlier in the same transaction are included.
+func (s *AppointmentCreatorService) withRepo(repo AppointmentPersistorRepository) *AppointmentCreatorService {
+	c := *s
+	c.repo = repo
+	if limited, ok := repo.(LimitedAppointmentPersistor); ok && c.limited != nil {
+		c.limited = limited
+	}
+	return &c
+}
+
 func (s *AppointmentCreatorService) Create(ctx context.Context, appt *Appointment) (*Appointment, error) {
+	ctx, span := tracer.Start(ctx, "AppointmentCreatorService.Create")
+	defer span.End()
+
+	saved, err := s.create(ctx, appt)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if s.observer != nil {
+		if err != nil {
+			s.observer.Rejected(err)
+		} else {
+			s.observer.Booked(saved)
+		}
+	}
+	return saved, err
+}
+
+func (s *AppointmentCreatorService) create(ctx context.Context, appt *Appointment) (*Appointment, error) {
 	if appt == nil {
 		return nil, fmt.Errorf("appointment is nil")
 	}
-	if appt.VisitDate.Before(s.nowFunc()) {
-		return nil, ErrAppointmentInPast
-	}
-	ok, err := s.checker.IsPublicHoliday(ctx, appt.VisitDate)
-	if err != nil {
-		return nil, fmt.Errorf("isPublicHoliday: %w", err)
+	now := s.nowFunc()
+	if err := validateVisitDate(ctx, s.checker, now, appt.VisitDate); err != nil {
+		return nil, err
 	}
 
-	if ok {
-		return nil, ErrAppointmentOnPublicHoliday
+	var save *Appointment
+	var err error
+	if s.limited != nil {
+		save, err = s.limited.CreateAppointmentWithinLimit(ctx, appt, PatientLimit{Max: s.maxActive, From: now})
+	} else {
+		save, err = s.repo.CreateAppointment(ctx, appt)
 	}
-
-	save, err := s.repo.CreateAppointment(ctx, appt)
 	if err != nil {
 		if errors.Is(err, ErrAppointmentDateTaken) {
 			return nil, ErrAppointmentDateTaken // bubble up to allow http error handling
 		}
+		if errors.Is(err, ErrHoldNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		if errors.Is(err, ErrPatientBookingLimit) {
+			return nil, ErrPatientBookingLimit
+		}
 		return nil, fmt.Errorf("save appointment: %w", err)
 	}
 	return save, nil
 }
+
+// validateVisitDate checks the rules every bookable date must satisfy, whether it is being held or booked.
+func validateVisitDate(ctx context.Context, checker PublicHolidayChecker, now time.Time, date *time.Time) error {
+	if date.Before(now) {
+		return ErrAppointmentInPast
+	}
+	ok, err := checker.IsPublicHoliday(ctx, date)
+	if err != nil {
+		return fmt.Errorf("isPublicHoliday: %w", err)
+	}
+
+	if ok {
+		return ErrAppointmentOnPublicHoliday
+	}
+	return nil
+}
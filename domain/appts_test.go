@@ -8,6 +8,11 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 	"k8s.io/utils/ptr"
 )
 
@@ -51,6 +56,13 @@ func TestAppointmentCreatorService_Create(t *testing.T) {
 			appointmentPersistor: conflict{},
 			wantErr:              ErrAppointmentDateTaken,
 		},
+		{
+			name:                 "bubble up hold not found error",
+			appointmentToSave:    NewAppointment("first", "last", ptr.To(fixedTimeFunc().Add(time.Hour).UTC())),
+			publicHolidayChecker: publicHolidayCheckerSuccess{},
+			appointmentPersistor: holdNotFound{},
+			wantErr:              ErrHoldNotFound,
+		},
 		{
 			name:                 "success",
 			appointmentToSave:    NewAppointment("first", "last", ptr.To(fixedTimeFunc().Add(time.Hour).UTC())),
@@ -76,6 +88,85 @@ func TestAppointmentCreatorService_Create(t *testing.T) {
 	}
 }
 
+func TestAppointmentCreatorService_PatientLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    limitedPersistor
+		wantErr error
+	}{
+		{
+			name: "books within the limit",
+			repo: limitedPersistor{},
+		},
+		{
+			name:    "refuses once the patient has the maximum upcoming appointments",
+			repo:    limitedPersistor{err: ErrPatientBookingLimit},
+			wantErr: ErrPatientBookingLimit,
+		},
+		{
+			name:    "return error from repository",
+			repo:    limitedPersistor{err: errors.New("some error")},
+			wantErr: errors.New("save appointment: some error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appt := NewAppointment("first", "last", ptr.To(fixedTimeFunc().Add(time.Hour)))
+			underTest := NewAppointmentCreatorService(appointmentPesistorError{}, publicHolidayCheckerSuccess{}, fixedTimeFunc).
+				WithPatientLimit(&tt.repo, 2)
+			got, err := underTest.Create(t.Context(), appt)
+			require.Equal(t, PatientLimit{Max: 2, From: fixedTimeFunc()}, tt.repo.limit, "only upcoming appointments count")
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr.Error())
+				require.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, appt, got)
+		})
+	}
+
+	t.Run("limits through a transactional repository", func(t *testing.T) {
+		underTest := NewAppointmentCreatorService(appointmentPersistorSuccess{}, publicHolidayCheckerSuccess{}, fixedTimeFunc).
+			WithPatientLimit(&limitedPersistor{}, 2).
+			withRepo(&limitedPersistor{err: ErrPatientBookingLimit})
+		_, err := underTest.Create(t.Context(), NewAppointment("first", "last", ptr.To(fixedTimeFunc().Add(time.Hour))))
+		require.ErrorIs(t, err, ErrPatientBookingLimit)
+	})
+}
+
+func TestAppointmentCreatorService_Observer(t *testing.T) {
+	observer := &bookingObserver{}
+	appt := NewAppointment("first", "last", ptr.To(fixedTimeFunc().Add(time.Hour)))
+
+	underTest := NewAppointmentCreatorService(appointmentPersistorSuccess{}, publicHolidayCheckerSuccess{}, fixedTimeFunc).WithObserver(observer)
+	_, err := underTest.Create(t.Context(), appt)
+	require.NoError(t, err)
+	_, err = underTest.Create(t.Context(), NewAppointment("first", "last", ptr.To(fixedTimeFunc().Add(-time.Hour))))
+	require.ErrorIs(t, err, ErrAppointmentInPast)
+	_, err = underTest.withRepo(conflict{}).Create(t.Context(), appt)
+	require.ErrorIs(t, err, ErrAppointmentDateTaken)
+
+	require.Equal(t, []*Appointment{appt}, observer.booked)
+	require.Equal(t, []error{ErrAppointmentInPast, ErrAppointmentDateTaken}, observer.rejected)
+}
+
+func TestAppointmentCreatorService_Span(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	underTest := NewAppointmentCreatorService(conflict{}, publicHolidayCheckerSuccess{}, fixedTimeFunc)
+	_, err := underTest.Create(t.Context(), NewAppointment("first", "last", ptr.To(fixedTimeFunc().Add(time.Hour))))
+	require.ErrorIs(t, err, ErrAppointmentDateTaken)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "AppointmentCreatorService.Create", spans[0].Name())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, ErrAppointmentDateTaken.Error(), spans[0].Status().Description)
+}
+
 func fixedTimeFunc() time.Time {
 	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 }
@@ -115,3 +206,37 @@ type conflict struct{}
 func (c conflict) CreateAppointment(_ context.Context, _ *Appointment) (*Appointment, error) {
 	return nil, ErrAppointmentDateTaken
 }
+
+type holdNotFound struct{}
+
+func (h holdNotFound) CreateAppointment(_ context.Context, _ *Appointment) (*Appointment, error) {
+	return nil, ErrHoldNotFound
+}
+
+// limitedPersistor records the limit it was asked to book within.
+type limitedPersistor struct {
+	appointmentPersistorSuccess
+	err   error
+	limit PatientLimit
+}
+
+func (l *limitedPersistor) CreateAppointmentWithinLimit(_ context.Context, appt *Appointment, limit PatientLimit) (*Appointment, error) {
+	l.limit = limit
+	if l.err != nil {
+		return nil, l.err
+	}
+	return appt, nil
+}
+
+type bookingObserver struct {
+	booked   []*Appointment
+	rejected []error
+}
+
+func (b *bookingObserver) Booked(appt *Appointment) {
+	b.booked = append(b.booked, appt)
+}
+
+func (b *bookingObserver) Rejected(err error) {
+	b.rejected = append(b.rejected, err)
+}
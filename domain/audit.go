@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditBooked      AuditAction = "booked"
+	AuditCancelled   AuditAction = "cancelled"
+	AuditRescheduled AuditAction = "rescheduled"
+)
+
+// AuditSystemActor is recorded for changes made without an authenticated principal, e.g. by the import command.
+const AuditSystemActor = "system"
+
+// AuditEntry is an append-only record of a change to an appointment. The repository writes one in the same
+// transaction as every booking mutation.
+type AuditEntry struct {
+	ID            int64
+	AppointmentID int32
+	Action        AuditAction
+	Actor         string       // Principal.String() of whoever made the change, or AuditSystemActor
+	RequestID     string       // the http request that made the change, if any
+	Before        *Appointment // nil when the appointment was booked
+	After         *Appointment
+	CreatedAt     time.Time
+}
+
+// AuditActor names who is making the changes in ctx.
+func AuditActor(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.String()
+	}
+	return AuditSystemActor
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request it serves, so audit entries can be traced to it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type AuditRepository interface {
+	GetAppointmentByPublicID(ctx context.Context, id uuid.UUID) (*Appointment, error)
+	ListAuditEntries(ctx context.Context, appointmentID int32) ([]*AuditEntry, error)
+}
+
+type AuditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// History returns every change made to the appointment, oldest first.
+func (s *AuditService) History(ctx context.Context, id uuid.UUID) ([]*AuditEntry, error) {
+	appt, err := s.repo.GetAppointmentByPublicID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentNotFound) {
+			return nil, ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get appointment by public id: %w", err)
+	}
+	entries, err := s.repo.ListAuditEntries(ctx, appt.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditActor(t *testing.T) {
+	require.Equal(t, AuditSystemActor, AuditActor(t.Context()))
+	ctx := WithPrincipal(t.Context(), &Principal{Kind: PrincipalAPIKey, Subject: "3"})
+	require.Equal(t, "api-key:3", AuditActor(ctx))
+
+	require.Empty(t, RequestIDFromContext(t.Context()))
+	require.Equal(t, "host/abc-000001", RequestIDFromContext(WithRequestID(t.Context(), "host/abc-000001")))
+}
+
+func TestManageChangesAreAttributedToTheLink(t *testing.T) {
+	signer, err := NewManageTokenSigner(testManageKey)
+	require.NoError(t, err)
+	repo := &manageRepo{}
+	underTest := NewManageService(repo, signer, publicHolidayCheckerSuccess{}, fixedTimeFunc, time.Hour)
+	token, err := underTest.Issue(t.Context(), 7)
+	require.NoError(t, err)
+
+	_, err = underTest.Cancel(t.Context(), token)
+	require.NoError(t, err)
+	require.Equal(t, "manage-link:"+uuid.UUID(ManageTokenID{9}).String(), repo.actor)
+}
+
+func TestAuditService_History(t *testing.T) {
+	id := uuid.MustParse("019b8e2a-6c00-7000-8000-000000000001")
+	entries := []*AuditEntry{{ID: 1, AppointmentID: 7, Action: AuditBooked, Actor: "api-key:3"}}
+	tests := []struct {
+		name    string
+		repo    auditRepo
+		want    []*AuditEntry
+		wantErr string
+	}{
+		{
+			name: "returns the appointment's entries",
+			repo: auditRepo{entries: entries},
+			want: entries,
+		},
+		{
+			name:    "bubble up not found",
+			repo:    auditRepo{getErr: ErrAppointmentNotFound},
+			wantErr: ErrAppointmentNotFound.Error(),
+		},
+		{
+			name:    "return error from get",
+			repo:    auditRepo{getErr: errors.New("some error")},
+			wantErr: "get appointment by public id: some error",
+		},
+		{
+			name:    "return error from list",
+			repo:    auditRepo{listErr: errors.New("some error")},
+			wantErr: "list audit entries: some error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewAuditService(&tt.repo).History(t.Context(), id)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, int32(7), tt.repo.listedFor)
+		})
+	}
+}
+
+// auditRepo holds appointment 7.
+type auditRepo struct {
+	entries   []*AuditEntry
+	getErr    error
+	listErr   error
+	listedFor int32
+}
+
+func (a *auditRepo) GetAppointmentByPublicID(_ context.Context, id uuid.UUID) (*Appointment, error) {
+	if a.getErr != nil {
+		return nil, a.getErr
+	}
+	return &Appointment{ID: 7, PublicID: id}, nil
+}
+
+func (a *auditRepo) ListAuditEntries(_ context.Context, appointmentID int32) ([]*AuditEntry, error) {
+	a.listedFor = appointmentID
+	return a.entries, a.listErr
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnauthenticated = fmt.Errorf("missing or invalid credentials")
+var ErrAPIKeyNotFound = fmt.Errorf("api key not found")
+
+// APIKeyPrefix starts every api key, telling them apart from JWTs and making leaked keys easy to scan for.
+const APIKeyPrefix = "appts_"
+
+// apiKeyDisplayLength is how much of a key is kept in clear, enough to recognise it in a list.
+const apiKeyDisplayLength = len(APIKeyPrefix) + 6
+
+type PrincipalKind string
+
+const (
+	PrincipalAPIKey PrincipalKind = "api-key"
+	PrincipalJWT    PrincipalKind = "jwt"
+	// PrincipalManageLink is a patient acting through a manage link, the subject is the manage token ID. It has no
+	// roles, the link itself scopes what it can do.
+	PrincipalManageLink PrincipalKind = "manage-link"
+)
+
+// Principal is the caller a request was authenticated as.
+type Principal struct {
+	Kind    PrincipalKind
+	Subject string // the api key ID or the JWT sub claim
+	Name    string // the api key name or the JWT name claim, if any
+	Email   string // the JWT email claim, if any and not unverified, which the principal's own appointments are booked under
+	Roles   []Role
+}
+
+// String identifies the principal in logs and audit records, e.g. "api-key:3".
+func (p *Principal) String() string {
+	return string(p.Kind) + ":" + p.Subject
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal the request was authenticated as, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// APIKey is a static credential for server to server callers. Only a hash of the key is stored.
+type APIKey struct {
+	ID        int32
+	Name      string
+	Role      Role
+	Prefix    string // the start of the key, to recognise it by
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, name string, role Role, prefix string, hash []byte) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*APIKey, error)
+	GetActiveAPIKey(ctx context.Context, hash []byte) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int32) error
+}
+
+type APIKeyService struct {
+	repo APIKeyRepository
+}
+
+func NewAPIKeyService(repo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// Create issues a new key, which is returned alongside the stored record and cannot be recovered later.
+func (s *APIKeyService) Create(ctx context.Context, name string, role Role) (*APIKey, string, error) {
+	if !role.Valid() {
+		return nil, "", ErrInvalidRole
+	}
+	key := APIKeyPrefix + rand.Text()
+	created, err := s.repo.CreateAPIKey(ctx, name, role, key[:apiKeyDisplayLength], hashAPIKey(key))
+	if err != nil {
+		return nil, "", fmt.Errorf("create api key: %w", err)
+	}
+	return created, key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]*APIKey, error) {
+	keys, err := s.repo.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke stops a key from authenticating, the record is kept so past actions can still be attributed to it.
+func (s *APIKeyService) Revoke(ctx context.Context, id int32) error {
+	if err := s.repo.RevokeAPIKey(ctx, id); err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	return nil
+}
+
+// Authenticate returns the principal for an active key, or ErrUnauthenticated.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrUnauthenticated
+	}
+	apiKey, err := s.repo.GetActiveAPIKey(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	return &Principal{Kind: PrincipalAPIKey, Subject: strconv.Itoa(int(apiKey.ID)), Name: apiKey.Name, Roles: []Role{apiKey.Role}}, nil
+}
+
+// hashAPIKey is a plain SHA-256, keys are random enough that a slow password hash would add nothing.
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
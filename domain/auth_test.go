@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService(t *testing.T) {
+	repo := &apiKeyRepo{}
+	underTest := NewAPIKeyService(repo)
+
+	_, _, err := underTest.Create(t.Context(), "billing", "owner")
+	require.ErrorIs(t, err, ErrInvalidRole)
+	created, key, err := underTest.Create(t.Context(), "billing", RoleIntegration)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key, APIKeyPrefix))
+	require.True(t, strings.HasPrefix(key, created.Prefix))
+	require.NotContains(t, string(repo.hash), key, "only a hash of the key is stored")
+
+	tests := []struct {
+		name    string
+		key     string
+		revoked bool
+		want    *Principal
+		wantErr error
+	}{
+		{
+			name: "active key",
+			key:  key,
+			want: &Principal{Kind: PrincipalAPIKey, Subject: "3", Name: "billing", Roles: []Role{RoleIntegration}},
+		},
+		{
+			name:    "unknown key",
+			key:     APIKeyPrefix + "nope",
+			wantErr: ErrUnauthenticated,
+		},
+		{
+			name:    "not an api key",
+			key:     "eyJhbGciOi",
+			wantErr: ErrUnauthenticated,
+		},
+		{
+			name:    "revoked key",
+			key:     key,
+			revoked: true,
+			wantErr: ErrUnauthenticated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.revoked = tt.revoked
+			got, err := underTest.Authenticate(t.Context(), tt.key)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	repo.err = fmt.Errorf("some error")
+	_, err = underTest.Authenticate(t.Context(), key)
+	require.EqualError(t, err, "get api key: some error")
+}
+
+func TestPrincipalContext(t *testing.T) {
+	_, ok := PrincipalFromContext(t.Context())
+	require.False(t, ok)
+
+	principal := &Principal{Kind: PrincipalJWT, Subject: "user-1"}
+	got, ok := PrincipalFromContext(WithPrincipal(t.Context(), principal))
+	require.True(t, ok)
+	require.Equal(t, principal, got)
+	require.Equal(t, "jwt:user-1", got.String())
+}
+
+// apiKeyRepo stores a single key with ID 3.
+type apiKeyRepo struct {
+	hash    []byte
+	revoked bool
+	err     error
+}
+
+func (a *apiKeyRepo) CreateAPIKey(_ context.Context, name string, role Role, prefix string, hash []byte) (*APIKey, error) {
+	a.hash = hash
+	return &APIKey{ID: 3, Name: name, Role: role, Prefix: prefix}, nil
+}
+
+func (a *apiKeyRepo) ListAPIKeys(_ context.Context) ([]*APIKey, error) {
+	return nil, nil
+}
+
+func (a *apiKeyRepo) GetActiveAPIKey(_ context.Context, hash []byte) (*APIKey, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	if a.revoked || !bytes.Equal(hash, a.hash) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return &APIKey{ID: 3, Name: "billing", Role: RoleIntegration}, nil
+}
+
+func (a *apiKeyRepo) RevokeAPIKey(_ context.Context, _ int32) error {
+	return nil
+}
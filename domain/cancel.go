@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type AppointmentCancellerRepository interface {
+	GetAppointmentByPublicID(ctx context.Context, id uuid.UUID) (*Appointment, error)
+	CancelAppointment(ctx context.Context, id int32) (*Appointment, error)
+}
+
+// AppointmentCancelService cancels any booking on behalf of staff, patients cancel their own through ManageService.
+type AppointmentCancelService struct {
+	repo AppointmentCancellerRepository
+}
+
+func NewAppointmentCancelService(repo AppointmentCancellerRepository) *AppointmentCancelService {
+	return &AppointmentCancelService{repo: repo}
+}
+
+func (s *AppointmentCancelService) Cancel(ctx context.Context, id uuid.UUID) (*Appointment, error) {
+	appt, err := s.repo.GetAppointmentByPublicID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentNotFound) {
+			return nil, ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get appointment by public id: %w", err)
+	}
+	cancelled, err := s.repo.CancelAppointment(ctx, appt.ID)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentNotFound) || errors.Is(err, ErrAppointmentCancelled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("cancel appointment: %w", err)
+	}
+	return cancelled, nil
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppointmentCancelService_Cancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    cancellerRepo
+		want    *Appointment
+		wantErr error
+	}{
+		{
+			name: "cancelled",
+			repo: cancellerRepo{},
+			want: &Appointment{ID: 7, Status: AppointmentStatusCancelled},
+		},
+		{
+			name:    "bubble up not found",
+			repo:    cancellerRepo{getErr: ErrAppointmentNotFound},
+			wantErr: ErrAppointmentNotFound,
+		},
+		{
+			name:    "bubble up already cancelled",
+			repo:    cancellerRepo{cancelErr: ErrAppointmentCancelled},
+			wantErr: ErrAppointmentCancelled,
+		},
+		{
+			name:    "wrap repository error",
+			repo:    cancellerRepo{cancelErr: fmt.Errorf("some error")},
+			wantErr: fmt.Errorf("cancel appointment: some error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewAppointmentCancelService(tt.repo).Cancel(t.Context(), uuid.New())
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr.Error())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type cancellerRepo struct {
+	getErr    error
+	cancelErr error
+}
+
+func (c cancellerRepo) GetAppointmentByPublicID(_ context.Context, id uuid.UUID) (*Appointment, error) {
+	if c.getErr != nil {
+		return nil, c.getErr
+	}
+	return &Appointment{ID: 7, PublicID: id}, nil
+}
+
+func (c cancellerRepo) CancelAppointment(_ context.Context, id int32) (*Appointment, error) {
+	if c.cancelErr != nil {
+		return nil, c.cancelErr
+	}
+	return &Appointment{ID: id, Status: AppointmentStatusCancelled}, nil
+}
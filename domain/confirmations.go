@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type ConfirmationEmailStatus string
+
+const (
+	ConfirmationEmailPending ConfirmationEmailStatus = "pending"
+	ConfirmationEmailSent    ConfirmationEmailStatus = "sent"
+	ConfirmationEmailDead    ConfirmationEmailStatus = "dead" // rejected by the mail server or gave up after too many failed attempts
+)
+
+// ConfirmationEmail is the confirmation of a single booking on its way to the patient, doubling as the log of how that
+// went. Only bookings with an email address get one.
+type ConfirmationEmail struct {
+	ID            int64
+	EventID       int64 // the appointment.created event it confirms
+	AppointmentID int32
+	Status        ConfirmationEmailStatus
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventAppointmentCreated     EventType = "AppointmentCreated"
+	EventAppointmentCancelled   EventType = "AppointmentCancelled"
+	EventAppointmentRescheduled EventType = "AppointmentRescheduled"
+)
+
+func (t EventType) Valid() bool {
+	return t == EventAppointmentCreated || t == EventAppointmentCancelled || t == EventAppointmentRescheduled
+}
+
+// Event records a change to an appointment. Events are written to the outbox in the same transaction as the change
+// and published afterwards, so consumers may see the same event more than once and should deduplicate on ID.
+type Event struct {
+	ID            int64
+	Type          EventType
+	AppointmentID int32
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+}
+
+// AppointmentEventPayload is the JSON body of appointment lifecycle events.
+type AppointmentEventPayload struct {
+	ID        uuid.UUID         `json:"id"`
+	FirstName string            `json:"firstName"`
+	LastName  string            `json:"lastName"`
+	VisitDate string            `json:"visitDate"`
+	Status    AppointmentStatus `json:"status"`
+}
+
+func NewAppointmentEvent(eventType EventType, appt *Appointment) (*Event, error) {
+	payload, err := json.Marshal(AppointmentEventPayload{
+		ID:        appt.PublicID,
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+		VisitDate: appt.VisitDate.Format(time.DateOnly),
+		Status:    appt.Status,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s payload: %w", eventType, err)
+	}
+	return &Event{Type: eventType, AppointmentID: appt.ID, Payload: payload}, nil
+}
+
+// EventPublisher delivers events to downstream consumers.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewAppointmentEvent(t *testing.T) {
+	appt := NewAppointment("first", "last", ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)))
+	appt.ID = 42
+	appt.PublicID = uuid.MustParse("019b8e2a-6c00-7000-8000-00000000002a")
+	appt.Status = AppointmentStatusBooked
+
+	event, err := NewAppointmentEvent(EventAppointmentCreated, appt)
+	require.NoError(t, err)
+	require.Equal(t, EventAppointmentCreated, event.Type)
+	require.Equal(t, int32(42), event.AppointmentID)
+	require.JSONEq(t, `{"id":"019b8e2a-6c00-7000-8000-00000000002a","firstName":"first","lastName":"last","visitDate":"2026-01-05","status":"booked"}`, string(event.Payload))
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendarFeed = fmt.Errorf("invalid or expired calendar feed")
+
+// CalendarFeed is what a calendar feed token grants: every booking, or only those under Email.
+type CalendarFeed struct {
+	Email     string
+	ExpiresAt time.Time
+}
+
+// Includes reports whether appt belongs in the feed.
+func (f *CalendarFeed) Includes(appt *Appointment) bool {
+	return f.Email == "" || strings.EqualFold(f.Email, appt.Email)
+}
+
+// CalendarFeedService issues the tokens subscribed calendars use in place of credentials, which calendar apps cannot
+// send. A token carries its feed and expiry signed with HMAC-SHA256 and is not stored, so rotating the key is what
+// revokes feeds.
+type CalendarFeedService struct {
+	key     []byte
+	nowFunc func() time.Time
+	ttl     time.Duration
+}
+
+func NewCalendarFeedService(key []byte, nowFunc func() time.Time, ttl time.Duration) (*CalendarFeedService, error) {
+	if len(key) < 32 {
+		return nil, fmt.Errorf("calendar feed key must be at least 32 bytes")
+	}
+	return &CalendarFeedService{key: key, nowFunc: nowFunc, ttl: ttl}, nil
+}
+
+// Issue returns a token for the feed of the bookings the principal in ctx may read, valid for the service's ttl.
+// Principals only granted their own appointments get a feed of those, and without a principal, as when auth is
+// disabled, the feed has every booking.
+func (s *CalendarFeedService) Issue(ctx context.Context) (string, *CalendarFeed, error) {
+	feed := &CalendarFeed{ExpiresAt: s.nowFunc().Add(s.ttl).Truncate(time.Second)}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		switch principal.Scope(PermissionRead) {
+		case ScopeAll:
+		case ScopeOwn:
+			if principal.Email == "" {
+				return "", nil, fmt.Errorf("%w: no email to subscribe to the bookings of", ErrForbidden)
+			}
+			feed.Email = principal.Email
+		default:
+			return "", nil, fmt.Errorf("%w: requires %s", ErrForbidden, PermissionRead)
+		}
+	}
+	return s.sign(feed), feed, nil
+}
+
+// sign returns base64url(big endian unix expiry || email) "." base64url(hmac).
+func (s *CalendarFeedService) sign(feed *CalendarFeed) string {
+	payload := binary.BigEndian.AppendUint64(nil, uint64(feed.ExpiresAt.Unix()))
+	payload = append(payload, feed.Email...)
+	return tokenEncoding.EncodeToString(payload) + "." + tokenEncoding.EncodeToString(s.mac(payload))
+}
+
+// Parse checks the signature and expiry of token, returning the feed it grants.
+func (s *CalendarFeedService) Parse(token string) (*CalendarFeed, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCalendarFeed
+	}
+	payload, err := tokenEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) < 8 {
+		return nil, ErrInvalidCalendarFeed
+	}
+	mac, err := tokenEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(payload)) {
+		return nil, ErrInvalidCalendarFeed
+	}
+	feed := &CalendarFeed{Email: string(payload[8:]), ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)}
+	if !s.nowFunc().Before(feed.ExpiresAt) {
+		return nil, ErrInvalidCalendarFeed
+	}
+	return feed, nil
+}
+
+func (s *CalendarFeedService) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
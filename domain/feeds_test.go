@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeedService(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	clock := now
+	underTest, err := NewCalendarFeedService(testManageKey, func() time.Time { return clock }, time.Hour)
+	require.NoError(t, err)
+	other, err := NewCalendarFeedService([]byte("fedcba9876543210fedcba9876543210"), func() time.Time { return clock }, time.Hour)
+	require.NoError(t, err)
+
+	staff := WithPrincipal(context.Background(), &Principal{Roles: []Role{RoleReceptionist}})
+	patient := WithPrincipal(context.Background(), &Principal{Email: "jane@example.com", Roles: []Role{RolePatient}})
+	staffToken, _, err := underTest.Issue(staff)
+	require.NoError(t, err)
+	patientToken, _, err := underTest.Issue(patient)
+	require.NoError(t, err)
+	otherToken, _, err := other.Issue(staff)
+	require.NoError(t, err)
+	payload, mac, _ := strings.Cut(patientToken, ".")
+	tampered := []byte(payload)
+	tampered[len(tampered)-1] ^= 'A' ^ 'B'
+
+	tests := []struct {
+		name      string
+		token     string
+		now       time.Time
+		wantEmail string
+		wantErr   error
+	}{
+		{
+			name:  "every booking for staff",
+			token: staffToken,
+			now:   now,
+		},
+		{
+			name:      "only their own for patients",
+			token:     patientToken,
+			now:       now,
+			wantEmail: "jane@example.com",
+		},
+		{
+			name:    "expired",
+			token:   staffToken,
+			now:     now.Add(time.Hour),
+			wantErr: ErrInvalidCalendarFeed,
+		},
+		{
+			name:    "signed with another key",
+			token:   otherToken,
+			now:     now,
+			wantErr: ErrInvalidCalendarFeed,
+		},
+		{
+			name:    "tampered email",
+			token:   string(tampered) + "." + mac,
+			now:     now,
+			wantErr: ErrInvalidCalendarFeed,
+		},
+		{
+			name:    "not a token",
+			token:   "42",
+			now:     now,
+			wantErr: ErrInvalidCalendarFeed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = tt.now
+			got, err := underTest.Parse(tt.token)
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				require.Equal(t, tt.wantEmail, got.Email)
+				require.Equal(t, now.Add(time.Hour), got.ExpiresAt.UTC())
+			}
+		})
+	}
+
+	clock = now
+	_, _, err = underTest.Issue(WithPrincipal(context.Background(), &Principal{Roles: []Role{RolePatient}}))
+	require.ErrorIs(t, err, ErrForbidden, "patients without an email own nothing to subscribe to")
+	_, _, err = underTest.Issue(WithPrincipal(context.Background(), &Principal{Roles: []Role{RoleIntegration}}))
+	require.ErrorIs(t, err, ErrForbidden)
+
+	_, err = NewCalendarFeedService([]byte("short"), time.Now, time.Hour)
+	require.Error(t, err)
+}
+
+func TestCalendarFeed_Includes(t *testing.T) {
+	appt := &Appointment{Email: "Jane@Example.com"}
+	require.True(t, (&CalendarFeed{}).Includes(appt))
+	require.True(t, (&CalendarFeed{Email: "jane@example.com"}).Includes(appt))
+	require.False(t, (&CalendarFeed{Email: "john@example.com"}).Includes(appt))
+}
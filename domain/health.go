@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrDraining = fmt.Errorf("shutting down")
+
+// HealthCheck reports whether a dependency is usable, returning nil when it is.
+type HealthCheck func(ctx context.Context) error
+
+type HealthCheckResult struct {
+	Name     string
+	Optional bool  // a failing optional check is reported but does not make the service unready
+	Err      error // nil when the check passed
+}
+
+type HealthReport struct {
+	Ready  bool
+	Err    error // ErrDraining once shutdown has begun
+	Checks []HealthCheckResult
+}
+
+type namedCheck struct {
+	name     string
+	optional bool
+	check    HealthCheck
+}
+
+// HealthService decides whether the service should receive traffic.
+type HealthService struct {
+	timeout  time.Duration
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+func NewHealthService(timeout time.Duration) *HealthService {
+	return &HealthService{timeout: timeout}
+}
+
+// AddCheck adds a dependency the service cannot serve requests without.
+func (s *HealthService) AddCheck(name string, check HealthCheck) {
+	s.checks = append(s.checks, namedCheck{name: name, check: check})
+}
+
+// AddOptionalCheck adds a dependency whose failure is reported without taking the service out of rotation.
+func (s *HealthService) AddOptionalCheck(name string, check HealthCheck) {
+	s.checks = append(s.checks, namedCheck{name: name, optional: true, check: check})
+}
+
+// Drain marks the service unready so it stops receiving new traffic while it shuts down.
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Ready runs every check concurrently, each limited to the service's timeout, and reports them in the order they
+// were added.
+func (s *HealthService) Ready(ctx context.Context) *HealthReport {
+	report := &HealthReport{Ready: true, Checks: make([]HealthCheckResult, len(s.checks))}
+	var wg sync.WaitGroup
+	for i, c := range s.checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+			report.Checks[i] = HealthCheckResult{Name: c.name, Optional: c.optional, Err: c.check(ctx)}
+		})
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Err != nil && !result.Optional {
+			report.Ready = false
+		}
+	}
+	if s.draining.Load() {
+		report.Ready = false
+		report.Err = ErrDraining
+	}
+	return report
+}
+
+// HolidaySourceHealth passes lookups through to a PublicHolidayChecker and remembers whether the last one failed,
+// so readiness can report on the holiday source without calling it on every probe.
+type HolidaySourceHealth struct {
+	next    PublicHolidayChecker
+	lastErr atomic.Pointer[error]
+}
+
+func NewHolidaySourceHealth(next PublicHolidayChecker) *HolidaySourceHealth {
+	return &HolidaySourceHealth{next: next}
+}
+
+func (h *HolidaySourceHealth) IsPublicHoliday(ctx context.Context, date *time.Time) (bool, error) {
+	holiday, err := h.next.IsPublicHoliday(ctx, date)
+	h.lastErr.Store(&err)
+	return holiday, err
+}
+
+// Check returns the error from the last lookup, nil before the first.
+func (h *HolidaySourceHealth) Check(context.Context) error {
+	if err := h.lastErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthService_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("some error") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	tests := []struct {
+		name      string
+		checks    map[string]HealthCheck
+		optional  map[string]HealthCheck
+		drain     bool
+		wantReady bool
+		wantErr   error
+		wantFails []string
+	}{
+		{
+			name:      "ready when every check passes",
+			checks:    map[string]HealthCheck{"database": ok, "migrations": ok},
+			optional:  map[string]HealthCheck{"holidays": ok},
+			wantReady: true,
+		},
+		{
+			name:      "not ready when a check fails",
+			checks:    map[string]HealthCheck{"database": failing, "migrations": ok},
+			wantFails: []string{"database"},
+		},
+		{
+			name:      "not ready when a check outlasts the timeout",
+			checks:    map[string]HealthCheck{"database": slow},
+			wantFails: []string{"database"},
+		},
+		{
+			name:      "still ready when an optional check fails",
+			checks:    map[string]HealthCheck{"database": ok},
+			optional:  map[string]HealthCheck{"holidays": failing},
+			wantReady: true,
+			wantFails: []string{"holidays"},
+		},
+		{
+			name:    "not ready while draining",
+			checks:  map[string]HealthCheck{"database": ok},
+			drain:   true,
+			wantErr: ErrDraining,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			underTest := NewHealthService(10 * time.Millisecond)
+			for name, check := range tt.checks {
+				underTest.AddCheck(name, check)
+			}
+			for name, check := range tt.optional {
+				underTest.AddOptionalCheck(name, check)
+			}
+			if tt.drain {
+				underTest.Drain()
+			}
+
+			got := underTest.Ready(t.Context())
+			require.Equal(t, tt.wantReady, got.Ready)
+			require.Equal(t, tt.wantErr, got.Err)
+			require.Len(t, got.Checks, len(tt.checks)+len(tt.optional))
+			var fails []string
+			for _, result := range got.Checks {
+				require.Equal(t, tt.optional[result.Name] != nil, result.Optional)
+				if result.Err != nil {
+					fails = append(fails, result.Name)
+				}
+			}
+			require.Equal(t, tt.wantFails, fails)
+		})
+	}
+}
+
+func TestHolidaySourceHealth(t *testing.T) {
+	date := fixedTimeFunc()
+	underTest := NewHolidaySourceHealth(publicHolidayError{})
+	require.NoError(t, underTest.Check(t.Context()), "healthy before the first lookup")
+
+	_, err := underTest.IsPublicHoliday(t.Context(), &date)
+	require.Error(t, err)
+	require.Equal(t, err, underTest.Check(t.Context()))
+
+	underTest.next = publicHolidayCheckerSuccess{}
+	_, err = underTest.IsPublicHoliday(t.Context(), &date)
+	require.NoError(t, err)
+	require.NoError(t, underTest.Check(t.Context()), "recovers with the next lookup")
+}
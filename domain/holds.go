@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/utils/ptr"
+)
+
+// Hold temporarily reserves a visit date while a patient completes checkout. The Token must be presented when
+// creating the appointment, until then the date counts as taken for everyone else.
+type Hold struct {
+	Token     string
+	VisitDate *time.Time
+	ExpiresAt time.Time
+}
+
+type HoldPersistorRepository interface {
+	CreateHold(ctx context.Context, hold *Hold) (*Hold, error)
+}
+
+func NewHold(date *time.Time, expiresAt time.Time) *Hold {
+	return &Hold{
+		VisitDate: ptr.To(time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)),
+		ExpiresAt: expiresAt,
+	}
+}
+
+type HoldCreatorService struct {
+	repo    HoldPersistorRepository
+	checker PublicHolidayChecker
+	nowFunc func() time.Time
+	ttl     time.Duration
+}
+
+func NewHoldCreatorService(repo HoldPersistorRepository, checker PublicHolidayChecker, nowFunc func() time.Time, ttl time.Duration) *HoldCreatorService {
+	return &HoldCreatorService{
+		repo:    repo,
+		checker: checker,
+		nowFunc: nowFunc,
+		ttl:     ttl,
+	}
+}
+
+func (s *HoldCreatorService) Create(ctx context.Context, date *time.Time) (*Hold, error) {
+	if date == nil {
+		return nil, fmt.Errorf("date is nil")
+	}
+	now := s.nowFunc()
+	hold := NewHold(date, now.Add(s.ttl))
+	if err := validateVisitDate(ctx, s.checker, now, hold.VisitDate); err != nil {
+		return nil, err
+	}
+
+	save, err := s.repo.CreateHold(ctx, hold)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentDateTaken) {
+			return nil, ErrAppointmentDateTaken
+		}
+		return nil, fmt.Errorf("save hold: %w", err)
+	}
+	return save, nil
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestHoldCreatorService_Create(t *testing.T) {
+	ttl := 10 * time.Minute
+	tomorrow := ptr.To(fixedTimeFunc().Add(24 * time.Hour))
+	tests := []struct {
+		name                 string
+		date                 *time.Time
+		want                 *Hold
+		wantErr              error
+		holdPersistor        HoldPersistorRepository
+		publicHolidayChecker PublicHolidayChecker
+	}{
+		{
+			name:    "return error when date is nil",
+			wantErr: errors.New("date is nil"),
+		},
+		{
+			name:    "do not allow hold in the past",
+			date:    ptr.To(fixedTimeFunc().Add(-time.Hour)),
+			wantErr: ErrAppointmentInPast,
+		},
+		{
+			name:                 "return error from public holiday checker",
+			date:                 tomorrow,
+			publicHolidayChecker: publicHolidayError{},
+			wantErr:              errors.New("isPublicHoliday: some error"),
+		},
+		{
+			name:                 "return error when date is a public holiday",
+			date:                 tomorrow,
+			publicHolidayChecker: publicHolidayCheckerIsPublicHoliday{},
+			wantErr:              ErrAppointmentOnPublicHoliday,
+		},
+		{
+			name:                 "return error from repository on save",
+			date:                 tomorrow,
+			publicHolidayChecker: publicHolidayCheckerSuccess{},
+			holdPersistor:        holdPersistorError{},
+			wantErr:              errors.New("save hold: some error"),
+		},
+		{
+			name:                 "bubble up conflict error",
+			date:                 tomorrow,
+			publicHolidayChecker: publicHolidayCheckerSuccess{},
+			holdPersistor:        holdConflict{},
+			wantErr:              ErrAppointmentDateTaken,
+		},
+		{
+			name:                 "success expires after ttl",
+			date:                 ptr.To(tomorrow.Add(13 * time.Hour)),
+			publicHolidayChecker: publicHolidayCheckerSuccess{},
+			holdPersistor:        holdPersistorSuccess{},
+			want: &Hold{
+				Token:     "token",
+				VisitDate: tomorrow,
+				ExpiresAt: fixedTimeFunc().Add(ttl),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unitUnderTest := NewHoldCreatorService(tt.holdPersistor, tt.publicHolidayChecker, fixedTimeFunc, ttl)
+			got, err := unitUnderTest.Create(t.Context(), tt.date)
+			if tt.wantErr != nil {
+				require.ErrorContains(t, err, tt.wantErr.Error())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type holdPersistorError struct{}
+
+func (h holdPersistorError) CreateHold(_ context.Context, _ *Hold) (*Hold, error) {
+	return nil, fmt.Errorf("some error")
+}
+
+type holdPersistorSuccess struct{}
+
+func (h holdPersistorSuccess) CreateHold(_ context.Context, hold *Hold) (*Hold, error) {
+	hold.Token = "token"
+	return hold, nil
+}
+
+type holdConflict struct{}
+
+func (h holdConflict) CreateHold(_ context.Context, _ *Hold) (*Hold, error) {
+	return nil, ErrAppointmentDateTaken
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidImportRow = fmt.Errorf("invalid import row")
+
+// errImportRolledBack discards an import transaction that had failures or was a dry run.
+var errImportRolledBack = errors.New("import rolled back")
+
+// ImportRow is a booking read from an import file, Err is set instead of Appointment when the row could not be read.
+type ImportRow struct {
+	Line        int
+	Appointment *Appointment
+	Err         error
+}
+
+// ImportReport describes what happened to each row, keeping the requested appointment on rows that failed. Rows without
+// an error were valid but are only persisted when Committed is true.
+type ImportReport struct {
+	DryRun    bool
+	Committed bool
+	Rows      []ImportRow
+}
+
+type AppointmentImportService struct {
+	creator *AppointmentCreatorService
+	tx      AppointmentTransactor
+}
+
+func NewAppointmentImportService(creator *AppointmentCreatorService, tx AppointmentTransactor) *AppointmentImportService {
+	return &AppointmentImportService{
+		creator: creator,
+		tx:      tx,
+	}
+}
+
+// Import books every row in a single transaction that is only committed if every row succeeds. A dry run validates
+// the rows against the database, including conflicts between rows, and always rolls back.
+func (s *AppointmentImportService) Import(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, Rows: make([]ImportRow, len(rows))}
+	err := s.tx.InTx(ctx, func(repo AppointmentPersistorRepository) error {
+		creator := s.creator.withRepo(repo)
+		failed := false
+		for i, row := range rows {
+			report.Rows[i] = row
+			if row.Err != nil {
+				failed = true
+				continue
+			}
+			booked, err := creator.Create(ctx, row.Appointment)
+			if err != nil {
+				report.Rows[i].Err = err
+				failed = true
+				continue
+			}
+			report.Rows[i].Appointment = booked
+		}
+		if failed || dryRun {
+			return errImportRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		return nil, fmt.Errorf("import appointments: %w", err)
+	}
+	report.Committed = err == nil
+	return report, nil
+}
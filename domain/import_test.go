@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppointmentImportService_Import(t *testing.T) {
+	free := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	taken := time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC)
+	past := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	unreadable := errors.New("expected 3 columns, got 2")
+	tests := []struct {
+		name          string
+		rows          []ImportRow
+		dryRun        bool
+		wantErrs      []error
+		wantCommitted bool
+		wantBooked    int
+	}{
+		{
+			name:          "commits when every row is valid",
+			rows:          []ImportRow{{Line: 1, Appointment: NewAppointment("first", "last", &free)}},
+			wantErrs:      []error{nil},
+			wantCommitted: true,
+			wantBooked:    1,
+		},
+		{
+			name:     "dry run never commits",
+			rows:     []ImportRow{{Line: 1, Appointment: NewAppointment("first", "last", &free)}},
+			dryRun:   true,
+			wantErrs: []error{nil},
+		},
+		{
+			name: "rolls back every row when any fails",
+			rows: []ImportRow{
+				{Line: 1, Appointment: NewAppointment("first", "last", &free)},
+				{Line: 2, Appointment: NewAppointment("first", "last", &taken)},
+				{Line: 3, Appointment: NewAppointment("first", "last", &past)},
+				{Line: 4, Err: unreadable},
+			},
+			wantErrs: []error{nil, ErrAppointmentDateTaken, ErrAppointmentInPast, unreadable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTxRepo{taken: []time.Time{taken}}
+			creator := NewAppointmentCreatorService(repo, publicHolidayCheckerSuccess{}, fixedTimeFunc)
+			unitUnderTest := NewAppointmentImportService(creator, repo)
+
+			got, err := unitUnderTest.Import(t.Context(), tt.rows, tt.dryRun)
+			require.NoError(t, err)
+			require.Equal(t, tt.dryRun, got.DryRun)
+			require.Equal(t, tt.wantCommitted, got.Committed)
+			require.Len(t, got.Rows, len(tt.wantErrs))
+			for i := range got.Rows {
+				require.Equal(t, tt.rows[i].Line, got.Rows[i].Line)
+				require.Equal(t, tt.wantErrs[i], got.Rows[i].Err)
+			}
+			require.Len(t, repo.committed, tt.wantBooked)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidFilter = fmt.Errorf("from date must not be after to date")
+
+// AppointmentFilter selects appointments by visit date, both bounds inclusive, and status. Zero values match all.
+type AppointmentFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Status AppointmentStatus
+}
+
+// AppointmentListerRepository calls fn for every matching appointment in visit date order, stopping at the first error.
+// Implementations must not hold the whole result set in memory.
+type AppointmentListerRepository interface {
+	ListAppointments(ctx context.Context, filter AppointmentFilter, fn func(*Appointment) error) error
+}
+
+type AppointmentGetterRepository interface {
+	GetAppointment(ctx context.Context, id int32) (*Appointment, error)
+	GetAppointmentByPublicID(ctx context.Context, id uuid.UUID) (*Appointment, error)
+}
+
+type AppointmentListService struct {
+	repo AppointmentListerRepository
+}
+
+func NewAppointmentListService(repo AppointmentListerRepository) *AppointmentListService {
+	return &AppointmentListService{repo: repo}
+}
+
+func (s *AppointmentListService) List(ctx context.Context, filter AppointmentFilter, fn func(*Appointment) error) error {
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return ErrInvalidFilter
+	}
+	return s.repo.ListAppointments(ctx, filter, fn)
+}
+
+type AppointmentGetterService struct {
+	repo AppointmentGetterRepository
+}
+
+func NewAppointmentGetterService(repo AppointmentGetterRepository) *AppointmentGetterService {
+	return &AppointmentGetterService{repo: repo}
+}
+
+func (s *AppointmentGetterService) Get(ctx context.Context, id int32) (*Appointment, error) {
+	appt, err := s.repo.GetAppointment(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentNotFound) {
+			return nil, ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get appointment: %w", err)
+	}
+	return appt, nil
+}
+
+func (s *AppointmentGetterService) GetByPublicID(ctx context.Context, id uuid.UUID) (*Appointment, error) {
+	appt, err := s.repo.GetAppointmentByPublicID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentNotFound) {
+			return nil, ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get appointment by public id: %w", err)
+	}
+	return appt, nil
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestAppointmentListService_List(t *testing.T) {
+	jan1 := ptr.To(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	jan2 := ptr.To(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name     string
+		filter   AppointmentFilter
+		wantErr  error
+		wantRepo bool
+	}{
+		{
+			name:     "open ended filter",
+			filter:   AppointmentFilter{From: jan1},
+			wantRepo: true,
+		},
+		{
+			name:     "single day",
+			filter:   AppointmentFilter{From: jan1, To: jan1},
+			wantRepo: true,
+		},
+		{
+			name:    "from after to",
+			filter:  AppointmentFilter{From: jan2, To: jan1},
+			wantErr: ErrInvalidFilter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &listerRepo{}
+			err := NewAppointmentListService(repo).List(t.Context(), tt.filter, func(*Appointment) error { return nil })
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantRepo, repo.called)
+		})
+	}
+}
+
+type listerRepo struct {
+	called bool
+}
+
+func (l *listerRepo) ListAppointments(_ context.Context, _ AppointmentFilter, _ func(*Appointment) error) error {
+	l.called = true
+	return nil
+}
+
+func TestAppointmentGetterService_Get(t *testing.T) {
+	appt := NewAppointment("first", "last", ptr.To(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	tests := []struct {
+		name    string
+		repo    AppointmentGetterRepository
+		want    *Appointment
+		wantErr error
+	}{
+		{
+			name: "found",
+			repo: getterRepo{appt: appt},
+			want: appt,
+		},
+		{
+			name:    "bubble up not found",
+			repo:    getterRepo{err: ErrAppointmentNotFound},
+			wantErr: ErrAppointmentNotFound,
+		},
+		{
+			name:    "wrap repository error",
+			repo:    getterRepo{err: fmt.Errorf("some error")},
+			wantErr: fmt.Errorf("get appointment: some error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewAppointmentGetterService(tt.repo).Get(t.Context(), 1)
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr.Error())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAppointmentGetterService_GetByPublicID(t *testing.T) {
+	appt := NewAppointment("first", "last", ptr.To(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	tests := []struct {
+		name    string
+		repo    AppointmentGetterRepository
+		want    *Appointment
+		wantErr error
+	}{
+		{
+			name: "found",
+			repo: getterRepo{appt: appt},
+			want: appt,
+		},
+		{
+			name:    "bubble up not found",
+			repo:    getterRepo{err: ErrAppointmentNotFound},
+			wantErr: ErrAppointmentNotFound,
+		},
+		{
+			name:    "wrap repository error",
+			repo:    getterRepo{err: fmt.Errorf("some error")},
+			wantErr: fmt.Errorf("get appointment by public id: some error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewAppointmentGetterService(tt.repo).GetByPublicID(t.Context(), uuid.New())
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr.Error())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type getterRepo struct {
+	appt *Appointment
+	err  error
+}
+
+func (g getterRepo) GetAppointment(_ context.Context, _ int32) (*Appointment, error) {
+	return g.appt, g.err
+}
+
+func (g getterRepo) GetAppointmentByPublicID(_ context.Context, _ uuid.UUID) (*Appointment, error) {
+	return g.appt, g.err
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Location is the clinic appointments are booked at. Visit dates are calendar days in its TimeZone.
+type Location struct {
+	Name     string
+	Address  string
+	TimeZone *time.Location
+}
+
+func NewLocation(name string, address string, timeZone string) (*Location, error) {
+	tz, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("load time zone: %w", err)
+	}
+	return &Location{Name: name, Address: address, TimeZone: tz}, nil
+}
+
+// String returns the name and address on a single line.
+func (l *Location) String() string {
+	var parts []string
+	for _, part := range []string{l.Name, l.Address} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocation(t *testing.T) {
+	location, err := NewLocation("High Street Clinic", "1 High Street", "Europe/London")
+	require.NoError(t, err)
+	require.Equal(t, "Europe/London", location.TimeZone.String())
+	require.Equal(t, "High Street Clinic, 1 High Street", location.String())
+
+	require.Equal(t, "1 High Street", (&Location{Address: "1 High Street"}).String())
+
+	_, err = NewLocation("", "", "Mars/Olympus_Mons")
+	require.ErrorContains(t, err, "load time zone")
+}
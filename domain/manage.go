@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidManageToken = fmt.Errorf("invalid or expired manage token")
+var ErrAppointmentCancelled = fmt.Errorf("appointment is cancelled")
+
+// ManageTokenID identifies an issued manage token in the repository. It is random, so it reveals nothing about the
+// appointment it is for.
+type ManageTokenID [16]byte
+
+// ManageToken lets whoever holds it view, reschedule or cancel a single appointment without an account.
+type ManageToken struct {
+	ID            ManageTokenID
+	AppointmentID int32
+	ExpiresAt     time.Time
+	Revoked       bool
+}
+
+// ManageTokenSigner turns token IDs into the strings handed to patients and back. A token string carries its ID and
+// expiry signed with HMAC-SHA256, so forged or tampered tokens are rejected before touching the repository.
+type ManageTokenSigner struct {
+	key []byte
+}
+
+func NewManageTokenSigner(key []byte) (*ManageTokenSigner, error) {
+	if len(key) < 32 {
+		return nil, fmt.Errorf("manage token key must be at least 32 bytes")
+	}
+	return &ManageTokenSigner{key: key}, nil
+}
+
+var tokenEncoding = base64.RawURLEncoding
+
+// Sign returns base64url(id || big endian unix expiry) "." base64url(hmac).
+func (s *ManageTokenSigner) Sign(id ManageTokenID, expiresAt time.Time) string {
+	payload := make([]byte, 0, len(id)+8)
+	payload = append(payload, id[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expiresAt.Unix()))
+	return tokenEncoding.EncodeToString(payload) + "." + tokenEncoding.EncodeToString(s.mac(payload))
+}
+
+// Parse checks the signature and expiry of token, returning the ID to look up.
+func (s *ManageTokenSigner) Parse(token string, now time.Time) (ManageTokenID, error) {
+	var id ManageTokenID
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return id, ErrInvalidManageToken
+	}
+	payload, err := tokenEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != len(id)+8 {
+		return id, ErrInvalidManageToken
+	}
+	mac, err := tokenEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(payload)) {
+		return id, ErrInvalidManageToken
+	}
+	if !now.Before(time.Unix(int64(binary.BigEndian.Uint64(payload[len(id):])), 0)) {
+		return id, ErrInvalidManageToken
+	}
+	copy(id[:], payload)
+	return id, nil
+}
+
+func (s *ManageTokenSigner) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+type ManageRepository interface {
+	CreateManageToken(ctx context.Context, appointmentID int32, expiresAt time.Time) (*ManageToken, error)
+	GetManageToken(ctx context.Context, id ManageTokenID) (*ManageToken, error)
+	RevokeManageToken(ctx context.Context, id ManageTokenID) error
+	GetAppointment(ctx context.Context, id int32) (*Appointment, error)
+	CancelAppointment(ctx context.Context, id int32) (*Appointment, error)
+	RescheduleAppointment(ctx context.Context, id int32, date *time.Time) (*Appointment, error)
+}
+
+// ManageService issues manage tokens and carries out what patients ask of them. Every operation is scoped to the
+// appointment the token was issued for.
+type ManageService struct {
+	repo    ManageRepository
+	signer  *ManageTokenSigner
+	checker PublicHolidayChecker
+	nowFunc func() time.Time
+	ttl     time.Duration
+}
+
+func NewManageService(repo ManageRepository, signer *ManageTokenSigner, checker PublicHolidayChecker, nowFunc func() time.Time, ttl time.Duration) *ManageService {
+	return &ManageService{
+		repo:    repo,
+		signer:  signer,
+		checker: checker,
+		nowFunc: nowFunc,
+		ttl:     ttl,
+	}
+}
+
+// Issue creates a new token for the appointment, valid for the service's ttl.
+func (s *ManageService) Issue(ctx context.Context, appointmentID int32) (string, error) {
+	token, err := s.repo.CreateManageToken(ctx, appointmentID, s.nowFunc().Add(s.ttl).Truncate(time.Second))
+	if err != nil {
+		return "", fmt.Errorf("save manage token: %w", err)
+	}
+	return s.signer.Sign(token.ID, token.ExpiresAt), nil
+}
+
+func (s *ManageService) Get(ctx context.Context, token string) (*Appointment, error) {
+	ctx, appointmentID, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	appt, err := s.repo.GetAppointment(ctx, appointmentID)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentNotFound) {
+			return nil, ErrInvalidManageToken
+		}
+		return nil, fmt.Errorf("get appointment: %w", err)
+	}
+	return appt, nil
+}
+
+func (s *ManageService) Cancel(ctx context.Context, token string) (*Appointment, error) {
+	ctx, appointmentID, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	appt, err := s.repo.CancelAppointment(ctx, appointmentID)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentCancelled) {
+			return nil, ErrAppointmentCancelled
+		}
+		return nil, fmt.Errorf("cancel appointment: %w", err)
+	}
+	return appt, nil
+}
+
+// Reschedule moves the appointment to date, which must be bookable and free. Appointments whose visit date has passed
+// stay where they are.
+func (s *ManageService) Reschedule(ctx context.Context, token string, date *time.Time) (*Appointment, error) {
+	ctx, appointmentID, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.repo.GetAppointment(ctx, appointmentID)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentNotFound) {
+			return nil, ErrInvalidManageToken
+		}
+		return nil, fmt.Errorf("get appointment: %w", err)
+	}
+	if current.VisitDate.Before(s.nowFunc()) {
+		return nil, ErrAppointmentInPast
+	}
+	visitDate := NewAppointment("", "", date).VisitDate
+	if err := validateVisitDate(ctx, s.checker, s.nowFunc(), visitDate); err != nil {
+		return nil, err
+	}
+	appt, err := s.repo.RescheduleAppointment(ctx, appointmentID, visitDate)
+	if err != nil {
+		if errors.Is(err, ErrAppointmentCancelled) {
+			return nil, ErrAppointmentCancelled
+		}
+		if errors.Is(err, ErrAppointmentDateTaken) {
+			return nil, ErrAppointmentDateTaken
+		}
+		return nil, fmt.Errorf("reschedule appointment: %w", err)
+	}
+	return appt, nil
+}
+
+// Revoke stops the token working, e.g. when a patient has forwarded the link by mistake.
+func (s *ManageService) Revoke(ctx context.Context, token string) error {
+	id, err := s.signer.Parse(token, s.nowFunc())
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RevokeManageToken(ctx, id); err != nil {
+		if errors.Is(err, ErrInvalidManageToken) {
+			return ErrInvalidManageToken
+		}
+		return fmt.Errorf("revoke manage token: %w", err)
+	}
+	return nil
+}
+
+// resolve returns the appointment a valid, unrevoked token was issued for, and ctx carrying the token as the principal
+// so changes made through it are attributed to the link.
+func (s *ManageService) resolve(ctx context.Context, token string) (context.Context, int32, error) {
+	id, err := s.signer.Parse(token, s.nowFunc())
+	if err != nil {
+		return ctx, 0, err
+	}
+	stored, err := s.repo.GetManageToken(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrInvalidManageToken) {
+			return ctx, 0, ErrInvalidManageToken
+		}
+		return ctx, 0, fmt.Errorf("get manage token: %w", err)
+	}
+	if stored.Revoked {
+		return ctx, 0, ErrInvalidManageToken
+	}
+	return WithPrincipal(ctx, &Principal{Kind: PrincipalManageLink, Subject: uuid.UUID(id).String()}), stored.AppointmentID, nil
+}
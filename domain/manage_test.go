@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+var testManageKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestManageTokenSigner(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	signer, err := NewManageTokenSigner(testManageKey)
+	require.NoError(t, err)
+	other, err := NewManageTokenSigner([]byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	id := ManageTokenID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	valid := signer.Sign(id, now.Add(time.Hour))
+	payload, mac, _ := strings.Cut(valid, ".")
+	tampered := []byte(payload)
+	tampered[0] ^= 'A' ^ 'B'
+
+	tests := []struct {
+		name    string
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: valid,
+			now:   now,
+		},
+		{
+			name:    "expired",
+			token:   valid,
+			now:     now.Add(time.Hour),
+			wantErr: ErrInvalidManageToken,
+		},
+		{
+			name:    "signed with another key",
+			token:   other.Sign(id, now.Add(time.Hour)),
+			now:     now,
+			wantErr: ErrInvalidManageToken,
+		},
+		{
+			name:    "tampered payload",
+			token:   string(tampered) + "." + mac,
+			now:     now,
+			wantErr: ErrInvalidManageToken,
+		},
+		{
+			name:    "extended expiry",
+			token:   signer.Sign(id, now.Add(time.Hour))[:len(payload)-2] + "AA." + mac,
+			now:     now,
+			wantErr: ErrInvalidManageToken,
+		},
+		{
+			name:    "not a token",
+			token:   "42",
+			now:     now,
+			wantErr: ErrInvalidManageToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signer.Parse(tt.token, tt.now)
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				require.Equal(t, id, got)
+			}
+		})
+	}
+
+	_, err = NewManageTokenSigner([]byte("short"))
+	require.Error(t, err)
+}
+
+func TestManageService(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	signer, err := NewManageTokenSigner(testManageKey)
+	require.NoError(t, err)
+	tests := []struct {
+		name    string
+		repo    *manageRepo
+		checker PublicHolidayChecker
+		op      func(s *ManageService, token string) error
+		wantErr error
+	}{
+		{
+			name: "get",
+			repo: &manageRepo{},
+			op: func(s *ManageService, token string) error {
+				appt, err := s.Get(t.Context(), token)
+				if err == nil && appt.ID != 7 {
+					t.Errorf("got appointment %d, want 7", appt.ID)
+				}
+				return err
+			},
+		},
+		{
+			name:    "revoked token",
+			repo:    &manageRepo{revoked: true},
+			op:      func(s *ManageService, token string) error { _, err := s.Get(t.Context(), token); return err },
+			wantErr: ErrInvalidManageToken,
+		},
+		{
+			name:    "unknown token",
+			repo:    &manageRepo{missing: true},
+			op:      func(s *ManageService, token string) error { _, err := s.Cancel(t.Context(), token); return err },
+			wantErr: ErrInvalidManageToken,
+		},
+		{
+			name:    "cancel twice",
+			repo:    &manageRepo{err: ErrAppointmentCancelled},
+			op:      func(s *ManageService, token string) error { _, err := s.Cancel(t.Context(), token); return err },
+			wantErr: ErrAppointmentCancelled,
+		},
+		{
+			name:    "reschedule into the past",
+			repo:    &manageRepo{},
+			checker: publicHolidayCheckerSuccess{},
+			op: func(s *ManageService, token string) error {
+				_, err := s.Reschedule(t.Context(), token, ptr.To(now.AddDate(0, 0, -1)))
+				return err
+			},
+			wantErr: ErrAppointmentInPast,
+		},
+		{
+			name:    "reschedule an appointment that has passed",
+			repo:    &manageRepo{visitDate: ptr.To(now.AddDate(0, 0, -1))},
+			checker: publicHolidayCheckerSuccess{},
+			op: func(s *ManageService, token string) error {
+				_, err := s.Reschedule(t.Context(), token, ptr.To(now.AddDate(0, 0, 7)))
+				return err
+			},
+			wantErr: ErrAppointmentInPast,
+		},
+		{
+			name:    "reschedule onto a holiday",
+			repo:    &manageRepo{},
+			checker: publicHolidayCheckerIsPublicHoliday{},
+			op: func(s *ManageService, token string) error {
+				_, err := s.Reschedule(t.Context(), token, ptr.To(now.AddDate(0, 0, 7)))
+				return err
+			},
+			wantErr: ErrAppointmentOnPublicHoliday,
+		},
+		{
+			name:    "reschedule onto a taken date",
+			repo:    &manageRepo{err: ErrAppointmentDateTaken},
+			checker: publicHolidayCheckerSuccess{},
+			op: func(s *ManageService, token string) error {
+				_, err := s.Reschedule(t.Context(), token, ptr.To(now.AddDate(0, 0, 7)))
+				return err
+			},
+			wantErr: ErrAppointmentDateTaken,
+		},
+		{
+			name:    "revoke",
+			repo:    &manageRepo{},
+			op:      func(s *ManageService, token string) error { return s.Revoke(t.Context(), token) },
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.repo.visitDate == nil {
+				tt.repo.visitDate = ptr.To(now.AddDate(0, 0, 3))
+			}
+			underTest := NewManageService(tt.repo, signer, tt.checker, func() time.Time { return now }, time.Hour)
+			token, err := underTest.Issue(t.Context(), 7)
+			require.NoError(t, err)
+			require.Equal(t, now.Add(time.Hour), tt.repo.expiresAt)
+
+			require.ErrorIs(t, tt.op(underTest, token), tt.wantErr)
+		})
+	}
+}
+
+// manageRepo stores a single token for appointment 7.
+type manageRepo struct {
+	expiresAt time.Time
+	visitDate *time.Time // of appointment 7
+	revoked   bool
+	missing   bool
+	err       error
+	actor     string // who the last cancel was attributed to
+}
+
+func (m *manageRepo) CreateManageToken(_ context.Context, appointmentID int32, expiresAt time.Time) (*ManageToken, error) {
+	m.expiresAt = expiresAt
+	return &ManageToken{ID: ManageTokenID{9}, AppointmentID: appointmentID, ExpiresAt: expiresAt}, nil
+}
+
+func (m *manageRepo) GetManageToken(_ context.Context, id ManageTokenID) (*ManageToken, error) {
+	if m.missing || id != (ManageTokenID{9}) {
+		return nil, ErrInvalidManageToken
+	}
+	return &ManageToken{ID: id, AppointmentID: 7, ExpiresAt: m.expiresAt, Revoked: m.revoked}, nil
+}
+
+func (m *manageRepo) RevokeManageToken(_ context.Context, _ ManageTokenID) error {
+	m.revoked = true
+	return nil
+}
+
+func (m *manageRepo) GetAppointment(_ context.Context, id int32) (*Appointment, error) {
+	return &Appointment{ID: id, VisitDate: m.visitDate}, nil
+}
+
+func (m *manageRepo) CancelAppointment(ctx context.Context, id int32) (*Appointment, error) {
+	m.actor = AuditActor(ctx)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &Appointment{ID: id, Status: AppointmentStatusCancelled}, nil
+}
+
+func (m *manageRepo) RescheduleAppointment(_ context.Context, id int32, date *time.Time) (*Appointment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &Appointment{ID: id, VisitDate: date}, nil
+}
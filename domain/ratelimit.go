@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = fmt.Errorf("rate limit exceeded")
+var ErrInvalidRateLimit = fmt.Errorf("invalid rate limit")
+
+// RateLimit allows Requests hits per bucket in each fixed window of length Per.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseRateLimit parses limits such as "30/1m" or "500/1h".
+func ParseRateLimit(s string) (RateLimit, error) {
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("%w: %q is not <requests>/<duration>", ErrInvalidRateLimit, s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return RateLimit{}, fmt.Errorf("%w: requests must be a positive integer", ErrInvalidRateLimit)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d < time.Second {
+		return RateLimit{}, fmt.Errorf("%w: window must be a duration of at least 1s", ErrInvalidRateLimit)
+	}
+	return RateLimit{Requests: n, Per: d}, nil
+}
+
+type RateLimitRepository interface {
+	// IncrementRateLimit atomically counts a hit against the bucket's window and returns the hits so far.
+	IncrementRateLimit(ctx context.Context, bucket string, windowStart time.Time, expiresAt time.Time) (int, error)
+	DeleteExpiredRateLimits(ctx context.Context) error
+}
+
+// RateLimiter counts hits in the database so every replica enforces the same limit.
+type RateLimiter struct {
+	repo    RateLimitRepository
+	limit   RateLimit
+	nowFunc func() time.Time
+
+	mu     sync.Mutex
+	pruned time.Time // start of the window expired counters were last deleted in
+}
+
+func NewRateLimiter(repo RateLimitRepository, limit RateLimit, nowFunc func() time.Time) *RateLimiter {
+	return &RateLimiter{
+		repo:    repo,
+		limit:   limit,
+		nowFunc: nowFunc,
+	}
+}
+
+// Allow counts a hit against bucket. Once the limit is exceeded it returns ErrRateLimited and how long until the
+// window resets.
+func (l *RateLimiter) Allow(ctx context.Context, bucket string) (time.Duration, error) {
+	now := l.nowFunc()
+	windowStart := now.Truncate(l.limit.Per)
+	windowEnd := windowStart.Add(l.limit.Per)
+	l.prune(ctx, windowStart)
+
+	hits, err := l.repo.IncrementRateLimit(ctx, bucket, windowStart, windowEnd)
+	if err != nil {
+		return 0, fmt.Errorf("increment rate limit: %w", err)
+	}
+	if hits > l.limit.Requests {
+		return windowEnd.Sub(now), ErrRateLimited
+	}
+	return 0, nil
+}
+
+// prune deletes expired counters once per window, a failure only leaves them for the next window.
+func (l *RateLimiter) prune(ctx context.Context, windowStart time.Time) {
+	l.mu.Lock()
+	if !windowStart.After(l.pruned) {
+		l.mu.Unlock()
+		return
+	}
+	l.pruned = windowStart
+	l.mu.Unlock()
+
+	_ = l.repo.DeleteExpiredRateLimits(ctx)
+}
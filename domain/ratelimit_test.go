@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		want    RateLimit
+		wantErr string
+	}{
+		{
+			name:  "per minute",
+			limit: "30/1m",
+			want:  RateLimit{Requests: 30, Per: time.Minute},
+		},
+		{
+			name:    "missing window",
+			limit:   "30",
+			wantErr: `invalid rate limit: "30" is not <requests>/<duration>`,
+		},
+		{
+			name:    "zero requests",
+			limit:   "0/1m",
+			wantErr: "invalid rate limit: requests must be a positive integer",
+		},
+		{
+			name:    "window under a second",
+			limit:   "30/10ms",
+			wantErr: "invalid rate limit: window must be a duration of at least 1s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.limit)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 45, 0, time.UTC)
+	repo := &rateLimitRepo{hits: map[string]int{}}
+	underTest := NewRateLimiter(repo, RateLimit{Requests: 2, Per: time.Minute}, func() time.Time { return now })
+
+	for range 2 {
+		retryAfter, err := underTest.Allow(t.Context(), "ip:192.0.2.1")
+		require.NoError(t, err)
+		require.Zero(t, retryAfter)
+	}
+	retryAfter, err := underTest.Allow(t.Context(), "ip:192.0.2.1")
+	require.ErrorIs(t, err, ErrRateLimited)
+	require.Equal(t, 15*time.Second, retryAfter, "until the window ends")
+
+	_, err = underTest.Allow(t.Context(), "ip:192.0.2.2")
+	require.NoError(t, err, "buckets are counted separately")
+	require.Equal(t, 1, repo.pruned, "expired counters are deleted once per window")
+
+	now = now.Add(time.Minute)
+	_, err = underTest.Allow(t.Context(), "ip:192.0.2.1")
+	require.NoError(t, err, "a new window starts a new count")
+	require.Equal(t, 2, repo.pruned)
+
+	repo.err = errors.New("some error")
+	_, err = underTest.Allow(t.Context(), "ip:192.0.2.1")
+	require.EqualError(t, err, "increment rate limit: some error")
+}
+
+type rateLimitRepo struct {
+	hits   map[string]int
+	pruned int
+	err    error
+}
+
+func (r *rateLimitRepo) IncrementRateLimit(_ context.Context, bucket string, windowStart time.Time, _ time.Time) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	key := bucket + windowStart.String()
+	r.hits[key]++
+	return r.hits[key], nil
+}
+
+func (r *rateLimitRepo) DeleteExpiredRateLimits(_ context.Context) error {
+	r.pruned++
+	return nil
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrNoContactDetails is returned by a Notifier that has no way to reach the patient, e.g. email without an address.
+var ErrNoContactDetails = fmt.Errorf("no contact details for notification channel")
+
+// Reminder tells a patient their visit starts in Lead or a little less.
+type Reminder struct {
+	Appointment *Appointment
+	Lead        time.Duration
+	VisitStart  time.Time // start of the visit day in the clinic's time zone
+	Location    *Location
+}
+
+// Notifier sends reminders over a single channel. Channel names the channel when recording sent reminders, so it must
+// stay the same across releases.
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, reminder *Reminder) error
+}
+
+// VisitStart is the start of the visit date in tz.
+func VisitStart(visitDate time.Time, tz *time.Location) time.Time {
+	return time.Date(visitDate.Year(), visitDate.Month(), visitDate.Day(), 0, 0, 0, 0, tz)
+}
+
+// ReminderWindow returns the range of visit dates, inclusive and as stored, whose visit starts more than shorter but no
+// more than lead after now. Passing the next shorter lead as shorter means a booking made close to the visit only gets
+// the most relevant reminder rather than every one at once. ok is false when no date falls in the window.
+func ReminderWindow(now time.Time, shorter time.Duration, lead time.Duration, tz *time.Location) (from time.Time, to time.Time, ok bool) {
+	from = storedDate(now.Add(shorter).In(tz)).AddDate(0, 0, 1)
+	to = storedDate(now.Add(lead).In(tz))
+	return from, to, !from.After(to)
+}
+
+// storedDate is the calendar day of t as visit dates are stored, midnight UTC.
+func storedDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminderWindow(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	date := func(day int) time.Time { return time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		now      time.Time
+		shorter  time.Duration
+		lead     time.Duration
+		tz       *time.Location
+		wantFrom time.Time
+		wantTo   time.Time
+		wantOK   bool
+	}{
+		{
+			name:     "day before",
+			now:      time.Date(2026, 1, 5, 10, 0, 0, 0, london),
+			lead:     24 * time.Hour,
+			tz:       london,
+			wantFrom: date(6),
+			wantTo:   date(6),
+			wantOK:   true,
+		},
+		{
+			name:     "nothing starts in the next two hours mid morning",
+			now:      time.Date(2026, 1, 5, 10, 0, 0, 0, london),
+			lead:     2 * time.Hour,
+			tz:       london,
+			wantFrom: date(6),
+			wantTo:   date(5),
+		},
+		{
+			name:     "two hours before midnight",
+			now:      time.Date(2026, 1, 5, 22, 30, 0, 0, london),
+			lead:     2 * time.Hour,
+			tz:       london,
+			wantFrom: date(6),
+			wantTo:   date(6),
+			wantOK:   true,
+		},
+		{
+			name:     "excludes visits a shorter reminder covers",
+			now:      time.Date(2026, 1, 5, 10, 0, 0, 0, london),
+			shorter:  24 * time.Hour,
+			lead:     72 * time.Hour,
+			tz:       london,
+			wantFrom: date(7),
+			wantTo:   date(8),
+			wantOK:   true,
+		},
+		{
+			name:     "uses the clinic's calendar day",
+			now:      time.Date(2026, 1, 5, 20, 0, 0, 0, time.UTC), // already the 6th in Tokyo
+			lead:     24 * time.Hour,
+			tz:       tokyo,
+			wantFrom: date(7),
+			wantTo:   date(7),
+			wantOK:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, ok := ReminderWindow(tt.now, tt.shorter, tt.lead, tt.tz)
+			require.Equal(t, tt.wantFrom, from)
+			require.Equal(t, tt.wantTo, to)
+			require.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
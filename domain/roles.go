@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+var ErrForbidden = fmt.Errorf("forbidden")
+var ErrInvalidRole = fmt.Errorf("unknown role")
+
+type Role string
+
+const (
+	RoleAdmin        Role = "admin"
+	RoleReceptionist Role = "receptionist"
+	RolePatient      Role = "patient"
+	RoleIntegration  Role = "integration"
+)
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permission is what a route requires of the principal calling it.
+type Permission string
+
+const (
+	PermissionBook           Permission = "appointments:book"   // hold dates and book single appointments or series
+	PermissionImport         Permission = "appointments:import" // bulk import bookings
+	PermissionRead           Permission = "appointments:read"   // export, subscribe to and see the history of bookings
+	PermissionCancel         Permission = "appointments:cancel" // cancel a booking
+	PermissionManageWebhooks Permission = "webhooks:manage"
+	PermissionManageAPIKeys  Permission = "apikeys:manage"
+)
+
+// Scope is which appointments a role grants a permission on.
+type Scope string
+
+const (
+	ScopeNone Scope = ""
+	// ScopeOwn only covers appointments booked under the principal's email, see Principal.Owns.
+	ScopeOwn Scope = "own"
+	ScopeAll Scope = "all"
+)
+
+// rolePermissions grants each role its permissions. Patients book, see and cancel their own appointments only, staff
+// and integrations act on everyone's.
+var rolePermissions = map[Role]map[Permission]Scope{
+	RoleAdmin: all(
+		PermissionBook, PermissionImport, PermissionRead, PermissionCancel, PermissionManageWebhooks,
+		PermissionManageAPIKeys,
+	),
+	RoleReceptionist: all(PermissionBook, PermissionImport, PermissionRead, PermissionCancel),
+	RolePatient:      {PermissionBook: ScopeOwn, PermissionRead: ScopeOwn, PermissionCancel: ScopeOwn},
+	RoleIntegration:  all(PermissionBook),
+}
+
+func all(permissions ...Permission) map[Permission]Scope {
+	granted := make(map[Permission]Scope, len(permissions))
+	for _, permission := range permissions {
+		granted[permission] = ScopeAll
+	}
+	return granted
+}
+
+// Scope returns the widest scope any of the principal's roles grants permission with.
+func (p *Principal) Scope(permission Permission) Scope {
+	scope := ScopeNone
+	for _, role := range p.Roles {
+		switch rolePermissions[role][permission] {
+		case ScopeAll:
+			return ScopeAll
+		case ScopeOwn:
+			scope = ScopeOwn
+		}
+	}
+	return scope
+}
+
+// Can reports whether any of the principal's roles grants permission, on at least its own appointments.
+func (p *Principal) Can(permission Permission) bool {
+	return p.Scope(permission) != ScopeNone
+}
+
+// CanAccess reports whether the principal may use permission on appt.
+func (p *Principal) CanAccess(permission Permission, appt *Appointment) bool {
+	switch p.Scope(permission) {
+	case ScopeAll:
+		return true
+	case ScopeOwn:
+		return p.Owns(appt)
+	}
+	return false
+}
+
+// Owns reports whether appt was booked under the principal's email. Principals without one, such as api keys, own
+// nothing.
+func (p *Principal) Owns(appt *Appointment) bool {
+	return p.Email != "" && strings.EqualFold(p.Email, appt.Email)
+}
+
+// ParseRoles keeps the known roles in names, e.g. from a JWT claim shared with other applications.
+func ParseRoles(names []string) []Role {
+	var roles []Role
+	for _, name := range names {
+		if role := Role(name); role.Valid() {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
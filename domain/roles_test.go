@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrincipal_Can(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []Role
+		permission Permission
+		want       bool
+	}{
+		{
+			name:       "receptionists cancel anyone's booking",
+			roles:      []Role{RoleReceptionist},
+			permission: PermissionCancel,
+			want:       true,
+		},
+		{
+			name:       "receptionists do not manage keys",
+			roles:      []Role{RoleReceptionist},
+			permission: PermissionManageAPIKeys,
+		},
+		{
+			name:       "patients book their own",
+			roles:      []Role{RolePatient},
+			permission: PermissionBook,
+			want:       true,
+		},
+		{
+			name:       "patients do not import",
+			roles:      []Role{RolePatient},
+			permission: PermissionImport,
+		},
+		{
+			name:       "integrations only create",
+			roles:      []Role{RoleIntegration},
+			permission: PermissionCancel,
+		},
+		{
+			name:       "admins manage keys",
+			roles:      []Role{RoleAdmin},
+			permission: PermissionManageAPIKeys,
+			want:       true,
+		},
+		{
+			name:       "any role can grant",
+			roles:      []Role{RolePatient, RoleReceptionist},
+			permission: PermissionImport,
+			want:       true,
+		},
+		{
+			name:       "no roles",
+			permission: PermissionBook,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, (&Principal{Roles: tt.roles}).Can(tt.permission))
+		})
+	}
+}
+
+func TestPrincipal_CanAccess(t *testing.T) {
+	theirs := &Appointment{Email: "Jane@Example.com"}
+	tests := []struct {
+		name      string
+		principal *Principal
+		want      bool
+	}{
+		{
+			name:      "patients cancel their own",
+			principal: &Principal{Email: "jane@example.com", Roles: []Role{RolePatient}},
+			want:      true,
+		},
+		{
+			name:      "patients do not cancel someone else's",
+			principal: &Principal{Email: "john@example.com", Roles: []Role{RolePatient}},
+		},
+		{
+			name:      "patients without an email own nothing",
+			principal: &Principal{Roles: []Role{RolePatient}},
+		},
+		{
+			name:      "the widest role wins",
+			principal: &Principal{Email: "john@example.com", Roles: []Role{RolePatient, RoleReceptionist}},
+			want:      true,
+		},
+		{
+			name:      "integrations do not cancel",
+			principal: &Principal{Email: "jane@example.com", Roles: []Role{RoleIntegration}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.principal.CanAccess(PermissionCancel, theirs))
+		})
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	require.Equal(t, []Role{RoleReceptionist, RoleAdmin}, ParseRoles([]string{"receptionist", "offline_access", "admin"}))
+	require.Nil(t, ParseRoles(nil))
+}
@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSeriesOccurrences caps how many appointments a single series may expand to.
+const MaxSeriesOccurrences = 52
+
+var ErrInvalidRecurrence = fmt.Errorf("invalid recurrence rule")
+var ErrSeriesRolledBack = fmt.Errorf("not booked because another occurrence in the series failed")
+
+// errSeriesIncomplete aborts an all-or-nothing series transaction.
+var errSeriesIncomplete = errors.New("series incomplete")
+
+type Frequency string
+
+const (
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+// Recurrence is the subset of an RFC 5545 RRULE we support: weekly or monthly with an interval (INTERVAL=2 for
+// biweekly), bounded by exactly one of COUNT or UNTIL.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	Until    *time.Time
+}
+
+// ParseRecurrence parses rules such as "FREQ=WEEKLY;INTERVAL=2;COUNT=8" or "FREQ=MONTHLY;UNTIL=20260630".
+func ParseRecurrence(rrule string) (*Recurrence, error) {
+	rec := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rec.Freq = Frequency(strings.ToUpper(value))
+			if rec.Freq != FrequencyWeekly && rec.Freq != FrequencyMonthly {
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRecurrence, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRecurrence)
+			}
+			rec.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > MaxSeriesOccurrences {
+				return nil, fmt.Errorf("%w: COUNT must be between 1 and %d", ErrInvalidRecurrence, MaxSeriesOccurrences)
+			}
+			rec.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be a date like 20260630", ErrInvalidRecurrence)
+			}
+			rec.Until = &until
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRecurrence, key)
+		}
+	}
+	if rec.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	}
+	if (rec.Count == 0) == (rec.Until == nil) {
+		return nil, fmt.Errorf("%w: exactly one of COUNT or UNTIL is required", ErrInvalidRecurrence)
+	}
+	return rec, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if len(value) > len("20060102") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	return time.Parse("20060102", value)
+}
+
+// Dates expands the rule from start, which is always the first occurrence. Monthly occurrences that fall on a day the
+// month does not have (e.g. the 31st) are skipped as RFC 5545 requires.
+func (r *Recurrence) Dates(start time.Time) ([]time.Time, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	var dates []time.Time
+	for i := 0; ; i++ {
+		var next time.Time
+		switch r.Freq {
+		case FrequencyWeekly:
+			next = start.AddDate(0, 0, 7*r.Interval*i)
+		case FrequencyMonthly:
+			next = start.AddDate(0, r.Interval*i, 0)
+			if next.Day() != start.Day() {
+				continue
+			}
+		}
+		if r.Until != nil && next.After(*r.Until) {
+			if len(dates) == 0 {
+				return nil, fmt.Errorf("%w: UNTIL is before the first occurrence", ErrInvalidRecurrence)
+			}
+			return dates, nil
+		}
+		if len(dates) == MaxSeriesOccurrences {
+			return nil, fmt.Errorf("%w: more than %d occurrences", ErrInvalidRecurrence, MaxSeriesOccurrences)
+		}
+		dates = append(dates, next)
+		if r.Count > 0 && len(dates) == r.Count {
+			return dates, nil
+		}
+	}
+}
+
+type SeriesMode string
+
+const (
+	// SeriesAllOrNothing books every occurrence or none of them.
+	SeriesAllOrNothing SeriesMode = "all-or-nothing"
+	// SeriesBestEffort books every occurrence that passes validation and reports the rest.
+	SeriesBestEffort SeriesMode = "best-effort"
+)
+
+// OccurrenceResult is the outcome of booking a single date in a series, exactly one of Appointment or Err is set.
+type OccurrenceResult struct {
+	VisitDate   time.Time
+	Appointment *Appointment
+	Err         error
+}
+
+// AppointmentTransactor runs fn against a repository whose writes are committed together, or discarded if fn errors.
+type AppointmentTransactor interface {
+	InTx(ctx context.Context, fn func(repo AppointmentPersistorRepository) error) error
+}
+
+type AppointmentSeriesService struct {
+	creator *AppointmentCreatorService
+	tx      AppointmentTransactor
+}
+
+func NewAppointmentSeriesService(creator *AppointmentCreatorService, tx AppointmentTransactor) *AppointmentSeriesService {
+	return &AppointmentSeriesService{
+		creator: creator,
+		tx:      tx,
+	}
+}
+
+// Create books an appointment for every date of the recurrence starting at appt.VisitDate, validating each one with
+// the same rules as a single booking. The returned error is only set when the series could not be attempted at all.
+func (s *AppointmentSeriesService) Create(ctx context.Context, appt *Appointment, rec *Recurrence, mode SeriesMode) ([]OccurrenceResult, error) {
+	if appt == nil || rec == nil {
+		return nil, fmt.Errorf("appointment or recurrence is nil")
+	}
+	dates, err := rec.Dates(*appt.VisitDate)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case SeriesBestEffort:
+		return createOccurrences(ctx, s.creator, appt, dates), nil
+	case SeriesAllOrNothing:
+		var results []OccurrenceResult
+		err := s.tx.InTx(ctx, func(repo AppointmentPersistorRepository) error {
+			results = createOccurrences(ctx, s.creator.withRepo(repo), appt, dates)
+			for i := range results {
+				if results[i].Err != nil {
+					return errSeriesIncomplete
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, errSeriesIncomplete) {
+			for i := range results {
+				if results[i].Err == nil {
+					results[i] = OccurrenceResult{VisitDate: results[i].VisitDate, Err: ErrSeriesRolledBack}
+				}
+			}
+			return results, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("book series: %w", err)
+		}
+		return results, nil
+	default:
+		return nil, fmt.Errorf("unknown series mode %q", mode)
+	}
+}
+
+func createOccurrences(ctx context.Context, creator *AppointmentCreatorService, appt *Appointment, dates []time.Time) []OccurrenceResult {
+	results := make([]OccurrenceResult, len(dates))
+	for i := range dates {
+		occurrence := NewAppointment(appt.FirstName, appt.LastName, &dates[i])
+		occurrence.Email = appt.Email
+		occurrence.Phone = appt.Phone
+		booked, err := creator.Create(ctx, occurrence)
+		results[i] = OccurrenceResult{VisitDate: dates[i], Appointment: booked, Err: err}
+	}
+	return results
+}
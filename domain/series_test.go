@@ -0,0 +1,252 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name    string
+		rrule   string
+		want    *Recurrence
+		wantErr string
+	}{
+		{
+			name:  "weekly with count",
+			rrule: "FREQ=WEEKLY;COUNT=8",
+			want:  &Recurrence{Freq: FrequencyWeekly, Interval: 1, Count: 8},
+		},
+		{
+			name:  "biweekly with count and RRULE prefix",
+			rrule: "RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4",
+			want:  &Recurrence{Freq: FrequencyWeekly, Interval: 2, Count: 4},
+		},
+		{
+			name:  "monthly until date",
+			rrule: "FREQ=MONTHLY;UNTIL=20260630",
+			want:  &Recurrence{Freq: FrequencyMonthly, Interval: 1, Until: ptr.To(time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			name:  "monthly until date time",
+			rrule: "FREQ=MONTHLY;UNTIL=20260630T000000Z",
+			want:  &Recurrence{Freq: FrequencyMonthly, Interval: 1, Until: ptr.To(time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			name:    "unsupported frequency",
+			rrule:   "FREQ=DAILY;COUNT=3",
+			wantErr: `invalid recurrence rule: unsupported FREQ "DAILY"`,
+		},
+		{
+			name:    "missing frequency",
+			rrule:   "COUNT=3",
+			wantErr: "invalid recurrence rule: FREQ is required",
+		},
+		{
+			name:    "neither count nor until",
+			rrule:   "FREQ=WEEKLY",
+			wantErr: "invalid recurrence rule: exactly one of COUNT or UNTIL is required",
+		},
+		{
+			name:    "both count and until",
+			rrule:   "FREQ=WEEKLY;COUNT=2;UNTIL=20260630",
+			wantErr: "invalid recurrence rule: exactly one of COUNT or UNTIL is required",
+		},
+		{
+			name:    "count above maximum",
+			rrule:   "FREQ=WEEKLY;COUNT=53",
+			wantErr: "invalid recurrence rule: COUNT must be between 1 and 52",
+		},
+		{
+			name:    "unsupported part",
+			rrule:   "FREQ=WEEKLY;COUNT=2;BYDAY=MO",
+			wantErr: `invalid recurrence rule: unsupported part "BYDAY"`,
+		},
+		{
+			name:    "malformed part",
+			rrule:   "FREQ=WEEKLY;COUNT",
+			wantErr: `invalid recurrence rule: malformed part "COUNT"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.rrule)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.ErrorIs(t, err, ErrInvalidRecurrence)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRecurrence_Dates(t *testing.T) {
+	tests := []struct {
+		name    string
+		rec     Recurrence
+		start   time.Time
+		want    []time.Time
+		wantErr string
+	}{
+		{
+			name:  "biweekly count",
+			rec:   Recurrence{Freq: FrequencyWeekly, Interval: 2, Count: 3},
+			start: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "weekly until is inclusive",
+			rec:   Recurrence{Freq: FrequencyWeekly, Interval: 1, Until: ptr.To(time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC))},
+			start: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "monthly skips months without the day",
+			rec:   Recurrence{Freq: FrequencyMonthly, Interval: 1, Count: 3},
+			start: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "until before start",
+			rec:     Recurrence{Freq: FrequencyWeekly, Interval: 1, Until: ptr.To(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))},
+			start:   time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			wantErr: "invalid recurrence rule: UNTIL is before the first occurrence",
+		},
+		{
+			name:    "until too far ahead",
+			rec:     Recurrence{Freq: FrequencyWeekly, Interval: 1, Until: ptr.To(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))},
+			start:   time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			wantErr: "invalid recurrence rule: more than 52 occurrences",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rec.Dates(tt.start)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAppointmentSeriesService_Create(t *testing.T) {
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	weekly := &Recurrence{Freq: FrequencyWeekly, Interval: 1, Count: 3}
+	taken := start.AddDate(0, 0, 7)
+	tests := []struct {
+		name          string
+		mode          SeriesMode
+		takenDates    []time.Time
+		wantErrs      []error
+		wantCommitted int
+	}{
+		{
+			name:          "best effort books every free date",
+			mode:          SeriesBestEffort,
+			takenDates:    []time.Time{taken},
+			wantErrs:      []error{nil, ErrAppointmentDateTaken, nil},
+			wantCommitted: 2,
+		},
+		{
+			name:          "all or nothing books nothing when a date is taken",
+			mode:          SeriesAllOrNothing,
+			takenDates:    []time.Time{taken},
+			wantErrs:      []error{ErrSeriesRolledBack, ErrAppointmentDateTaken, ErrSeriesRolledBack},
+			wantCommitted: 0,
+		},
+		{
+			name:          "all or nothing books every date when all are free",
+			mode:          SeriesAllOrNothing,
+			wantErrs:      []error{nil, nil, nil},
+			wantCommitted: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTxRepo{taken: tt.takenDates}
+			creator := NewAppointmentCreatorService(repo, publicHolidayCheckerSuccess{}, fixedTimeFunc)
+			unitUnderTest := NewAppointmentSeriesService(creator, repo)
+
+			appt := NewAppointment("first", "last", &start)
+			appt.Email = "first@example.com"
+			appt.Phone = "+447700900123"
+			got, err := unitUnderTest.Create(t.Context(), appt, weekly, tt.mode)
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.wantErrs))
+			for i := range got {
+				require.Equal(t, start.AddDate(0, 0, 7*i), got[i].VisitDate)
+				require.Equal(t, tt.wantErrs[i], got[i].Err)
+				require.Equal(t, tt.wantErrs[i] == nil, got[i].Appointment != nil)
+			}
+			require.Len(t, repo.committed, tt.wantCommitted)
+			for _, occurrence := range repo.committed {
+				require.Equal(t, "first@example.com", occurrence.Email)
+				require.Equal(t, "+447700900123", occurrence.Phone)
+			}
+		})
+	}
+}
+
+func TestAppointmentSeriesService_CreateInvalid(t *testing.T) {
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	unitUnderTest := NewAppointmentSeriesService(nil, nil)
+
+	_, err := unitUnderTest.Create(t.Context(), NewAppointment("first", "last", &start), nil, SeriesBestEffort)
+	require.EqualError(t, err, "appointment or recurrence is nil")
+
+	_, err = unitUnderTest.Create(t.Context(), NewAppointment("first", "last", &start), &Recurrence{Freq: FrequencyWeekly, Interval: 1, Count: 1}, "sometimes")
+	require.EqualError(t, err, `unknown series mode "sometimes"`)
+}
+
+// fakeTxRepo books into pending while in a transaction and only moves them to committed if it succeeds.
+type fakeTxRepo struct {
+	taken     []time.Time
+	committed []*Appointment
+	pending   *[]*Appointment
+}
+
+func (f *fakeTxRepo) CreateAppointment(_ context.Context, appt *Appointment) (*Appointment, error) {
+	for _, taken := range f.taken {
+		if taken.Equal(*appt.VisitDate) {
+			return nil, ErrAppointmentDateTaken
+		}
+	}
+	if f.pending != nil {
+		*f.pending = append(*f.pending, appt)
+	} else {
+		f.committed = append(f.committed, appt)
+	}
+	return appt, nil
+}
+
+func (f *fakeTxRepo) InTx(_ context.Context, fn func(repo AppointmentPersistorRepository) error) error {
+	var pending []*Appointment
+	f.pending = &pending
+	defer func() { f.pending = nil }()
+	if err := fn(f); err != nil {
+		return err
+	}
+	f.committed = append(f.committed, pending...)
+	return nil
+}
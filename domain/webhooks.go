@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+var ErrInvalidWebhook = fmt.Errorf("invalid webhook")
+var ErrWebhookNotFound = fmt.Errorf("webhook not found")
+var ErrWebhookDeliveryNotFound = fmt.Errorf("webhook delivery not found")
+var ErrWebhookDeliveryNotDead = fmt.Errorf("only dead-lettered webhook deliveries can be retried")
+
+// WebhookSubscription asks for events to be POSTed to URL, signed with Secret. An empty EventTypes means every event.
+type WebhookSubscription struct {
+	ID         int32 // assigned by the repository
+	URL        string
+	Secret     string
+	EventTypes []EventType
+	CreatedAt  time.Time
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead" // gave up after too many failed attempts
+)
+
+func (s WebhookDeliveryStatus) Valid() bool {
+	return s == WebhookDeliveryPending || s == WebhookDeliveryDelivered || s == WebhookDeliveryDead
+}
+
+// WebhookDelivery is a single event on its way to a single subscription, doubling as the log of how that went.
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int32
+	EventID        int64
+	EventType      EventType
+	Payload        json.RawMessage
+	Status         WebhookDeliveryStatus
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode int32 // zero until a receiver has responded
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// DueWebhookDelivery is a delivery claimed for its next attempt, along with where to send it and the key to sign it with.
+type DueWebhookDelivery struct {
+	*WebhookDelivery
+	URL    string
+	Secret string
+}
+
+type WebhookRepository interface {
+	CreateWebhookSubscription(ctx context.Context, sub *WebhookSubscription) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int32) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int32, status WebhookDeliveryStatus) ([]*WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, subscriptionID int32, deliveryID int64) (*WebhookDelivery, error)
+}
+
+type WebhookService struct {
+	repo WebhookRepository
+}
+
+func NewWebhookService(repo WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// Create subscribes rawURL to eventTypes with a freshly generated signing secret, which is only ever returned here.
+func (s *WebhookService) Create(ctx context.Context, rawURL string, eventTypes []EventType) (*WebhookSubscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidWebhook)
+	}
+	for _, t := range eventTypes {
+		if !t.Valid() {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, t)
+		}
+	}
+
+	sub, err := s.repo.CreateWebhookSubscription(ctx, &WebhookSubscription{
+		URL:        u.String(),
+		Secret:     "whsec_" + rand.Text(),
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save webhook: %w", err)
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]*WebhookSubscription, error) {
+	subs, err := s.repo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	return subs, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int32) error {
+	if err := s.repo.DeleteWebhookSubscription(ctx, id); err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	return nil
+}
+
+// Deliveries returns the most recent deliveries to a subscription, newest first, optionally only those in status.
+func (s *WebhookService) Deliveries(ctx context.Context, subscriptionID int32, status WebhookDeliveryStatus) ([]*WebhookDelivery, error) {
+	if status != "" && !status.Valid() {
+		return nil, fmt.Errorf("%w: unknown delivery status %q", ErrInvalidWebhook, status)
+	}
+	deliveries, err := s.repo.ListWebhookDeliveries(ctx, subscriptionID, status)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Retry puts a dead-lettered delivery back in the queue with a fresh set of attempts.
+func (s *WebhookService) Retry(ctx context.Context, subscriptionID int32, deliveryID int64) (*WebhookDelivery, error) {
+	delivery, err := s.repo.RetryWebhookDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrWebhookDeliveryNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		if errors.Is(err, ErrWebhookDeliveryNotDead) {
+			return nil, ErrWebhookDeliveryNotDead
+		}
+		return nil, fmt.Errorf("retry webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
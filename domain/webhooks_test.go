@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookService_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []EventType
+		wantErr    string
+	}{
+		{
+			name:       "subscribes to the given events",
+			url:        "https://billing.example.com/hooks",
+			eventTypes: []EventType{EventAppointmentCreated},
+		},
+		{
+			name: "subscribes to every event by default",
+			url:  "http://localhost:8080/hooks",
+		},
+		{
+			name:    "relative url",
+			url:     "/hooks",
+			wantErr: "invalid webhook: url must be an absolute http or https url",
+		},
+		{
+			name:    "unsupported scheme",
+			url:     "ftp://billing.example.com/hooks",
+			wantErr: "invalid webhook: url must be an absolute http or https url",
+		},
+		{
+			name:       "unknown event type",
+			url:        "https://billing.example.com/hooks",
+			eventTypes: []EventType{"AppointmentEaten"},
+			wantErr:    `invalid webhook: unknown event type "AppointmentEaten"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &webhookRepo{}
+			got, err := NewWebhookService(repo).Create(t.Context(), tt.url, tt.eventTypes)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, ErrInvalidWebhook)
+				require.EqualError(t, err, tt.wantErr)
+				require.Nil(t, repo.created)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.url, got.URL)
+			require.Equal(t, tt.eventTypes, got.EventTypes)
+			require.True(t, strings.HasPrefix(got.Secret, "whsec_"))
+			require.Greater(t, len(got.Secret), len("whsec_")+20)
+		})
+	}
+}
+
+func TestWebhookService_SecretsAreUnique(t *testing.T) {
+	service := NewWebhookService(&webhookRepo{})
+	first, err := service.Create(t.Context(), "https://billing.example.com/hooks", nil)
+	require.NoError(t, err)
+	second, err := service.Create(t.Context(), "https://billing.example.com/hooks", nil)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Secret, second.Secret)
+}
+
+func TestWebhookService_Deliveries(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  WebhookDeliveryStatus
+		repoErr error
+		wantErr error
+	}{
+		{
+			name: "all statuses",
+		},
+		{
+			name:   "dead letters only",
+			status: WebhookDeliveryDead,
+		},
+		{
+			name:    "unknown status",
+			status:  "lost",
+			wantErr: ErrInvalidWebhook,
+		},
+		{
+			name:    "bubble up not found",
+			repoErr: ErrWebhookNotFound,
+			wantErr: ErrWebhookNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookService(&webhookRepo{err: tt.repoErr}).Deliveries(t.Context(), 1, tt.status)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWebhookService_Retry(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		wantErr string
+	}{
+		{
+			name: "requeued",
+		},
+		{
+			name:    "bubble up not dead",
+			repoErr: ErrWebhookDeliveryNotDead,
+			wantErr: ErrWebhookDeliveryNotDead.Error(),
+		},
+		{
+			name:    "bubble up not found",
+			repoErr: ErrWebhookDeliveryNotFound,
+			wantErr: ErrWebhookDeliveryNotFound.Error(),
+		},
+		{
+			name:    "wrap repository error",
+			repoErr: errors.New("some error"),
+			wantErr: "retry webhook delivery: some error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookService(&webhookRepo{err: tt.repoErr}).Retry(t.Context(), 1, 2)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+type webhookRepo struct {
+	created *WebhookSubscription
+	err     error
+}
+
+func (w *webhookRepo) CreateWebhookSubscription(_ context.Context, sub *WebhookSubscription) (*WebhookSubscription, error) {
+	w.created = sub
+	return sub, w.err
+}
+
+func (w *webhookRepo) ListWebhookSubscriptions(_ context.Context) ([]*WebhookSubscription, error) {
+	return nil, w.err
+}
+
+func (w *webhookRepo) DeleteWebhookSubscription(_ context.Context, _ int32) error {
+	return w.err
+}
+
+func (w *webhookRepo) ListWebhookDeliveries(_ context.Context, _ int32, _ WebhookDeliveryStatus) ([]*WebhookDelivery, error) {
+	return nil, w.err
+}
+
+func (w *webhookRepo) RetryWebhookDelivery(_ context.Context, _ int32, deliveryID int64) (*WebhookDelivery, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return &WebhookDelivery{ID: deliveryID, Status: WebhookDeliveryPending}, nil
+}
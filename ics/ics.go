@@ -0,0 +1,132 @@
+package ics
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status values for VEVENT STATUS, calendar clients remove events that come through as cancelled.
+type Status string
+
+const (
+	StatusConfirmed Status = "CONFIRMED"
+	StatusCancelled Status = "CANCELLED"
+)
+
+// dateTimeUTC is the DATE-TIME format for a time in UTC.
+const dateTimeUTC = "20060102T150405Z"
+
+// maxLineOctets is the RFC 5545 limit before a content line must be folded.
+const maxLineOctets = 75
+
+// Event is an all-day VEVENT. Stamp is when the calendar was generated, Sequence and LastModified tell clients which of
+// two copies of the event is newer.
+type Event struct {
+	UID          string
+	Stamp        time.Time
+	Sequence     int32
+	LastModified time.Time
+	Date         time.Time
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Status       Status
+}
+
+// Calendar holds the VCALENDAR level properties. TimeZone is the IANA name clients should display events in, all-day
+// dates are floating so it never shifts them to another day.
+type Calendar struct {
+	Name     string
+	TimeZone string
+}
+
+// Writer writes an iCalendar stream one event at a time. Errors are sticky and returned from Close.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewWriter starts a VCALENDAR on w.
+func NewWriter(w io.Writer, cal Calendar) *Writer {
+	cw := &Writer{w: bufio.NewWriter(w)}
+	cw.line("BEGIN", "VCALENDAR")
+	cw.line("VERSION", "2.0")
+	cw.line("PRODID", "-//jcooney//appts//EN")
+	cw.line("CALSCALE", "GREGORIAN")
+	cw.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		cw.line("X-WR-CALNAME", escape(cal.Name))
+	}
+	if cal.TimeZone != "" {
+		cw.line("X-WR-TIMEZONE", cal.TimeZone)
+	}
+	return cw
+}
+
+func (cw *Writer) WriteEvent(e Event) error {
+	cw.line("BEGIN", "VEVENT")
+	cw.line("UID", escape(e.UID))
+	cw.line("DTSTAMP", e.Stamp.UTC().Format(dateTimeUTC))
+	cw.line("SEQUENCE", strconv.Itoa(int(e.Sequence)))
+	if !e.LastModified.IsZero() {
+		cw.line("LAST-MODIFIED", e.LastModified.UTC().Format(dateTimeUTC))
+	}
+	cw.line("DTSTART;VALUE=DATE", e.Date.Format("20060102"))
+	cw.line("DTEND;VALUE=DATE", e.Date.AddDate(0, 0, 1).Format("20060102"))
+	cw.line("SUMMARY", escape(e.Summary))
+	if e.Description != "" {
+		cw.line("DESCRIPTION", escape(e.Description))
+	}
+	if e.Location != "" {
+		cw.line("LOCATION", escape(e.Location))
+	}
+	if e.URL != "" {
+		cw.line("URL", e.URL)
+	}
+	if e.Status != "" {
+		cw.line("STATUS", string(e.Status))
+	}
+	cw.line("END", "VEVENT")
+	return cw.err
+}
+
+// Close ends the VCALENDAR and flushes it to the underlying writer.
+func (cw *Writer) Close() error {
+	cw.line("END", "VCALENDAR")
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+// line writes a CRLF terminated content line, folding it at maxLineOctets without splitting UTF-8 sequences.
+func (cw *Writer) line(name, value string) {
+	if cw.err != nil {
+		return
+	}
+	content := name + ":" + value
+	var b strings.Builder
+	octets := 0
+	for _, r := range content {
+		size := len(string(r))
+		if octets+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			octets = 1
+		}
+		b.WriteRune(r)
+		octets += size
+	}
+	b.WriteString("\r\n")
+	_, cw.err = cw.w.WriteString(b.String())
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes TEXT property values.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
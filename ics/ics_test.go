@@ -0,0 +1,68 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b, Calendar{Name: "Clinic, bookings", TimeZone: "Europe/London"})
+	require.NoError(t, w.WriteEvent(Event{
+		UID:          "appointment-1@appts",
+		Stamp:        time.Date(2025, 12, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600)),
+		Sequence:     2,
+		LastModified: time.Date(2025, 11, 30, 18, 0, 0, 0, time.UTC),
+		Date:         time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		Summary:      "Appointment: John Doe",
+		Description:  "line one\nline two; with a semicolon",
+		Location:     "1 High Street, London",
+		URL:          "https://example.com/appts/1",
+		Status:       StatusCancelled,
+	}))
+	require.NoError(t, w.Close())
+
+	require.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//jcooney//appts//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Clinic\, bookings`,
+		"X-WR-TIMEZONE:Europe/London",
+		"BEGIN:VEVENT",
+		"UID:appointment-1@appts",
+		"DTSTAMP:20251201T083000Z",
+		"SEQUENCE:2",
+		"LAST-MODIFIED:20251130T180000Z",
+		"DTSTART;VALUE=DATE:20260105",
+		"DTEND;VALUE=DATE:20260106",
+		"SUMMARY:Appointment: John Doe",
+		`DESCRIPTION:line one\nline two\; with a semicolon`,
+		`LOCATION:1 High Street\, London`,
+		"URL:https://example.com/appts/1",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), b.String())
+}
+
+func TestWriterFoldsLongLines(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b, Calendar{})
+	require.NoError(t, w.WriteEvent(Event{
+		UID:     "appointment-1@appts",
+		Date:    time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		Summary: strings.Repeat("é", 40),
+	}))
+	require.NoError(t, w.Close())
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), 75)
+	}
+	require.Contains(t, b.String(), "SUMMARY:"+strings.Repeat("é", 33)+"\r\n "+strings.Repeat("é", 7)+"\r\n")
+}
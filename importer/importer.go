@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// MaxRows caps the size of a single import so it fits comfortably in one transaction.
+const MaxRows = 10000
+
+var ErrTooManyRows = fmt.Errorf("import exceeds %d rows", MaxRows)
+var ErrUnsupportedFormat = fmt.Errorf("unsupported import format")
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// record mirrors the columns of a legacy export, a CSV header row or NDJSON object uses the same names.
+type record struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	VisitDate string `json:"visitDate"`
+}
+
+// Parse reads firstName,lastName,visitDate rows. Rows that are malformed are returned with Err set rather than failing
+// the whole import, so they can be reported alongside rows rejected by the domain rules.
+func Parse(r io.Reader, format Format) ([]domain.ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatNDJSON:
+		return parseNDJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func parseCSV(r io.Reader) ([]domain.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // column count is checked per row so it can be reported
+	reader.TrimLeadingSpace = true
+	var rows []domain.ImportRow
+	seenData := false // a header is only skipped before the first data row, malformed lines may come before it
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if len(rows) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, invalidRow(parseErr.Line, parseErr.Err))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if !seenData && isHeader(fields) {
+			continue
+		}
+		seenData = true
+		if len(fields) != 3 {
+			rows = append(rows, invalidRow(line, fmt.Errorf("expected 3 columns, got %d", len(fields))))
+			continue
+		}
+		rows = append(rows, toRow(line, record{FirstName: fields[0], LastName: fields[1], VisitDate: fields[2]}))
+	}
+}
+
+func isHeader(fields []string) bool {
+	return len(fields) > 0 && strings.EqualFold(strings.TrimSpace(fields[0]), "firstName")
+}
+
+func parseNDJSON(r io.Reader) ([]domain.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	var rows []domain.ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+		var rec record
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			rows = append(rows, invalidRow(line, err))
+			continue
+		}
+		rows = append(rows, toRow(line, rec))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ndjson: %w", err)
+	}
+	return rows, nil
+}
+
+// toRow applies the same field rules as a single booking request, which limits names by characters rather than bytes.
+func toRow(line int, rec record) domain.ImportRow {
+	firstName, lastName := strings.TrimSpace(rec.FirstName), strings.TrimSpace(rec.LastName)
+	switch {
+	case firstName == "" || lastName == "":
+		return invalidRow(line, errors.New("firstName and lastName are required"))
+	case utf8.RuneCountInString(firstName) > 50 || utf8.RuneCountInString(lastName) > 50:
+		return invalidRow(line, errors.New("firstName and lastName must be at most 50 characters"))
+	}
+	visitDate, err := time.Parse(time.DateOnly, strings.TrimSpace(rec.VisitDate))
+	if err != nil {
+		return invalidRow(line, err)
+	}
+	return domain.ImportRow{Line: line, Appointment: domain.NewAppointment(firstName, lastName, &visitDate)}
+}
+
+func invalidRow(line int, err error) domain.ImportRow {
+	return domain.ImportRow{Line: line, Err: fmt.Errorf("%w: %w", domain.ErrInvalidImportRow, err)}
+}
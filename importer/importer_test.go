@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	visitDate := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		input   string
+		format  Format
+		want    []domain.ImportRow
+		wantErr error
+	}{
+		{
+			name:   "csv with header",
+			input:  "firstName,lastName,visitDate\nJohn,Doe,2026-01-05\n",
+			format: FormatCSV,
+			want: []domain.ImportRow{
+				{Line: 2, Appointment: domain.NewAppointment("John", "Doe", &visitDate)},
+			},
+		},
+		{
+			name:   "csv header after a malformed line",
+			input:  "a\"b,c\nfirstName,lastName,visitDate\nJohn,Doe,2026-01-05\n",
+			format: FormatCSV,
+			want: []domain.ImportRow{
+				{Line: 1, Err: errors.New(`invalid import row: bare " in non-quoted-field`)},
+				{Line: 3, Appointment: domain.NewAppointment("John", "Doe", &visitDate)},
+			},
+		},
+		{
+			name:   "csv header is data after the first data row",
+			input:  "John,Doe,2026-01-05\nfirstName,lastName,visitDate\n",
+			format: FormatCSV,
+			want: []domain.ImportRow{
+				{Line: 1, Appointment: domain.NewAppointment("John", "Doe", &visitDate)},
+				{Line: 2, Err: errors.New(`invalid import row: parsing time "visitDate" as "2006-01-02": cannot parse "visitDate" as "2006"`)},
+			},
+		},
+		{
+			name:   "csv without header reports bad rows",
+			input:  "John,Doe,2026-01-05\nJane,Doe\n,Doe,2026-01-05\nJane,Doe,05-01-2026\n",
+			format: FormatCSV,
+			want: []domain.ImportRow{
+				{Line: 1, Appointment: domain.NewAppointment("John", "Doe", &visitDate)},
+				{Line: 2, Err: errors.New("invalid import row: expected 3 columns, got 2")},
+				{Line: 3, Err: errors.New("invalid import row: firstName and lastName are required")},
+				{Line: 4, Err: errors.New(`invalid import row: parsing time "05-01-2026" as "2006-01-02": cannot parse "05-01-2026" as "2006"`)},
+			},
+		},
+		{
+			name:   "ndjson skips blank lines",
+			input:  "{\"firstName\":\"John\",\"lastName\":\"Doe\",\"visitDate\":\"2026-01-05\"}\n\n{\"firstName\":\"Jane\"\n",
+			format: FormatNDJSON,
+			want: []domain.ImportRow{
+				{Line: 1, Appointment: domain.NewAppointment("John", "Doe", &visitDate)},
+				{Line: 3, Err: errors.New("invalid import row: unexpected end of JSON input")},
+			},
+		},
+		{
+			name:   "names are limited by characters not bytes",
+			input:  strings.Repeat("é", 50) + ",Doe,2026-01-05\n" + strings.Repeat("é", 51) + ",Doe,2026-01-05\n",
+			format: FormatCSV,
+			want: []domain.ImportRow{
+				{Line: 1, Appointment: domain.NewAppointment(strings.Repeat("é", 50), "Doe", &visitDate)},
+				{Line: 2, Err: errors.New("invalid import row: firstName and lastName must be at most 50 characters")},
+			},
+		},
+		{
+			name:    "unsupported format",
+			format:  "xml",
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "too many rows",
+			input:   strings.Repeat("John,Doe,2026-01-05\n", MaxRows+1),
+			format:  FormatCSV,
+			wantErr: ErrTooManyRows,
+		},
+		{
+			name:    "malformed rows count toward the limit",
+			input:   strings.Repeat("John,Doe,2026-01-05\n", MaxRows) + "\"John,Doe,2026-01-05\n",
+			format:  FormatCSV,
+			wantErr: ErrTooManyRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input), tt.format)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				require.Equal(t, tt.want[i].Line, got[i].Line)
+				require.Equal(t, tt.want[i].Appointment, got[i].Appointment)
+				if tt.want[i].Err != nil {
+					require.EqualError(t, got[i].Err, tt.want[i].Err.Error())
+					require.ErrorIs(t, got[i].Err, domain.ErrInvalidImportRow)
+				} else {
+					require.NoError(t, got[i].Err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/jcooney/appts/domain"
+)
+
+// rejectionReasons labels the reasons a booking is refused, anything else is counted as "error".
+var rejectionReasons = map[error]string{
+	domain.ErrAppointmentInPast:          "past",
+	domain.ErrAppointmentOnPublicHoliday: "holiday",
+	domain.ErrAppointmentDateTaken:       "taken",
+	domain.ErrHoldNotFound:               "hold-not-found",
+	domain.ErrPatientBookingLimit:        "patient-limit",
+}
+
+// Booked implements domain.BookingObserver.
+func (m *Metrics) Booked(_ *domain.Appointment) {
+	m.bookings.Inc()
+}
+
+// Rejected implements domain.BookingObserver.
+func (m *Metrics) Rejected(err error) {
+	reason, ok := rejectionReasons[err]
+	if !ok {
+		reason = "error"
+	}
+	m.rejections.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+type holidayChecker struct {
+	next    domain.PublicHolidayChecker
+	metrics *Metrics
+}
+
+// HolidayChecker times every lookup made through next and counts the ones that fail.
+func (m *Metrics) HolidayChecker(next domain.PublicHolidayChecker) domain.PublicHolidayChecker {
+	return &holidayChecker{next: next, metrics: m}
+}
+
+func (h *holidayChecker) IsPublicHoliday(ctx context.Context, date *time.Time) (bool, error) {
+	start := time.Now()
+	holiday, err := h.next.IsPublicHoliday(ctx, date)
+	h.metrics.holidayLookups.Observe(time.Since(start).Seconds())
+	if err != nil {
+		h.metrics.holidayErrors.Inc()
+	}
+	return holiday, err
+}
@@ -0,0 +1,79 @@
+// Package metrics exposes the service's Prometheus metrics: http requests per route, booking outcomes, public holiday
+// lookups and database pool stats.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "appts"
+
+type Metrics struct {
+	registry       *prometheus.Registry
+	requests       *prometheus.HistogramVec
+	bookings       prometheus.Counter
+	rejections     *prometheus.CounterVec
+	holidayLookups prometheus.Histogram
+	holidayErrors  prometheus.Counter
+}
+
+// New registers the service's metrics, and the Go runtime and process collectors, in a registry of their own.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of http requests by method, chi route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "code"}),
+		bookings: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bookings_created_total",
+			Help:      "Appointments booked, including each occurrence of a series and imported rows.",
+		}),
+		rejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bookings_rejected_total",
+			Help:      "Booking attempts refused, by reason.",
+		}, []string{"reason"}),
+		holidayLookups: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "holiday_lookup_duration_seconds",
+			Help:      "Duration of public holiday lookups against nager.at.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		holidayErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "holiday_lookup_errors_total",
+			Help:      "Public holiday lookups that failed.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.bookings,
+		m.rejections,
+		m.holidayLookups,
+		m.holidayErrors,
+	)
+	for _, reason := range rejectionReasons {
+		m.rejections.WithLabelValues(reason) // export zeros so rates work before the first rejection
+	}
+	return m
+}
+
+// Register adds further collectors, e.g. the database pool's.
+func (m *Metrics) Register(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
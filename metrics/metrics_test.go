@@ -0,0 +1,109 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareLabelsByRoutePattern(t *testing.T) {
+	m := metrics.New()
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/appts/{id}/history", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/metrics", m.Handler().ServeHTTP)
+
+	for _, path := range []string{"/appts/1/history", "/appts/2/history", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, r)
+	require.Contains(t, body, `appts_http_request_duration_seconds_count{code="404",method="GET",route="/appts/{id}/history"} 2`)
+	require.Contains(t, body, `appts_http_request_duration_seconds_count{code="404",method="GET",route="unmatched"} 1`)
+	require.NotContains(t, body, `route="/appts/1/history"`)
+}
+
+func TestBookingOutcomes(t *testing.T) {
+	m := metrics.New()
+	m.Booked(&domain.Appointment{})
+	m.Booked(&domain.Appointment{})
+	m.Rejected(domain.ErrAppointmentInPast)
+	m.Rejected(domain.ErrAppointmentOnPublicHoliday)
+	m.Rejected(domain.ErrAppointmentDateTaken)
+	m.Rejected(domain.ErrAppointmentDateTaken)
+	m.Rejected(errors.New("boom"))
+
+	body := scrape(t, m.Handler())
+	for _, want := range []string{
+		`appts_bookings_created_total 2`,
+		`appts_bookings_rejected_total{reason="past"} 1`,
+		`appts_bookings_rejected_total{reason="holiday"} 1`,
+		`appts_bookings_rejected_total{reason="taken"} 2`,
+		`appts_bookings_rejected_total{reason="patient-limit"} 0`,
+		`appts_bookings_rejected_total{reason="error"} 1`,
+	} {
+		require.Contains(t, body, want)
+	}
+}
+
+func TestHolidayChecker(t *testing.T) {
+	m := metrics.New()
+	checker := m.HolidayChecker(holidays{err: errors.New("dial tcp: no such host")})
+	_, err := checker.IsPublicHoliday(t.Context(), &time.Time{})
+	require.EqualError(t, err, "dial tcp: no such host")
+	checker = m.HolidayChecker(holidays{holiday: true})
+	holiday, err := checker.IsPublicHoliday(t.Context(), &time.Time{})
+	require.NoError(t, err)
+	require.True(t, holiday)
+
+	body := scrape(t, m.Handler())
+	require.Contains(t, body, `appts_holiday_lookup_duration_seconds_count 2`)
+	require.Contains(t, body, `appts_holiday_lookup_errors_total 1`)
+}
+
+func TestPoolCollector(t *testing.T) {
+	pool, err := pgxpool.New(t.Context(), "postgres://appt_user@localhost:1/tabeo?pool_max_conns=3") // connects lazily
+	require.NoError(t, err)
+	defer pool.Close()
+
+	collector := metrics.NewPoolCollector(pool)
+	require.Equal(t, 8, testutil.CollectAndCount(collector))
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP appts_db_pool_acquired_connections Connections currently in use.
+# TYPE appts_db_pool_acquired_connections gauge
+appts_db_pool_acquired_connections 0
+# HELP appts_db_pool_max_connections Most connections the pool will open.
+# TYPE appts_db_pool_max_connections gauge
+appts_db_pool_max_connections 3
+`), "appts_db_pool_acquired_connections", "appts_db_pool_max_connections"))
+}
+
+func scrape(t *testing.T, h http.Handler) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+type holidays struct {
+	holiday bool
+	err     error
+}
+
+func (h holidays) IsPublicHoliday(_ context.Context, _ *time.Time) (bool, error) {
+	return h.holiday, h.err
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Pool is satisfied by *pgxpool.Pool.
+type Pool interface {
+	Stat() *pgxpool.Stat
+}
+
+type poolCollector struct {
+	pool Pool
+
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	totalConns      *prometheus.Desc
+	maxConns        *prometheus.Desc
+	acquires        *prometheus.Desc
+	emptyAcquires   *prometheus.Desc
+	canceledAcquire *prometheus.Desc
+	acquireSeconds  *prometheus.Desc
+}
+
+// NewPoolCollector reports pgxpool stats each time the metrics are scraped.
+func NewPoolCollector(pool Pool) prometheus.Collector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		pool:            pool,
+		acquiredConns:   desc("acquired_connections", "Connections currently in use."),
+		idleConns:       desc("idle_connections", "Connections currently idle."),
+		totalConns:      desc("total_connections", "Connections currently open, including those being established."),
+		maxConns:        desc("max_connections", "Most connections the pool will open."),
+		acquires:        desc("acquires_total", "Connections acquired from the pool."),
+		emptyAcquires:   desc("empty_acquires_total", "Acquires that had to wait because the pool had no idle connection."),
+		canceledAcquire: desc("canceled_acquires_total", "Acquires cancelled by their context."),
+		acquireSeconds:  desc("acquire_seconds_total", "Time spent waiting to acquire connections."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquires
+	ch <- c.emptyAcquires
+	ch <- c.canceledAcquire
+	ch <- c.acquireSeconds
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireSeconds, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
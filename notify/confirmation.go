@@ -0,0 +1,307 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/webhook"
+)
+
+const (
+	subjectTemplate = "confirmation.subject.tmpl"
+	textTemplate    = "confirmation.txt.tmpl"
+	htmlTemplate    = "confirmation.html.tmpl"
+)
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// ConfirmationData is what the confirmation templates are executed with.
+type ConfirmationData struct {
+	ID          uuid.UUID // the appointment's public ID, used as the booking reference
+	FirstName   string
+	LastName    string
+	VisitDate   time.Time // start of the visit day in the clinic's time zone
+	Location    *domain.Location
+	CancelURL   string
+	CalendarURL string
+}
+
+// ConfirmationTemplates renders the subject, plain text and HTML bodies of booking confirmations.
+type ConfirmationTemplates struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// LoadConfirmationTemplates uses any of confirmation.subject.tmpl, confirmation.txt.tmpl and confirmation.html.tmpl
+// found in dir in place of the built in ones, and any found in the subdirectory of dir named after location, such as
+// high-street-clinic for "High Street Clinic", in place of those, letting each location word its emails its own way.
+// An empty dir means the built in templates.
+func LoadConfirmationTemplates(dir string, location *domain.Location) (*ConfirmationTemplates, error) {
+	var dirs []string
+	if dir != "" {
+		if location != nil && location.Name != "" {
+			dirs = append(dirs, filepath.Join(dir, LocationDir(location.Name)))
+		}
+		dirs = append(dirs, dir)
+	}
+	read := func(name string) (string, error) {
+		for _, dir := range dirs {
+			b, err := os.ReadFile(filepath.Join(dir, name))
+			if err == nil {
+				return string(b), nil
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				return "", fmt.Errorf("read %s: %w", name, err)
+			}
+		}
+		b, err := defaultTemplates.ReadFile("templates/" + name)
+		if err != nil {
+			return "", fmt.Errorf("read default %s: %w", name, err)
+		}
+		return string(b), nil
+	}
+
+	var t ConfirmationTemplates
+	src, err := read(subjectTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if t.subject, err = texttemplate.New(subjectTemplate).Option("missingkey=error").Parse(src); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", subjectTemplate, err)
+	}
+	if src, err = read(textTemplate); err != nil {
+		return nil, err
+	}
+	if t.text, err = texttemplate.New(textTemplate).Option("missingkey=error").Parse(src); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", textTemplate, err)
+	}
+	if src, err = read(htmlTemplate); err != nil {
+		return nil, err
+	}
+	if t.html, err = htmltemplate.New(htmlTemplate).Option("missingkey=error").Parse(src); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", htmlTemplate, err)
+	}
+	return &t, nil
+}
+
+// LocationDir is the name of the directory holding a location's own templates: its name in lower case with every run
+// of other characters than letters and digits replaced by a single dash.
+func LocationDir(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// Render returns the subject, plain text body and HTML body for data.
+func (t *ConfirmationTemplates) Render(data *ConfirmationData) (string, string, string, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return "", "", "", fmt.Errorf("render subject: %w", err)
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return "", "", "", fmt.Errorf("render text: %w", err)
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return "", "", "", fmt.Errorf("render html: %w", err)
+	}
+	return strings.TrimSpace(subject.String()), text.String(), html.String(), nil
+}
+
+type AppointmentGetter interface {
+	GetAppointment(ctx context.Context, id int32) (*domain.Appointment, error)
+}
+
+// Links builds the absolute URLs put in confirmation emails. CancelURL may issue a credential, such as a manage token,
+// so it can fail.
+type Links interface {
+	CancelURL(ctx context.Context, appt *domain.Appointment) (string, error)
+	CalendarURL(appt *domain.Appointment) string
+}
+
+// MaxConfirmationAttempts is how many times a confirmation is tried before it is given up on.
+const MaxConfirmationAttempts = 8
+
+// ConfirmationQueueStore queues a confirmation of the appointment an event is about.
+type ConfirmationQueueStore interface {
+	EnqueueConfirmationEmail(ctx context.Context, event *domain.Event) error
+}
+
+// ConfirmationQueue is the domain.EventPublisher the outbox relay hands events to. It only queues a confirmation of
+// each new booking, Confirmations sends them, so a slow or failing mail server cannot hold up the outbox.
+type ConfirmationQueue struct {
+	store ConfirmationQueueStore
+}
+
+func NewConfirmationQueue(store ConfirmationQueueStore) *ConfirmationQueue {
+	return &ConfirmationQueue{store: store}
+}
+
+func (q *ConfirmationQueue) Publish(ctx context.Context, event *domain.Event) error {
+	if event.Type != domain.EventAppointmentCreated {
+		return nil
+	}
+	return q.store.EnqueueConfirmationEmail(ctx, event)
+}
+
+// ConfirmationStore claims due confirmations and records how each attempt went.
+type ConfirmationStore interface {
+	ClaimDueConfirmationEmails(ctx context.Context, limit int32, lease time.Duration) ([]*domain.ConfirmationEmail, error)
+	RecordConfirmationAttempt(ctx context.Context, email *domain.ConfirmationEmail) error
+}
+
+// Confirmations emails patients the confirmations queued by ConfirmationQueue, retrying failures with the same backoff
+// as webhook deliveries until MaxConfirmationAttempts is reached. Addresses the mail server permanently rejects are
+// given up on straight away.
+type Confirmations struct {
+	store     ConfirmationStore
+	appts     AppointmentGetter
+	email     *Email
+	templates *ConfirmationTemplates
+	location  *domain.Location
+	links     Links
+	interval  time.Duration
+	batchSize int32
+	nowFunc   func() time.Time
+}
+
+// NewConfirmations polls store every interval.
+func NewConfirmations(store ConfirmationStore, appts AppointmentGetter, email *Email, templates *ConfirmationTemplates, location *domain.Location, links Links, interval time.Duration, batchSize int32, nowFunc func() time.Time) *Confirmations {
+	return &Confirmations{
+		store:     store,
+		appts:     appts,
+		email:     email,
+		templates: templates,
+		location:  location,
+		links:     links,
+		interval:  interval,
+		batchSize: batchSize,
+		nowFunc:   nowFunc,
+	}
+}
+
+// Run polls for due confirmations every interval until ctx is cancelled.
+func (c *Confirmations) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain attempts full batches back to back until nothing is due.
+func (c *Confirmations) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := c.store.ClaimDueConfirmationEmails(ctx, c.batchSize, c.lease())
+		if err != nil {
+			slog.Error("error claiming confirmation emails", "error", err)
+			return
+		}
+		for _, email := range due {
+			c.attempt(ctx, email)
+			if err := c.store.RecordConfirmationAttempt(ctx, email); err != nil {
+				slog.Error("error recording confirmation email attempt", "confirmation", email.ID, "error", err)
+			}
+		}
+		if len(due) < int(c.batchSize) {
+			return
+		}
+	}
+}
+
+// lease covers every send in a batch timing out, so a claim outlives the sender working through it.
+func (c *Confirmations) lease() time.Duration {
+	return time.Duration(c.batchSize)*c.email.timeout + time.Minute
+}
+
+// attempt sends the confirmation once and updates it with the outcome.
+func (c *Confirmations) attempt(ctx context.Context, email *domain.ConfirmationEmail) {
+	email.Attempts++
+	err := c.send(ctx, email.AppointmentID)
+	if err == nil {
+		email.Status = domain.ConfirmationEmailSent
+		email.LastError = ""
+		return
+	}
+
+	email.LastError = err.Error()
+	if permanent(err) {
+		email.Status = domain.ConfirmationEmailDead
+		slog.Warn("confirmation email rejected", "appointmentID", email.AppointmentID, "error", err)
+		return
+	}
+	if email.Attempts >= MaxConfirmationAttempts {
+		email.Status = domain.ConfirmationEmailDead
+		slog.Warn("confirmation email given up", "appointmentID", email.AppointmentID, "error", err)
+		return
+	}
+	email.NextAttemptAt = c.nowFunc().Add(webhook.Backoff(email.Attempts))
+}
+
+// permanent reports whether retrying cannot fix err: the mail server rejecting the address, or there being nowhere to
+// send the confirmation.
+func permanent(err error) bool {
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 500
+	}
+	return errors.Is(err, domain.ErrAppointmentNotFound) || errors.Is(err, domain.ErrNoContactDetails)
+}
+
+func (c *Confirmations) send(ctx context.Context, appointmentID int32) error {
+	appt, err := c.appts.GetAppointment(ctx, appointmentID)
+	if err != nil {
+		return fmt.Errorf("get appointment: %w", err)
+	}
+	if appt.Email == "" {
+		return domain.ErrNoContactDetails
+	}
+	cancelURL, err := c.links.CancelURL(ctx, appt)
+	if err != nil {
+		return fmt.Errorf("cancel url: %w", err)
+	}
+	subject, text, html, err := c.templates.Render(&ConfirmationData{
+		ID:          appt.PublicID,
+		FirstName:   appt.FirstName,
+		LastName:    appt.LastName,
+		VisitDate:   domain.VisitStart(*appt.VisitDate, c.location.TimeZone),
+		Location:    c.location,
+		CancelURL:   cancelURL,
+		CalendarURL: c.links.CalendarURL(appt),
+	})
+	if err != nil {
+		return err
+	}
+	return c.email.sendAlternative(ctx, appt.Email, subject, text, html)
+}
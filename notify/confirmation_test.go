@@ -0,0 +1,311 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testConfirmationData() *ConfirmationData {
+	return &ConfirmationData{
+		ID:          uuid.MustParse("019b8e2a-6c00-7000-8000-000000000007"),
+		FirstName:   "Jane",
+		LastName:    "<Doe>",
+		VisitDate:   time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		Location:    &domain.Location{Name: "Smile Dental", Address: "1 High Street"},
+		CancelURL:   "https://appts.example.com/appts/7/cancel",
+		CalendarURL: "https://appts.example.com/appts/7.ics",
+	}
+}
+
+func TestConfirmationTemplates_Render(t *testing.T) {
+	templates, err := LoadConfirmationTemplates("", nil)
+	require.NoError(t, err)
+
+	subject, text, html, err := templates.Render(testConfirmationData())
+	require.NoError(t, err)
+	require.Equal(t, "Your appointment on Monday 5 January 2026 is confirmed", subject)
+	require.Contains(t, text, "Your appointment is booked for Monday 5 January 2026 at Smile Dental, 1 High Street.")
+	require.Contains(t, text, "Name: Jane <Doe>")
+	require.Contains(t, text, "Cancel here: https://appts.example.com/appts/7/cancel")
+	require.Contains(t, html, "Jane &lt;Doe&gt;", "html is escaped")
+	require.Contains(t, html, `<a href="https://appts.example.com/appts/7/cancel">`)
+	require.Contains(t, html, `<a href="https://appts.example.com/appts/7.ics">`)
+}
+
+func TestLoadConfirmationTemplates_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, subjectTemplate), []byte("See you soon {{.FirstName}}"), 0o600))
+
+	templates, err := LoadConfirmationTemplates(dir, nil)
+	require.NoError(t, err)
+	subject, text, _, err := templates.Render(testConfirmationData())
+	require.NoError(t, err)
+	require.Equal(t, "See you soon Jane", subject)
+	require.Contains(t, text, "Booking reference: 019b8e2a-6c00-7000-8000-000000000007", "templates that are not overridden use the defaults")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, textTemplate), []byte("{{.Nope}}"), 0o600))
+	templates, err = LoadConfirmationTemplates(dir, nil)
+	require.NoError(t, err)
+	_, _, _, err = templates.Render(testConfirmationData())
+	require.ErrorContains(t, err, "render text")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, htmlTemplate), []byte("{{.FirstName"), 0o600))
+	_, err = LoadConfirmationTemplates(dir, nil)
+	require.ErrorContains(t, err, "parse confirmation.html.tmpl")
+}
+
+func TestLoadConfirmationTemplates_PerLocation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, subjectTemplate), []byte("See you soon {{.FirstName}}"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "smile-dental"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "smile-dental", subjectTemplate), []byte("Smile, {{.FirstName}}!"), 0o600))
+
+	tests := []struct {
+		name        string
+		location    *domain.Location
+		wantSubject string
+	}{
+		{
+			name:        "location's own template",
+			location:    &domain.Location{Name: "Smile Dental"},
+			wantSubject: "Smile, Jane!",
+		},
+		{
+			name:        "shared template for a location without its own",
+			location:    &domain.Location{Name: "High Street Clinic"},
+			wantSubject: "See you soon Jane",
+		},
+		{
+			name:        "shared template without a location",
+			wantSubject: "See you soon Jane",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := LoadConfirmationTemplates(dir, tt.location)
+			require.NoError(t, err)
+			subject, text, _, err := templates.Render(testConfirmationData())
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSubject, subject)
+			require.Contains(t, text, "Booking reference: 019b8e2a-6c00-7000-8000-000000000007")
+		})
+	}
+}
+
+func TestLocationDir(t *testing.T) {
+	require.Equal(t, "smile-dental", LocationDir("Smile Dental"))
+	require.Equal(t, "st-mary-s-clinic-2", LocationDir("  St. Mary's Clinic #2 "))
+	require.Equal(t, "zürich-praxis", LocationDir("Zürich Praxis"))
+}
+
+func TestConfirmationQueue_Publish(t *testing.T) {
+	store := &fakeConfirmationQueue{}
+	underTest := NewConfirmationQueue(store)
+
+	require.NoError(t, underTest.Publish(t.Context(), &domain.Event{ID: 1, Type: domain.EventAppointmentCreated, AppointmentID: 7}))
+	require.NoError(t, underTest.Publish(t.Context(), &domain.Event{ID: 2, Type: domain.EventAppointmentCancelled, AppointmentID: 7}))
+	require.Equal(t, []int64{1}, store.queued, "only new bookings are confirmed")
+}
+
+func TestConfirmations_Attempt(t *testing.T) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	withEmail := domain.NewAppointment("Jane", "Doe", ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)))
+	withEmail.ID = 7
+	withEmail.Email = "jane@example.com"
+	withoutEmail := domain.NewAppointment("John", "Doe", ptr.To(time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)))
+	withoutEmail.ID = 8
+	tests := []struct {
+		name          string
+		appointmentID int32
+		attempts      int32
+		rejectRcpt    bool
+		want          domain.ConfirmationEmail
+		wantSent      int
+	}{
+		{
+			name:          "emails the patient",
+			appointmentID: 7,
+			want:          domain.ConfirmationEmail{Status: domain.ConfirmationEmailSent, Attempts: 1},
+			wantSent:      1,
+		},
+		{
+			name:          "retries with backoff when the appointment cannot be loaded",
+			appointmentID: 10,
+			want:          domain.ConfirmationEmail{Status: domain.ConfirmationEmailPending, Attempts: 1, NextAttemptAt: now.Add(30 * time.Second), LastError: "get appointment: connection reset"},
+		},
+		{
+			name:          "gives up after the last attempt",
+			appointmentID: 10,
+			attempts:      MaxConfirmationAttempts - 1,
+			want:          domain.ConfirmationEmail{Status: domain.ConfirmationEmailDead, Attempts: MaxConfirmationAttempts, LastError: "get appointment: connection reset"},
+		},
+		{
+			name:          "gives up on addresses the server permanently rejects",
+			appointmentID: 7,
+			rejectRcpt:    true,
+			want:          domain.ConfirmationEmail{Status: domain.ConfirmationEmailDead, Attempts: 1, LastError: `send mail: 550 "no such user"`},
+		},
+		{
+			name:          "gives up on appointments without an email address",
+			appointmentID: 8,
+			want:          domain.ConfirmationEmail{Status: domain.ConfirmationEmailDead, Attempts: 1, LastError: domain.ErrNoContactDetails.Error()},
+		},
+		{
+			name:          "gives up on appointments that no longer exist",
+			appointmentID: 9,
+			want:          domain.ConfirmationEmail{Status: domain.ConfirmationEmailDead, Attempts: 1, LastError: "get appointment: appointment not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t)
+			server.mu.Lock()
+			server.rejectRcpt = tt.rejectRcpt
+			server.mu.Unlock()
+			templates, err := LoadConfirmationTemplates("", nil)
+			require.NoError(t, err)
+			email := &domain.ConfirmationEmail{ID: 1, AppointmentID: tt.appointmentID, Status: domain.ConfirmationEmailPending, Attempts: tt.attempts}
+			store := &fakeConfirmationStore{due: []*domain.ConfirmationEmail{email}}
+			underTest := NewConfirmations(store, fakeAppointments{7: withEmail, 8: withoutEmail}, NewEmail(server.addr, "clinic@example.com", nil, time.Second), templates, &domain.Location{Name: "Smile Dental", TimeZone: time.UTC}, fakeLinks{}, time.Minute, 10, func() time.Time { return now })
+
+			underTest.drain(t.Context())
+
+			require.Len(t, store.recorded, 1)
+			tt.want.ID = 1
+			tt.want.AppointmentID = tt.appointmentID
+			require.Equal(t, tt.want, *store.recorded[0])
+			require.Len(t, server.received(), tt.wantSent)
+		})
+	}
+}
+
+func TestConfirmations_AttemptTimesOut(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	hung := make(chan struct{})
+	defer close(hung)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		<-hung // never greet, like a hung server
+		_ = conn.Close()
+	}()
+	templates, err := LoadConfirmationTemplates("", nil)
+	require.NoError(t, err)
+	appt := domain.NewAppointment("Jane", "Doe", ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)))
+	appt.ID = 7
+	appt.Email = "jane@example.com"
+	store := &fakeConfirmationStore{due: []*domain.ConfirmationEmail{{ID: 1, AppointmentID: 7, Status: domain.ConfirmationEmailPending}}}
+	underTest := NewConfirmations(store, fakeAppointments{7: appt}, NewEmail(l.Addr().String(), "clinic@example.com", nil, 50*time.Millisecond), templates, &domain.Location{TimeZone: time.UTC}, fakeLinks{}, time.Minute, 10, time.Now)
+
+	start := time.Now()
+	underTest.drain(t.Context())
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Len(t, store.recorded, 1)
+	require.Equal(t, domain.ConfirmationEmailPending, store.recorded[0].Status, "a hung server is retried")
+	require.Contains(t, store.recorded[0].LastError, "i/o timeout")
+}
+
+func TestConfirmations_Multipart(t *testing.T) {
+	server := newFakeSMTP(t)
+	templates, err := LoadConfirmationTemplates("", nil)
+	require.NoError(t, err)
+	appt := domain.NewAppointment("Jane", "Doe", ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)))
+	appt.ID = 7
+	appt.Email = "jane@example.com"
+	store := &fakeConfirmationStore{due: []*domain.ConfirmationEmail{{ID: 1, AppointmentID: 7, Status: domain.ConfirmationEmailPending}}}
+	underTest := NewConfirmations(store, fakeAppointments{7: appt}, NewEmail(server.addr, "clinic@example.com", nil, time.Second), templates, &domain.Location{TimeZone: time.UTC}, fakeLinks{}, time.Minute, 10, time.Now)
+	underTest.drain(t.Context())
+	received := server.received()
+	require.Len(t, received, 1)
+	msg, err := mail.ReadMessage(strings.NewReader(received[0].Data))
+	require.NoError(t, err)
+	require.Equal(t, "Your appointment on Monday 5 January 2026 is confirmed", msg.Header.Get("Subject"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part) // decodes quoted-printable
+		require.NoError(t, err)
+		require.Contains(t, string(body), "https://appts.example.com/appts/7/cancel")
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	require.Equal(t, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}, types)
+}
+
+type fakeConfirmationQueue struct {
+	queued []int64
+}
+
+func (f *fakeConfirmationQueue) EnqueueConfirmationEmail(_ context.Context, event *domain.Event) error {
+	f.queued = append(f.queued, event.ID)
+	return nil
+}
+
+// fakeConfirmationStore hands out its due confirmations once.
+type fakeConfirmationStore struct {
+	due      []*domain.ConfirmationEmail
+	recorded []*domain.ConfirmationEmail
+}
+
+func (f *fakeConfirmationStore) ClaimDueConfirmationEmails(_ context.Context, limit int32, _ time.Duration) ([]*domain.ConfirmationEmail, error) {
+	n := min(len(f.due), int(limit))
+	due := f.due[:n]
+	f.due = f.due[n:]
+	return due, nil
+}
+
+func (f *fakeConfirmationStore) RecordConfirmationAttempt(_ context.Context, email *domain.ConfirmationEmail) error {
+	copied := *email
+	f.recorded = append(f.recorded, &copied)
+	return nil
+}
+
+type fakeAppointments map[int32]*domain.Appointment
+
+func (f fakeAppointments) GetAppointment(_ context.Context, id int32) (*domain.Appointment, error) {
+	if id == 10 {
+		return nil, fmt.Errorf("connection reset")
+	}
+	appt, ok := f[id]
+	if !ok {
+		return nil, domain.ErrAppointmentNotFound
+	}
+	return appt, nil
+}
+
+type fakeLinks struct{}
+
+func (fakeLinks) CancelURL(_ context.Context, appt *domain.Appointment) (string, error) {
+	return fmt.Sprintf("https://appts.example.com/appts/%d/cancel", appt.ID), nil
+}
+
+func (fakeLinks) CalendarURL(appt *domain.Appointment) string {
+	return fmt.Sprintf("https://appts.example.com/appts/%d.ics", appt.ID)
+}
@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// Email sends reminders over SMTP to the appointment's email address.
+type Email struct {
+	addr    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+// NewEmail sends through the SMTP server at addr (host:port). auth may be nil for servers that accept unauthenticated
+// mail, such as a local relay. STARTTLS is used whenever the server offers it. timeout bounds each message from dialing
+// the server to it accepting the message, and must be set.
+func NewEmail(addr string, from string, auth smtp.Auth, timeout time.Duration) *Email {
+	return &Email{addr: addr, from: from, auth: auth, timeout: timeout}
+}
+
+func (e *Email) Channel() string {
+	return "email"
+}
+
+func (e *Email) Notify(ctx context.Context, reminder *domain.Reminder) error {
+	to := reminder.Appointment.Email
+	if to == "" {
+		return domain.ErrNoContactDetails
+	}
+	return e.send(ctx, to, reminderSubject, "text/plain; charset=utf-8", []byte(reminderText(reminder)+"\r\n"))
+}
+
+// sendAlternative sends text and html as alternative parts of the same message, letting the mail client pick.
+func (e *Email) sendAlternative(ctx context.Context, to string, subject string, text string, html string) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return fmt.Errorf("create part: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return fmt.Errorf("write part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return fmt.Errorf("write part: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("close multipart: %w", err)
+	}
+	return e.send(ctx, to, subject, "multipart/alternative; boundary="+mw.Boundary(), body.Bytes())
+}
+
+// send delivers a single part message. net/smtp takes no context, so the connection's deadline is what stops a hung
+// server from holding up the caller past ctx or the timeout.
+func (e *Email) send(ctx context.Context, to string, subject string, contentType string, body []byte) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&msg, "\r\n")
+	msg.Write(body)
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("send mail: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now()) // unblock any read or write when ctx is cancelled early
+	})
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(e.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("send mail: %w", err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	if err := transact(client, host, e.auth, e.from, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// transact is smtp.SendMail on an already connected client.
+func transact(client *smtp.Client, host string, auth smtp.Auth, from string, to string, msg []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// Log writes reminders to the log instead of sending them, for local development.
+type Log struct{}
+
+func (Log) Channel() string {
+	return "log"
+}
+
+func (Log) Notify(_ context.Context, reminder *domain.Reminder) error {
+	slog.Info("reminder", "appointmentID", reminder.Appointment.ID, "lead", reminder.Lead, "text", reminderText(reminder))
+	return nil
+}
@@ -0,0 +1,21 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jcooney/appts/domain"
+)
+
+const reminderSubject = "Appointment reminder"
+
+// reminderText is the plain text sent to patients, short enough for a single SMS in most cases.
+func reminderText(reminder *domain.Reminder) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hi %s, this is a reminder of your appointment", reminder.Appointment.FirstName)
+	if reminder.Location != nil && reminder.Location.String() != "" {
+		fmt.Fprintf(&b, " at %s", reminder.Location)
+	}
+	fmt.Fprintf(&b, " on %s.", reminder.VisitStart.Format("Monday 2 January 2006"))
+	return b.String()
+}
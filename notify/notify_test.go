@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testReminder(email string, phone string) *domain.Reminder {
+	appt := domain.NewAppointment("Jane", "Doe", ptr.To(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)))
+	appt.ID = 7
+	appt.Email = email
+	appt.Phone = phone
+	return &domain.Reminder{
+		Appointment: appt,
+		Lead:        24 * time.Hour,
+		VisitStart:  time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		Location:    &domain.Location{Name: "Smile Dental", Address: "1 High Street"},
+	}
+}
+
+func TestReminderText(t *testing.T) {
+	tests := []struct {
+		name     string
+		location *domain.Location
+		want     string
+	}{
+		{
+			name:     "with location",
+			location: &domain.Location{Name: "Smile Dental", Address: "1 High Street"},
+			want:     "Hi Jane, this is a reminder of your appointment at Smile Dental, 1 High Street on Monday 5 January 2026.",
+		},
+		{
+			name:     "without location",
+			location: &domain.Location{},
+			want:     "Hi Jane, this is a reminder of your appointment on Monday 5 January 2026.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminder := testReminder("", "")
+			reminder.Location = tt.location
+			require.Equal(t, tt.want, reminderText(reminder))
+		})
+	}
+}
+
+func TestEmail_Notify(t *testing.T) {
+	server := newFakeSMTP(t)
+	underTest := NewEmail(server.addr, "clinic@example.com", nil, time.Second)
+
+	require.ErrorIs(t, underTest.Notify(t.Context(), testReminder("", "+447700900123")), domain.ErrNoContactDetails)
+	require.NoError(t, underTest.Notify(t.Context(), testReminder("jane@example.com", "")))
+
+	got := server.received()
+	require.Len(t, got, 1)
+	require.Equal(t, "clinic@example.com", got[0].From)
+	require.Equal(t, []string{"jane@example.com"}, got[0].To)
+	require.Contains(t, got[0].Data, "To: jane@example.com\n")
+	require.Contains(t, got[0].Data, "Subject: Appointment reminder\n")
+	require.Contains(t, got[0].Data, "\nHi Jane, this is a reminder of your appointment at Smile Dental, 1 High Street on Monday 5 January 2026.\n")
+}
+
+func TestSMS_Notify(t *testing.T) {
+	tests := []struct {
+		name    string
+		phone   string
+		status  int
+		wantErr string
+		wantReq *SMSRequest
+	}{
+		{
+			name:    "sent",
+			phone:   "+447700900123",
+			status:  http.StatusAccepted,
+			wantReq: &SMSRequest{To: "+447700900123", Body: "Hi Jane, this is a reminder of your appointment at Smile Dental, 1 High Street on Monday 5 January 2026."},
+		},
+		{
+			name:    "no phone number",
+			wantErr: domain.ErrNoContactDetails.Error(),
+		},
+		{
+			name:    "gateway rejects",
+			phone:   "+447700900123",
+			status:  http.StatusBadRequest,
+			wantErr: "sms gateway returned 400: bad number\n",
+			wantReq: &SMSRequest{To: "+447700900123", Body: "Hi Jane, this is a reminder of your appointment at Smile Dental, 1 High Street on Monday 5 January 2026."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *SMSRequest
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				got = &SMSRequest{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(got))
+				if tt.status >= 300 {
+					http.Error(w, "bad number", tt.status)
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			err := NewSMS(ts.URL, "token", ts.Client()).Notify(t.Context(), testReminder("", tt.phone))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantReq, got)
+		})
+	}
+}
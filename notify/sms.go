@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// SMSRequest is the JSON body POSTed to the SMS gateway.
+type SMSRequest struct {
+	To   string `json:"to"` // E.164
+	Body string `json:"body"`
+}
+
+// SMS sends reminders to the appointment's phone number through an HTTP SMS gateway.
+type SMS struct {
+	gatewayURL string
+	token      string
+	client     *http.Client
+}
+
+// NewSMS posts to gatewayURL with token as a bearer token. The client's timeout bounds each send.
+func NewSMS(gatewayURL string, token string, client *http.Client) *SMS {
+	return &SMS{gatewayURL: gatewayURL, token: token, client: client}
+}
+
+func (s *SMS) Channel() string {
+	return "sms"
+}
+
+func (s *SMS) Notify(ctx context.Context, reminder *domain.Reminder) error {
+	to := reminder.Appointment.Phone
+	if to == "" {
+		return domain.ErrNoContactDetails
+	}
+	body, err := json.Marshal(SMSRequest{To: to, Body: reminderText(reminder)})
+	if err != nil {
+		return fmt.Errorf("marshal sms: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.gatewayURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send sms: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway returned %d: %s", resp.StatusCode, snippet)
+	}
+	return nil
+}
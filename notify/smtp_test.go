@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP is a MailHog style server that accepts everything and keeps the messages it receives.
+type fakeSMTP struct {
+	addr       string
+	rejectRcpt bool // reply to RCPT with a permanent failure
+	mu         sync.Mutex
+	msgs       []receivedMail
+}
+
+type receivedMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	f := &fakeSMTP{addr: l.Addr().String()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ready")
+	var mail receivedMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 localhost")
+		case "MAIL":
+			mail = receivedMail{From: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			f.mu.Lock()
+			reject := f.rejectRcpt
+			f.mu.Unlock()
+			if reject {
+				_ = tp.PrintfLine("550 no such user")
+				continue
+			}
+			mail.To = append(mail.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.Data = string(data)
+			f.mu.Lock()
+			f.msgs = append(f.msgs, mail)
+			f.mu.Unlock()
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (f *fakeSMTP) received() []receivedMail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]receivedMail(nil), f.msgs...)
+}
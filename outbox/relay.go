@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// Store hands pending outbox events to publish and marks the ones that succeed as published.
+type Store interface {
+	PublishPending(ctx context.Context, limit int32, publish func(ctx context.Context, event *domain.Event) error) (int, error)
+}
+
+// Relay moves events from the outbox to a publisher. Delivery is at-least-once, an event is retried on the next poll
+// until the publisher accepts it.
+type Relay struct {
+	store     Store
+	publisher domain.EventPublisher
+	interval  time.Duration
+	batchSize int32
+}
+
+func NewRelay(store Store, publisher domain.EventPublisher, interval time.Duration, batchSize int32) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run polls the outbox every interval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain publishes full batches back to back until the outbox is empty or publishing fails.
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := r.store.PublishPending(ctx, r.batchSize, r.publisher.Publish)
+		if err != nil {
+			slog.Error("error relaying outbox events", "published", published, "error", err)
+			return
+		}
+		if published < int(r.batchSize) {
+			return
+		}
+	}
+}
+
+// LogPublisher writes events to the log, for local development where there is nothing downstream.
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(_ context.Context, event *domain.Event) error {
+	slog.Info("published event", "id", event.ID, "type", event.Type, "appointmentID", event.AppointmentID, "payload", string(event.Payload))
+	return nil
+}
+
+// Publishers publishes each event to every publisher in turn, stopping at the first failure. The relay retries the
+// event as a whole, so every publisher after the first must tolerate seeing it again.
+type Publishers []domain.EventPublisher
+
+func (p Publishers) Publish(ctx context.Context, event *domain.Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelay_Run(t *testing.T) {
+	tests := []struct {
+		name          string
+		pending       int
+		failOn        map[int64]int // event id -> number of times publishing it fails
+		wantPublished []int64
+	}{
+		{
+			name:          "drains several batches in one poll",
+			pending:       5,
+			wantPublished: []int64{1, 2, 3, 4, 5},
+		},
+		{
+			name:          "retries a failed event on the next poll",
+			pending:       3,
+			failOn:        map[int64]int{2: 1},
+			wantPublished: []int64{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			for i := 1; i <= tt.pending; i++ {
+				store.pending = append(store.pending, &domain.Event{ID: int64(i), Type: domain.EventAppointmentCreated})
+			}
+			publisher := &fakePublisher{failOn: tt.failOn}
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				NewRelay(store, publisher, time.Millisecond, 2).Run(ctx)
+				close(done)
+			}()
+
+			require.Eventually(t, func() bool {
+				return len(publisher.got()) == len(tt.wantPublished)
+			}, time.Second, time.Millisecond)
+			cancel()
+			<-done
+			require.Equal(t, tt.wantPublished, publisher.got())
+		})
+	}
+}
+
+// fakeStore behaves like the repository: events are removed from pending only once published.
+type fakeStore struct {
+	mu      sync.Mutex
+	pending []*domain.Event
+}
+
+func (f *fakeStore) PublishPending(ctx context.Context, limit int32, publish func(ctx context.Context, event *domain.Event) error) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	published := 0
+	for published < int(limit) && published < len(f.pending) {
+		if err := publish(ctx, f.pending[published]); err != nil {
+			f.pending = f.pending[published:]
+			return published, err
+		}
+		published++
+	}
+	f.pending = f.pending[published:]
+	return published, nil
+}
+
+type fakePublisher struct {
+	mu        sync.Mutex
+	failOn    map[int64]int
+	published []int64
+}
+
+func (f *fakePublisher) Publish(_ context.Context, event *domain.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failOn[event.ID] > 0 {
+		f.failOn[event.ID]--
+		return errors.New("downstream unavailable")
+	}
+	f.published = append(f.published, event.ID)
+	return nil
+}
+
+func (f *fakePublisher) got() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int64(nil), f.published...)
+}
+
+func TestPublishers_Publish(t *testing.T) {
+	first := &fakePublisher{}
+	failing := &fakePublisher{failOn: map[int64]int{1: 1}}
+	last := &fakePublisher{}
+	underTest := Publishers{first, failing, last}
+
+	event := &domain.Event{ID: 1, Type: domain.EventAppointmentCreated}
+	require.Error(t, underTest.Publish(t.Context(), event))
+	require.Equal(t, []int64{1}, first.got())
+	require.Empty(t, last.got(), "publishers after a failure are not called")
+
+	require.NoError(t, underTest.Publish(t.Context(), event))
+	require.Equal(t, []int64{1, 1}, first.got(), "earlier publishers see a retried event again")
+	require.Equal(t, []int64{1}, last.got())
+}
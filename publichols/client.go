@@ -4,31 +4,50 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/jcooney/appts/publichols")
+
 type PublicHolidayGetter struct {
-	client *ClientWithResponses
+	client      *ClientWithResponses
+	countryCode string
 }
 
-func NewPublicHolidayGetter(host string) (*PublicHolidayGetter, error) {
-	client, err := NewClientWithResponses(host)
+// NewPublicHolidayGetter checks dates against the public holidays of countryCode, an ISO 3166-1 alpha-2 code, e.g. GB.
+func NewPublicHolidayGetter(host string, countryCode string, opts ...ClientOption) (*PublicHolidayGetter, error) {
+	client, err := NewClientWithResponses(host, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("NewClient: %w", err)
 	}
 	return &PublicHolidayGetter{
-		client: client,
+		client:      client,
+		countryCode: countryCode,
 	}, nil
 }
 
 func (g *PublicHolidayGetter) IsPublicHoliday(ctx context.Context, date *time.Time) (bool, error) {
-	publicHolidaysV3, err := g.client.PublicHolidayPublicHolidaysV3WithResponse(ctx, int32(date.Year()), "GB")
+	ctx, span := tracer.Start(ctx, "PublicHolidayPublicHolidaysV3", trace.WithAttributes(
+		attribute.Int("holiday.year", date.Year()),
+		attribute.String("holiday.country", g.countryCode),
+	))
+	defer span.End()
+
+	publicHolidaysV3, err := g.client.PublicHolidayPublicHolidaysV3WithResponse(ctx, int32(date.Year()), g.countryCode)
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return false, fmt.Errorf("PublicHolidayPublicHolidaysV3WithResponse: %w", err)
 	}
 
 	resp := publicHolidaysV3.JSON200
 	if resp == nil {
-		return false, fmt.Errorf("no response from PublicHolidayPublicHolidaysV3WithResponse: status code: %d", publicHolidaysV3.StatusCode())
+		err := fmt.Errorf("no response from PublicHolidayPublicHolidaysV3WithResponse: status code: %d", publicHolidaysV3.StatusCode())
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
 	}
 
 	for i := range *resp {
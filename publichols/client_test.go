@@ -66,7 +66,7 @@ func TestPublicHolidayGetter_IsPublicHoliday(t *testing.T) {
 			}))
 			defer server.Close()
 
-			getter, err := NewPublicHolidayGetter(server.URL)
+			getter, err := NewPublicHolidayGetter(server.URL, "GB")
 			require.NoError(t, err)
 			isPublicHoliday, err := getter.IsPublicHoliday(t.Context(), ptr.To(tt.date))
 			if tt.wantErr != nil {
@@ -110,7 +110,7 @@ func TestPublicHolidayGetter_IsPublicHolidayLiveAPI(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			getter, err := NewPublicHolidayGetter("https://date.nager.at")
+			getter, err := NewPublicHolidayGetter("https://date.nager.at", "GB")
 			require.NoError(t, err)
 			isPublicHoliday, err := getter.IsPublicHoliday(t.Context(), ptr.To(tt.date))
 			if tt.err != nil {
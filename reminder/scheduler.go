@@ -0,0 +1,113 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// Store finds appointments due a reminder and records which reminders have gone out.
+type Store interface {
+	ListReminderCandidates(ctx context.Context, from time.Time, to time.Time, lead time.Duration, channel string) ([]*domain.Appointment, error)
+	ClaimReminder(ctx context.Context, appointmentID int32, lead time.Duration, channel string) (bool, error)
+	ReleaseReminder(ctx context.Context, appointmentID int32, lead time.Duration, channel string) error
+}
+
+// Scheduler sends a reminder through every notifier for each lead time before a visit. Reminders are claimed in the
+// store before they are sent, so they go out at most once: a crash mid-send loses the reminder rather than repeating
+// it. Failed sends are released and retried on the next tick.
+type Scheduler struct {
+	store     Store
+	notifiers []domain.Notifier
+	leads     []time.Duration // longest first
+	location  *domain.Location
+	interval  time.Duration
+	nowFunc   func() time.Time
+}
+
+func NewScheduler(store Store, notifiers []domain.Notifier, leads []time.Duration, location *domain.Location, interval time.Duration, nowFunc func() time.Time) *Scheduler {
+	leads = slices.Clone(leads)
+	slices.Sort(leads)
+	slices.Reverse(leads)
+	return &Scheduler{
+		store:     store,
+		notifiers: notifiers,
+		leads:     slices.Compact(leads),
+		location:  location,
+		interval:  interval,
+		nowFunc:   nowFunc,
+	}
+}
+
+// Run checks for due reminders every interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.nowFunc()
+	for i, lead := range s.leads {
+		var shorter time.Duration
+		if i+1 < len(s.leads) {
+			shorter = s.leads[i+1]
+		}
+		from, to, ok := domain.ReminderWindow(now, shorter, lead, s.location.TimeZone)
+		if !ok {
+			continue
+		}
+		for _, notifier := range s.notifiers {
+			if ctx.Err() != nil {
+				return
+			}
+			s.remind(ctx, notifier, from, to, lead)
+		}
+	}
+}
+
+func (s *Scheduler) remind(ctx context.Context, notifier domain.Notifier, from time.Time, to time.Time, lead time.Duration) {
+	channel := notifier.Channel()
+	appts, err := s.store.ListReminderCandidates(ctx, from, to, lead, channel)
+	if err != nil {
+		slog.Error("error listing reminder candidates", "channel", channel, "lead", lead, "error", err)
+		return
+	}
+	for _, appt := range appts {
+		claimed, err := s.store.ClaimReminder(ctx, appt.ID, lead, channel)
+		if err != nil {
+			slog.Error("error claiming reminder", "appointmentID", appt.ID, "channel", channel, "error", err)
+			continue
+		}
+		if !claimed {
+			continue // sent by another instance
+		}
+
+		err = notifier.Notify(ctx, &domain.Reminder{
+			Appointment: appt,
+			Lead:        lead,
+			VisitStart:  domain.VisitStart(*appt.VisitDate, s.location.TimeZone),
+			Location:    s.location,
+		})
+		if errors.Is(err, domain.ErrNoContactDetails) {
+			continue // keep the claim, there is nothing to retry
+		}
+		if err != nil {
+			slog.Error("error sending reminder", "appointmentID", appt.ID, "channel", channel, "error", err)
+			if err := s.store.ReleaseReminder(context.WithoutCancel(ctx), appt.ID, lead, channel); err != nil {
+				slog.Error("error releasing reminder", "appointmentID", appt.ID, "channel", channel, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestScheduler_tick(t *testing.T) {
+	now := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	appt := func(id int32, day int) *domain.Appointment {
+		a := domain.NewAppointment("first", "last", ptr.To(time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)))
+		a.ID = id
+		return a
+	}
+	tests := []struct {
+		name      string
+		appts     []*domain.Appointment
+		leads     []time.Duration
+		notifier  *fakeNotifier
+		wantFirst []string // sent reminders as "id/lead"
+		wantAfter []string // sent on a second tick
+	}{
+		{
+			name:      "sends the closest lead once",
+			appts:     []*domain.Appointment{appt(1, 6), appt(2, 7), appt(3, 10)},
+			leads:     []time.Duration{24 * time.Hour, 72 * time.Hour},
+			notifier:  &fakeNotifier{},
+			wantFirst: []string{"2/72h0m0s", "1/24h0m0s"},
+		},
+		{
+			name:      "retries a failed send on the next tick",
+			appts:     []*domain.Appointment{appt(1, 6)},
+			leads:     []time.Duration{24 * time.Hour},
+			notifier:  &fakeNotifier{failures: 1},
+			wantAfter: []string{"1/24h0m0s"},
+		},
+		{
+			name:     "does not retry when the patient cannot be reached",
+			appts:    []*domain.Appointment{appt(1, 6)},
+			leads:    []time.Duration{24 * time.Hour},
+			notifier: &fakeNotifier{err: domain.ErrNoContactDetails},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{appts: tt.appts, sent: map[string]bool{}}
+			underTest := NewScheduler(store, []domain.Notifier{tt.notifier}, tt.leads, &domain.Location{TimeZone: time.UTC}, time.Minute, func() time.Time { return now })
+
+			underTest.tick(t.Context())
+			require.Equal(t, tt.wantFirst, tt.notifier.got())
+			tt.notifier.reset()
+			underTest.tick(t.Context())
+			require.Equal(t, tt.wantAfter, tt.notifier.got())
+		})
+	}
+}
+
+// fakeStore behaves like the repository, filtering on the visit date window and the reminders already claimed.
+type fakeStore struct {
+	appts []*domain.Appointment
+	sent  map[string]bool
+}
+
+func key(id int32, lead time.Duration, channel string) string {
+	return fmt.Sprintf("%d/%s/%s", id, lead, channel)
+}
+
+func (f *fakeStore) ListReminderCandidates(_ context.Context, from time.Time, to time.Time, lead time.Duration, channel string) ([]*domain.Appointment, error) {
+	var due []*domain.Appointment
+	for _, a := range f.appts {
+		if !a.VisitDate.Before(from) && !a.VisitDate.After(to) && !f.sent[key(a.ID, lead, channel)] {
+			due = append(due, a)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeStore) ClaimReminder(_ context.Context, id int32, lead time.Duration, channel string) (bool, error) {
+	if f.sent[key(id, lead, channel)] {
+		return false, nil
+	}
+	f.sent[key(id, lead, channel)] = true
+	return true, nil
+}
+
+func (f *fakeStore) ReleaseReminder(_ context.Context, id int32, lead time.Duration, channel string) error {
+	delete(f.sent, key(id, lead, channel))
+	return nil
+}
+
+type fakeNotifier struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	sent     []string
+}
+
+func (f *fakeNotifier) Channel() string {
+	return "fake"
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, reminder *domain.Reminder) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("gateway down")
+	}
+	f.sent = append(f.sent, fmt.Sprintf("%d/%s", reminder.Appointment.ID, reminder.Lead))
+	return nil
+}
+
+func (f *fakeNotifier) got() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sent
+}
+
+func (f *fakeNotifier) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = nil
+}
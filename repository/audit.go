@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/gen"
+	"k8s.io/utils/ptr"
+)
+
+// auditSnapshot is how an appointment is stored in the audit log. It is kept apart from domain.Appointment so the
+// stored format only changes deliberately.
+type auditSnapshot struct {
+	ID        int32                    `json:"id"`
+	PublicID  uuid.UUID                `json:"publicId"`
+	FirstName string                   `json:"firstName"`
+	LastName  string                   `json:"lastName"`
+	VisitDate time.Time                `json:"visitDate"`
+	Email     string                   `json:"email,omitempty"`
+	Phone     string                   `json:"phone,omitempty"`
+	Status    domain.AppointmentStatus `json:"status"`
+	CreatedAt time.Time                `json:"createdAt"`
+}
+
+func (r *Repository) ListAuditEntries(ctx context.Context, appointmentID int32) ([]*domain.AuditEntry, error) {
+	rows, err := r.queries.ListAuditEntries(ctx, appointmentID)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+	entries := make([]*domain.AuditEntry, len(rows))
+	for i := range rows {
+		entries[i], err = toAuditEntry(rows[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// appendAudit records who changed the appointment, it must be called in the transaction making the change.
+func appendAudit(ctx context.Context, q *sqlcappts.Queries, action domain.AuditAction, before *domain.Appointment, after *domain.Appointment) error {
+	var beforeJSON []byte
+	if before != nil {
+		var err error
+		if beforeJSON, err = json.Marshal(toAuditSnapshot(before)); err != nil {
+			return fmt.Errorf("marshal audit snapshot: %w", err)
+		}
+	}
+	afterJSON, err := json.Marshal(toAuditSnapshot(after))
+	if err != nil {
+		return fmt.Errorf("marshal audit snapshot: %w", err)
+	}
+	if err := q.InsertAuditEntry(ctx, sqlcappts.InsertAuditEntryParams{
+		AppointmentID: after.ID,
+		Action:        string(action),
+		Actor:         domain.AuditActor(ctx),
+		RequestID:     domain.RequestIDFromContext(ctx),
+		Before:        beforeJSON,
+		After:         afterJSON,
+	}); err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// lockAppointment reads the appointment as it is before a change, holding its row until the transaction ends.
+func lockAppointment(ctx context.Context, q *sqlcappts.Queries, id int32) (*domain.Appointment, error) {
+	row, err := q.GetDailyAppointmentForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get daily appointment for update: %w", err)
+	}
+	return toAppointment(row), nil
+}
+
+func toAuditSnapshot(appt *domain.Appointment) auditSnapshot {
+	return auditSnapshot{
+		ID:        appt.ID,
+		PublicID:  appt.PublicID,
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+		VisitDate: *appt.VisitDate,
+		Email:     appt.Email,
+		Phone:     appt.Phone,
+		Status:    appt.Status,
+		CreatedAt: appt.CreatedAt,
+	}
+}
+
+func fromAuditSnapshot(data []byte) (*domain.Appointment, error) {
+	var snapshot auditSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("unmarshal audit snapshot: %w", err)
+	}
+	return &domain.Appointment{
+		ID:        snapshot.ID,
+		PublicID:  snapshot.PublicID,
+		FirstName: snapshot.FirstName,
+		LastName:  snapshot.LastName,
+		VisitDate: ptr.To(snapshot.VisitDate),
+		Email:     snapshot.Email,
+		Phone:     snapshot.Phone,
+		Status:    snapshot.Status,
+		CreatedAt: snapshot.CreatedAt,
+	}, nil
+}
+
+func toAuditEntry(row sqlcappts.ApptsAppointmentAudit) (*domain.AuditEntry, error) {
+	entry := &domain.AuditEntry{
+		ID:            row.ID,
+		AppointmentID: row.AppointmentID,
+		Action:        domain.AuditAction(row.Action),
+		Actor:         row.Actor,
+		RequestID:     row.RequestID,
+		CreatedAt:     row.CreatedAt.Time,
+	}
+	var err error
+	if row.Before != nil {
+		if entry.Before, err = fromAuditSnapshot(row.Before); err != nil {
+			return nil, err
+		}
+	}
+	if entry.After, err = fromAuditSnapshot(row.After); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
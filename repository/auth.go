@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/gen"
+	"k8s.io/utils/ptr"
+)
+
+func (r *Repository) CreateAPIKey(ctx context.Context, name string, role domain.Role, prefix string, hash []byte) (*domain.APIKey, error) {
+	row, err := r.queries.CreateAPIKey(ctx, sqlcappts.CreateAPIKeyParams{Name: name, Role: string(role), Prefix: prefix, KeyHash: hash})
+	if err != nil {
+		return nil, fmt.Errorf("create api key: %w", err)
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := r.queries.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	keys := make([]*domain.APIKey, len(rows))
+	for i := range rows {
+		keys[i] = toAPIKey(rows[i])
+	}
+	return keys, nil
+}
+
+func (r *Repository) GetActiveAPIKey(ctx context.Context, hash []byte) (*domain.APIKey, error) {
+	row, err := r.queries.GetActiveAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("get active api key by hash: %w", err)
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *Repository) RevokeAPIKey(ctx context.Context, id int32) error {
+	revoked, err := r.queries.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if revoked == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func toAPIKey(row sqlcappts.ApptsApiKey) *domain.APIKey {
+	key := &domain.APIKey{
+		ID:        row.ID,
+		Name:      row.Name,
+		Role:      domain.Role(row.Role),
+		Prefix:    row.Prefix,
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.RevokedAt.Valid {
+		key.RevokedAt = ptr.To(row.RevokedAt.Time)
+	}
+	return key
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/gen"
+)
+
+// EnqueueConfirmationEmail queues a confirmation of the appointment the event is about, unless the patient gave no
+// email address. Enqueueing the same event twice is a no-op, so it is safe to call from an at-least-once relay.
+func (r *Repository) EnqueueConfirmationEmail(ctx context.Context, event *domain.Event) error {
+	_, err := r.queries.EnqueueConfirmationEmail(ctx, sqlcappts.EnqueueConfirmationEmailParams{
+		EventID:       event.ID,
+		AppointmentID: event.AppointmentID,
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue confirmation email: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueConfirmationEmails takes up to limit pending confirmations whose next attempt is due and pushes that attempt
+// back by lease, so other senders leave them alone while they are in flight.
+func (r *Repository) ClaimDueConfirmationEmails(ctx context.Context, limit int32, lease time.Duration) ([]*domain.ConfirmationEmail, error) {
+	rows, err := r.queries.ClaimDueConfirmationEmails(ctx, sqlcappts.ClaimDueConfirmationEmailsParams{
+		Lease:     pgtype.Interval{Microseconds: lease.Microseconds(), Valid: true},
+		BatchSize: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim due confirmation emails: %w", err)
+	}
+	due := make([]*domain.ConfirmationEmail, 0, len(rows))
+	for _, row := range rows {
+		due = append(due, toConfirmationEmail(sqlcappts.ApptsConfirmationEmail(row)))
+	}
+	return due, nil
+}
+
+// RecordConfirmationAttempt saves the outcome of an attempt, as set on email by the sender.
+func (r *Repository) RecordConfirmationAttempt(ctx context.Context, email *domain.ConfirmationEmail) error {
+	err := r.queries.RecordConfirmationEmailAttempt(ctx, sqlcappts.RecordConfirmationEmailAttemptParams{
+		Status:        string(email.Status),
+		Attempts:      email.Attempts,
+		NextAttemptAt: pgtype.Timestamptz{Time: email.NextAttemptAt, Valid: true},
+		LastError:     pgtype.Text{String: email.LastError, Valid: email.LastError != ""},
+		ID:            email.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("record confirmation email attempt: %w", err)
+	}
+	return nil
+}
+
+func toConfirmationEmail(row sqlcappts.ApptsConfirmationEmail) *domain.ConfirmationEmail {
+	return &domain.ConfirmationEmail{
+		ID:            row.ID,
+		EventID:       row.EventID,
+		AppointmentID: row.AppointmentID,
+		Status:        domain.ConfirmationEmailStatus(row.Status),
+		Attempts:      row.Attempts,
+		NextAttemptAt: row.NextAttemptAt.Time,
+		LastError:     row.LastError.String,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}
+}
@@ -8,9 +8,109 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApptsApiKey struct {
+	ID        int32
+	Name      string
+	Prefix    string
+	KeyHash   []byte
+	CreatedAt pgtype.Timestamptz
+	RevokedAt pgtype.Timestamptz
+	Role      string
+}
+
+type ApptsAppointmentAudit struct {
+	ID            int64
+	AppointmentID int32
+	Action        string
+	Actor         string
+	RequestID     string
+	Before        []byte
+	After         []byte
+	CreatedAt     pgtype.Timestamptz
+}
+
+type ApptsConfirmationEmail struct {
+	ID            int64
+	EventID       int64
+	AppointmentID int32
+	Status        string
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
 type ApptsDailyAppointment struct {
 	ID              int32
 	FirstName       string
 	LastName        string
 	AppointmentDate pgtype.Timestamptz
+	Status          string
+	CreatedAt       pgtype.Timestamptz
+	Email           string
+	Phone           string
+	PublicID        pgtype.UUID
+	Sequence        int32
+	UpdatedAt       pgtype.Timestamptz
+}
+
+type ApptsDateHold struct {
+	Token     pgtype.UUID
+	HoldDate  pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+}
+
+type ApptsManageToken struct {
+	ID            pgtype.UUID
+	AppointmentID int32
+	ExpiresAt     pgtype.Timestamptz
+	RevokedAt     pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+}
+
+type ApptsOutboxEvent struct {
+	ID          int64
+	EventType   string
+	AggregateID int32
+	Payload     []byte
+	CreatedAt   pgtype.Timestamptz
+	PublishedAt pgtype.Timestamptz
+}
+
+type ApptsRateLimit struct {
+	Bucket      string
+	WindowStart pgtype.Timestamptz
+	Hits        int32
+	ExpiresAt   pgtype.Timestamptz
+}
+
+type ApptsSentReminder struct {
+	AppointmentID int32
+	LeadMinutes   int32
+	Channel       string
+	SentAt        pgtype.Timestamptz
+}
+
+type ApptsWebhookDelivery struct {
+	ID             int64
+	SubscriptionID int32
+	EventID        int64
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	LastStatusCode pgtype.Int4
+	LastError      pgtype.Text
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type ApptsWebhookSubscription struct {
+	ID         int32
+	Url        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  pgtype.Timestamptz
 }
@@ -11,26 +11,1202 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createDailyAppointment = `-- name: CreateDailyAppointment :one
-insert into appts.daily_appointments (first_name, last_name, appointment_date)
+const cancelDailyAppointment = `-- name: CancelDailyAppointment :one
+update appts.daily_appointments
+set status = 'cancelled', sequence = sequence + 1, updated_at = now()
+where id = $1 and status = 'booked'
+returning id, first_name, last_name, appointment_date, status, created_at, email, phone, public_id, sequence, updated_at
+`
+
+func (q *Queries) CancelDailyAppointment(ctx context.Context, id int32) (ApptsDailyAppointment, error) {
+	row := q.db.QueryRow(ctx, cancelDailyAppointment, id)
+	var i ApptsDailyAppointment
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.AppointmentDate,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.PublicID,
+		&i.Sequence,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const claimDueConfirmationEmails = `-- name: ClaimDueConfirmationEmails :many
+with claimed as (
+    update appts.confirmation_emails
+    set next_attempt_at = now() + $1::interval
+    where confirmation_emails.id in (
+        select id from appts.confirmation_emails
+        where status = 'pending' and next_attempt_at <= now()
+        order by next_attempt_at, id
+        limit $2
+        for update skip locked
+    )
+    returning id, event_id, appointment_id, status, attempts, next_attempt_at, last_error, created_at, updated_at
+)
+select id, event_id, appointment_id, status, attempts, next_attempt_at, last_error, created_at, updated_at from claimed
+order by id
+`
+
+type ClaimDueConfirmationEmailsParams struct {
+	Lease     pgtype.Interval
+	BatchSize int32
+}
+
+type ClaimDueConfirmationEmailsRow struct {
+	ID            int64
+	EventID       int64
+	AppointmentID int32
+	Status        string
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) ClaimDueConfirmationEmails(ctx context.Context, arg ClaimDueConfirmationEmailsParams) ([]ClaimDueConfirmationEmailsRow, error) {
+	rows, err := q.db.Query(ctx, claimDueConfirmationEmails, arg.Lease, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueConfirmationEmailsRow
+	for rows.Next() {
+		var i ClaimDueConfirmationEmailsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.AppointmentID,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+with claimed as (
+    update appts.webhook_deliveries
+    set next_attempt_at = now() + $1::interval
+    where webhook_deliveries.id in (
+        select id from appts.webhook_deliveries
+        where status = 'pending' and next_attempt_at <= now()
+        order by next_attempt_at, id
+        limit $2
+        for update skip locked
+    )
+    returning id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at
+)
+select claimed.id, claimed.subscription_id, claimed.event_id, claimed.event_type, claimed.payload, claimed.status, claimed.attempts, claimed.next_attempt_at, claimed.last_status_code, claimed.last_error, claimed.created_at, claimed.updated_at, s.url, s.secret
+from claimed
+join appts.webhook_subscriptions s on s.id = claimed.subscription_id
+order by claimed.id
+`
+
+type ClaimDueWebhookDeliveriesParams struct {
+	Lease     pgtype.Interval
+	BatchSize int32
+}
+
+type ClaimDueWebhookDeliveriesRow struct {
+	ID             int64
+	SubscriptionID int32
+	EventID        int64
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	LastStatusCode pgtype.Int4
+	LastError      pgtype.Text
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+	Url            string
+	Secret         string
+}
+
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]ClaimDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimDueWebhookDeliveries, arg.Lease, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimReminder = `-- name: ClaimReminder :execrows
+insert into appts.sent_reminders (appointment_id, lead_minutes, channel)
 values ($1, $2, $3)
-returning id, first_name, last_name, appointment_date
+on conflict do nothing
+`
+
+type ClaimReminderParams struct {
+	AppointmentID int32
+	LeadMinutes   int32
+	Channel       string
+}
+
+func (q *Queries) ClaimReminder(ctx context.Context, arg ClaimReminderParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimReminder, arg.AppointmentID, arg.LeadMinutes, arg.Channel)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const countPatientAppointments = `-- name: CountPatientAppointments :one
+select count(*) from appts.daily_appointments
+where status = 'booked'
+  and appointment_date >= $1
+  and (($2::text <> '' and lower(email) = lower($2::text))
+    or ($3::text <> '' and phone = $3::text)
+    or ($2::text = '' and $3::text = '' and email = '' and phone = ''
+      and lower(first_name) = lower($4::text) and lower(last_name) = lower($5::text)))
+`
+
+type CountPatientAppointmentsParams struct {
+	FromDate  pgtype.Timestamptz
+	Email     string
+	Phone     string
+	FirstName string
+	LastName  string
+}
+
+// CountPatientAppointments matches on email or phone, and on name only between bookings that have neither, so
+// different people sharing a name are not counted together.
+func (q *Queries) CountPatientAppointments(ctx context.Context, arg CountPatientAppointmentsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countPatientAppointments,
+		arg.FromDate,
+		arg.Email,
+		arg.Phone,
+		arg.FirstName,
+		arg.LastName,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIKey = `-- name: CreateAPIKey :one
+insert into appts.api_keys (name, role, prefix, key_hash)
+values ($1, $2, $3, $4)
+returning id, name, prefix, key_hash, created_at, revoked_at, role
+`
+
+type CreateAPIKeyParams struct {
+	Name    string
+	Role    string
+	Prefix  string
+	KeyHash []byte
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApptsApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.Name,
+		arg.Role,
+		arg.Prefix,
+		arg.KeyHash,
+	)
+	var i ApptsApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.CreatedAt,
+		&i.RevokedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const createDailyAppointment = `-- name: CreateDailyAppointment :one
+insert into appts.daily_appointments (first_name, last_name, appointment_date, email, phone, public_id)
+values ($1, $2, $3, $4, $5, $6)
+returning id, first_name, last_name, appointment_date, status, created_at, email, phone, public_id, sequence, updated_at
 `
 
 type CreateDailyAppointmentParams struct {
 	FirstName       string
 	LastName        string
 	AppointmentDate pgtype.Timestamptz
+	Email           string
+	Phone           string
+	PublicID        pgtype.UUID
 }
 
 func (q *Queries) CreateDailyAppointment(ctx context.Context, arg CreateDailyAppointmentParams) (ApptsDailyAppointment, error) {
-	row := q.db.QueryRow(ctx, createDailyAppointment, arg.FirstName, arg.LastName, arg.AppointmentDate)
+	row := q.db.QueryRow(ctx, createDailyAppointment,
+		arg.FirstName,
+		arg.LastName,
+		arg.AppointmentDate,
+		arg.Email,
+		arg.Phone,
+		arg.PublicID,
+	)
+	var i ApptsDailyAppointment
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.AppointmentDate,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.PublicID,
+		&i.Sequence,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createDateHold = `-- name: CreateDateHold :one
+insert into appts.date_holds (hold_date, expires_at)
+values ($1, $2)
+returning token, hold_date, expires_at
+`
+
+type CreateDateHoldParams struct {
+	HoldDate  pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateDateHold(ctx context.Context, arg CreateDateHoldParams) (ApptsDateHold, error) {
+	row := q.db.QueryRow(ctx, createDateHold, arg.HoldDate, arg.ExpiresAt)
+	var i ApptsDateHold
+	err := row.Scan(&i.Token, &i.HoldDate, &i.ExpiresAt)
+	return i, err
+}
+
+const createManageToken = `-- name: CreateManageToken :one
+insert into appts.manage_tokens (appointment_id, expires_at)
+values ($1, $2)
+returning id, appointment_id, expires_at, revoked_at, created_at
+`
+
+type CreateManageTokenParams struct {
+	AppointmentID int32
+	ExpiresAt     pgtype.Timestamptz
+}
+
+func (q *Queries) CreateManageToken(ctx context.Context, arg CreateManageTokenParams) (ApptsManageToken, error) {
+	row := q.db.QueryRow(ctx, createManageToken, arg.AppointmentID, arg.ExpiresAt)
+	var i ApptsManageToken
+	err := row.Scan(
+		&i.ID,
+		&i.AppointmentID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :one
+insert into appts.webhook_subscriptions (url, secret, event_types)
+values ($1, $2, $3)
+returning id, url, secret, event_types, created_at
+`
+
+type CreateWebhookSubscriptionParams struct {
+	Url        string
+	Secret     string
+	EventTypes []string
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (ApptsWebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, createWebhookSubscription, arg.Url, arg.Secret, arg.EventTypes)
+	var i ApptsWebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const dailyAppointmentExists = `-- name: DailyAppointmentExists :one
+select exists(select 1 from appts.daily_appointments where appointment_date = $1 and status = 'booked')
+`
+
+func (q *Queries) DailyAppointmentExists(ctx context.Context, appointmentDate pgtype.Timestamptz) (bool, error) {
+	row := q.db.QueryRow(ctx, dailyAppointmentExists, appointmentDate)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const dateHoldExists = `-- name: DateHoldExists :one
+select exists(select 1 from appts.date_holds where hold_date = $1)
+`
+
+func (q *Queries) DateHoldExists(ctx context.Context, holdDate pgtype.Timestamptz) (bool, error) {
+	row := q.db.QueryRow(ctx, dateHoldExists, holdDate)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const deleteDateHold = `-- name: DeleteDateHold :execrows
+delete from appts.date_holds
+where token = $1 and hold_date = $2
+`
+
+type DeleteDateHoldParams struct {
+	Token    pgtype.UUID
+	HoldDate pgtype.Timestamptz
+}
+
+func (q *Queries) DeleteDateHold(ctx context.Context, arg DeleteDateHoldParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDateHold, arg.Token, arg.HoldDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredDateHolds = `-- name: DeleteExpiredDateHolds :exec
+delete from appts.date_holds
+where expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredDateHolds(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredDateHolds)
+	return err
+}
+
+const deleteExpiredRateLimits = `-- name: DeleteExpiredRateLimits :exec
+delete from appts.rate_limits
+where expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredRateLimits(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredRateLimits)
+	return err
+}
+
+const deleteSentReminders = `-- name: DeleteSentReminders :exec
+delete from appts.sent_reminders
+where appointment_id = $1
+`
+
+func (q *Queries) DeleteSentReminders(ctx context.Context, appointmentID int32) error {
+	_, err := q.db.Exec(ctx, deleteSentReminders, appointmentID)
+	return err
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :execrows
+delete from appts.webhook_subscriptions
+where id = $1
+`
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookSubscription, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueConfirmationEmail = `-- name: EnqueueConfirmationEmail :execrows
+insert into appts.confirmation_emails (event_id, appointment_id)
+select $1::bigint, id
+from appts.daily_appointments
+where id = $2 and email <> ''
+on conflict (event_id) do nothing
+`
+
+type EnqueueConfirmationEmailParams struct {
+	EventID       int64
+	AppointmentID int32
+}
+
+func (q *Queries) EnqueueConfirmationEmail(ctx context.Context, arg EnqueueConfirmationEmailParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueConfirmationEmail, arg.EventID, arg.AppointmentID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :execrows
+insert into appts.webhook_deliveries (subscription_id, event_id, event_type, payload)
+select id, $1::bigint, $2::text, $3::jsonb
+from appts.webhook_subscriptions
+where cardinality(event_types) = 0 or $2::text = any(event_types)
+order by id
+on conflict (subscription_id, event_id) do nothing
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	EventID   int64
+	EventType string
+	Payload   []byte
+}
+
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueWebhookDeliveries, arg.EventID, arg.EventType, arg.Payload)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getActiveAPIKeyByHash = `-- name: GetActiveAPIKeyByHash :one
+select id, name, prefix, key_hash, created_at, revoked_at, role from appts.api_keys
+where key_hash = $1 and revoked_at is null
+`
+
+func (q *Queries) GetActiveAPIKeyByHash(ctx context.Context, keyHash []byte) (ApptsApiKey, error) {
+	row := q.db.QueryRow(ctx, getActiveAPIKeyByHash, keyHash)
+	var i ApptsApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.CreatedAt,
+		&i.RevokedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getDailyAppointment = `-- name: GetDailyAppointment :one
+select id, first_name, last_name, appointment_date, status, created_at, email, phone, public_id, sequence, updated_at from appts.daily_appointments
+where id = $1
+`
+
+func (q *Queries) GetDailyAppointment(ctx context.Context, id int32) (ApptsDailyAppointment, error) {
+	row := q.db.QueryRow(ctx, getDailyAppointment, id)
+	var i ApptsDailyAppointment
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.AppointmentDate,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.PublicID,
+		&i.Sequence,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getDailyAppointmentByPublicID = `-- name: GetDailyAppointmentByPublicID :one
+select id, first_name, last_name, appointment_date, status, created_at, email, phone, public_id, sequence, updated_at from appts.daily_appointments
+where public_id = $1
+`
+
+func (q *Queries) GetDailyAppointmentByPublicID(ctx context.Context, publicID pgtype.UUID) (ApptsDailyAppointment, error) {
+	row := q.db.QueryRow(ctx, getDailyAppointmentByPublicID, publicID)
 	var i ApptsDailyAppointment
 	err := row.Scan(
 		&i.ID,
 		&i.FirstName,
 		&i.LastName,
 		&i.AppointmentDate,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.PublicID,
+		&i.Sequence,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
+
+const getDailyAppointmentForUpdate = `-- name: GetDailyAppointmentForUpdate :one
+select id, first_name, last_name, appointment_date, status, created_at, email, phone, public_id, sequence, updated_at from appts.daily_appointments
+where id = $1
+for update
+`
+
+func (q *Queries) GetDailyAppointmentForUpdate(ctx context.Context, id int32) (ApptsDailyAppointment, error) {
+	row := q.db.QueryRow(ctx, getDailyAppointmentForUpdate, id)
+	var i ApptsDailyAppointment
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.AppointmentDate,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.PublicID,
+		&i.Sequence,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getManageToken = `-- name: GetManageToken :one
+select id, appointment_id, expires_at, revoked_at, created_at from appts.manage_tokens
+where id = $1
+`
+
+func (q *Queries) GetManageToken(ctx context.Context, id pgtype.UUID) (ApptsManageToken, error) {
+	row := q.db.QueryRow(ctx, getManageToken, id)
+	var i ApptsManageToken
+	err := row.Scan(
+		&i.ID,
+		&i.AppointmentID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+select id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at from appts.webhook_deliveries
+where id = $1 and subscription_id = $2
+`
+
+type GetWebhookDeliveryParams struct {
+	ID             int64
+	SubscriptionID int32
+}
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, arg GetWebhookDeliveryParams) (ApptsWebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, arg.ID, arg.SubscriptionID)
+	var i ApptsWebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+select id, url, secret, event_types, created_at from appts.webhook_subscriptions
+where id = $1
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id int32) (ApptsWebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, getWebhookSubscription, id)
+	var i ApptsWebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const incrementRateLimit = `-- name: IncrementRateLimit :one
+insert into appts.rate_limits (bucket, window_start, hits, expires_at)
+values ($1, $2, 1, $3)
+on conflict (bucket, window_start) do update set hits = rate_limits.hits + 1
+returning hits
+`
+
+type IncrementRateLimitParams struct {
+	Bucket      string
+	WindowStart pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
+}
+
+func (q *Queries) IncrementRateLimit(ctx context.Context, arg IncrementRateLimitParams) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementRateLimit, arg.Bucket, arg.WindowStart, arg.ExpiresAt)
+	var hits int32
+	err := row.Scan(&hits)
+	return hits, err
+}
+
+const insertAuditEntry = `-- name: InsertAuditEntry :exec
+insert into appts.appointment_audit (appointment_id, action, actor, request_id, before, after)
+values ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertAuditEntryParams struct {
+	AppointmentID int32
+	Action        string
+	Actor         string
+	RequestID     string
+	Before        []byte
+	After         []byte
+}
+
+func (q *Queries) InsertAuditEntry(ctx context.Context, arg InsertAuditEntryParams) error {
+	_, err := q.db.Exec(ctx, insertAuditEntry,
+		arg.AppointmentID,
+		arg.Action,
+		arg.Actor,
+		arg.RequestID,
+		arg.Before,
+		arg.After,
+	)
+	return err
+}
+
+const insertOutboxEvent = `-- name: InsertOutboxEvent :exec
+insert into appts.outbox_events (event_type, aggregate_id, payload)
+values ($1, $2, $3)
+`
+
+type InsertOutboxEventParams struct {
+	EventType   string
+	AggregateID int32
+	Payload     []byte
+}
+
+func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, insertOutboxEvent, arg.EventType, arg.AggregateID, arg.Payload)
+	return err
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+select id, name, prefix, key_hash, created_at, revoked_at, role from appts.api_keys
+order by id
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]ApptsApiKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApptsApiKey
+	for rows.Next() {
+		var i ApptsApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			&i.CreatedAt,
+			&i.RevokedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+select id, appointment_id, action, actor, request_id, before, after, created_at from appts.appointment_audit
+where appointment_id = $1
+order by id
+`
+
+func (q *Queries) ListAuditEntries(ctx context.Context, appointmentID int32) ([]ApptsAppointmentAudit, error) {
+	rows, err := q.db.Query(ctx, listAuditEntries, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApptsAppointmentAudit
+	for rows.Next() {
+		var i ApptsAppointmentAudit
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppointmentID,
+			&i.Action,
+			&i.Actor,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyAppointmentsPage = `-- name: ListDailyAppointmentsPage :many
+select id, first_name, last_name, appointment_date, status, created_at, email, phone, public_id, sequence, updated_at from appts.daily_appointments
+where ($1::timestamptz is null or appointment_date >= $1)
+  and ($2::timestamptz is null or appointment_date <= $2)
+  and ($3::text is null or status = $3)
+  and ($4::timestamptz is null or (appointment_date, id) > ($4, $5::integer))
+order by appointment_date, id
+limit $6
+`
+
+type ListDailyAppointmentsPageParams struct {
+	FromDate  pgtype.Timestamptz
+	ToDate    pgtype.Timestamptz
+	Status    pgtype.Text
+	AfterDate pgtype.Timestamptz
+	AfterID   pgtype.Int4
+	PageSize  int32
+}
+
+func (q *Queries) ListDailyAppointmentsPage(ctx context.Context, arg ListDailyAppointmentsPageParams) ([]ApptsDailyAppointment, error) {
+	rows, err := q.db.Query(ctx, listDailyAppointmentsPage,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Status,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApptsDailyAppointment
+	for rows.Next() {
+		var i ApptsDailyAppointment
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirstName,
+			&i.LastName,
+			&i.AppointmentDate,
+			&i.Status,
+			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.PublicID,
+			&i.Sequence,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReminderCandidates = `-- name: ListReminderCandidates :many
+select a.id, a.first_name, a.last_name, a.appointment_date, a.status, a.created_at, a.email, a.phone, a.public_id, a.sequence, a.updated_at from appts.daily_appointments a
+where a.status = 'booked'
+  and a.appointment_date >= $1
+  and a.appointment_date <= $2
+  and not exists (
+    select 1 from appts.sent_reminders r
+    where r.appointment_id = a.id and r.lead_minutes = $3 and r.channel = $4
+  )
+order by a.appointment_date, a.id
+`
+
+type ListReminderCandidatesParams struct {
+	FromDate    pgtype.Timestamptz
+	ToDate      pgtype.Timestamptz
+	LeadMinutes int32
+	Channel     string
+}
+
+func (q *Queries) ListReminderCandidates(ctx context.Context, arg ListReminderCandidatesParams) ([]ApptsDailyAppointment, error) {
+	rows, err := q.db.Query(ctx, listReminderCandidates,
+		arg.FromDate,
+		arg.ToDate,
+		arg.LeadMinutes,
+		arg.Channel,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApptsDailyAppointment
+	for rows.Next() {
+		var i ApptsDailyAppointment
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirstName,
+			&i.LastName,
+			&i.AppointmentDate,
+			&i.Status,
+			&i.CreatedAt,
+			&i.Email,
+			&i.Phone,
+			&i.PublicID,
+			&i.Sequence,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+select id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at from appts.webhook_deliveries
+where subscription_id = $1
+  and ($2::text is null or status = $2)
+order by id desc
+limit $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID int32
+	Status         pgtype.Text
+	PageSize       int32
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ApptsWebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, arg.SubscriptionID, arg.Status, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApptsWebhookDelivery
+	for rows.Next() {
+		var i ApptsWebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+select id, url, secret, event_types, created_at from appts.webhook_subscriptions
+order by id
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context) ([]ApptsWebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApptsWebhookSubscription
+	for rows.Next() {
+		var i ApptsWebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAppointmentDate = `-- name: LockAppointmentDate :exec
+select pg_advisory_xact_lock(extract(epoch from $1::timestamptz)::bigint)
+`
+
+func (q *Queries) LockAppointmentDate(ctx context.Context, appointmentDate pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, lockAppointmentDate, appointmentDate)
+	return err
+}
+
+const lockPatient = `-- name: LockPatient :exec
+select pg_advisory_xact_lock(hashtext('appts.patient'), hashtext(lower($1::text)))
+`
+
+func (q *Queries) LockPatient(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, lockPatient, key)
+	return err
+}
+
+const lockPendingOutboxEvents = `-- name: LockPendingOutboxEvents :many
+select id, event_type, aggregate_id, payload, created_at, published_at from appts.outbox_events
+where published_at is null
+order by id
+limit $1
+for update skip locked
+`
+
+func (q *Queries) LockPendingOutboxEvents(ctx context.Context, batchSize int32) ([]ApptsOutboxEvent, error) {
+	rows, err := q.db.Query(ctx, lockPendingOutboxEvents, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApptsOutboxEvent
+	for rows.Next() {
+		var i ApptsOutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.AggregateID,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventsPublished = `-- name: MarkOutboxEventsPublished :exec
+update appts.outbox_events
+set published_at = now()
+where id = any($1::bigint[])
+`
+
+func (q *Queries) MarkOutboxEventsPublished(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventsPublished, ids)
+	return err
+}
+
+const recordConfirmationEmailAttempt = `-- name: RecordConfirmationEmailAttempt :exec
+update appts.confirmation_emails
+set status = $1,
+    attempts = $2,
+    next_attempt_at = $3,
+    last_error = $4,
+    updated_at = now()
+where id = $5
+`
+
+type RecordConfirmationEmailAttemptParams struct {
+	Status        string
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	ID            int64
+}
+
+func (q *Queries) RecordConfirmationEmailAttempt(ctx context.Context, arg RecordConfirmationEmailAttemptParams) error {
+	_, err := q.db.Exec(ctx, recordConfirmationEmailAttempt,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const recordWebhookDeliveryAttempt = `-- name: RecordWebhookDeliveryAttempt :exec
+update appts.webhook_deliveries
+set status = $1,
+    attempts = $2,
+    next_attempt_at = $3,
+    last_status_code = $4,
+    last_error = $5,
+    updated_at = now()
+where id = $6
+`
+
+type RecordWebhookDeliveryAttemptParams struct {
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	LastStatusCode pgtype.Int4
+	LastError      pgtype.Text
+	ID             int64
+}
+
+func (q *Queries) RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) error {
+	_, err := q.db.Exec(ctx, recordWebhookDeliveryAttempt,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const releaseReminder = `-- name: ReleaseReminder :exec
+delete from appts.sent_reminders
+where appointment_id = $1 and lead_minutes = $2 and channel = $3
+`
+
+type ReleaseReminderParams struct {
+	AppointmentID int32
+	LeadMinutes   int32
+	Channel       string
+}
+
+func (q *Queries) ReleaseReminder(ctx context.Context, arg ReleaseReminderParams) error {
+	_, err := q.db.Exec(ctx, releaseReminder, arg.AppointmentID, arg.LeadMinutes, arg.Channel)
+	return err
+}
+
+const rescheduleDailyAppointment = `-- name: RescheduleDailyAppointment :one
+update appts.daily_appointments
+set appointment_date = $1, sequence = sequence + 1, updated_at = now()
+where id = $2 and status = 'booked'
+returning id, first_name, last_name, appointment_date, status, created_at, email, phone, public_id, sequence, updated_at
+`
+
+type RescheduleDailyAppointmentParams struct {
+	AppointmentDate pgtype.Timestamptz
+	ID              int32
+}
+
+func (q *Queries) RescheduleDailyAppointment(ctx context.Context, arg RescheduleDailyAppointmentParams) (ApptsDailyAppointment, error) {
+	row := q.db.QueryRow(ctx, rescheduleDailyAppointment, arg.AppointmentDate, arg.ID)
+	var i ApptsDailyAppointment
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.AppointmentDate,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Email,
+		&i.Phone,
+		&i.PublicID,
+		&i.Sequence,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const retryDeadWebhookDelivery = `-- name: RetryDeadWebhookDelivery :one
+update appts.webhook_deliveries
+set status = 'pending', attempts = 0, next_attempt_at = now(), updated_at = now()
+where id = $1 and subscription_id = $2 and status = 'dead'
+returning id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at
+`
+
+type RetryDeadWebhookDeliveryParams struct {
+	ID             int64
+	SubscriptionID int32
+}
+
+func (q *Queries) RetryDeadWebhookDelivery(ctx context.Context, arg RetryDeadWebhookDeliveryParams) (ApptsWebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, retryDeadWebhookDelivery, arg.ID, arg.SubscriptionID)
+	var i ApptsWebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :execrows
+update appts.api_keys
+set revoked_at = coalesce(revoked_at, now())
+where id = $1
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeAPIKey, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeManageToken = `-- name: RevokeManageToken :execrows
+update appts.manage_tokens
+set revoked_at = now()
+where id = $1 and revoked_at is null
+`
+
+func (q *Queries) RevokeManageToken(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeManageToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/gen"
+)
+
+func (r *Repository) CreateManageToken(ctx context.Context, appointmentID int32, expiresAt time.Time) (*domain.ManageToken, error) {
+	row, err := r.queries.CreateManageToken(ctx, sqlcappts.CreateManageTokenParams{
+		AppointmentID: appointmentID,
+		ExpiresAt:     pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create manage token: %w", err)
+	}
+	return toManageToken(row), nil
+}
+
+func (r *Repository) GetManageToken(ctx context.Context, id domain.ManageTokenID) (*domain.ManageToken, error) {
+	row, err := r.queries.GetManageToken(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidManageToken
+		}
+		return nil, fmt.Errorf("get manage token: %w", err)
+	}
+	return toManageToken(row), nil
+}
+
+func (r *Repository) RevokeManageToken(ctx context.Context, id domain.ManageTokenID) error {
+	revoked, err := r.queries.RevokeManageToken(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("revoke manage token: %w", err)
+	}
+	if revoked == 0 {
+		return domain.ErrInvalidManageToken
+	}
+	return nil
+}
+
+// CancelAppointment marks a booked appointment cancelled, freeing its date.
+func (r *Repository) CancelAppointment(ctx context.Context, id int32) (*domain.Appointment, error) {
+	var appt *domain.Appointment
+	err := r.withTx(ctx, func(q *sqlcappts.Queries) error {
+		before, err := lockAppointment(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		row, err := q.CancelDailyAppointment(ctx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return notBooked(ctx, q, id)
+			}
+			return fmt.Errorf("cancel daily appointment: %w", err)
+		}
+		appt = toAppointment(row)
+		if err := appendAudit(ctx, q, domain.AuditCancelled, before, appt); err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, domain.EventAppointmentCancelled, appt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appt, nil
+}
+
+// RescheduleAppointment moves a booked appointment to date under the same rules as booking it: dates that are booked
+// or held by anyone are taken. The reminders already sent for the old date are forgotten, so the new date gets its own.
+func (r *Repository) RescheduleAppointment(ctx context.Context, id int32, date *time.Time) (*domain.Appointment, error) {
+	visitDate := pgtype.Timestamptz{Time: *date, Valid: true}
+	var appt *domain.Appointment
+	err := r.withTx(ctx, func(q *sqlcappts.Queries) error {
+		if err := lockDate(ctx, q, visitDate); err != nil {
+			return err
+		}
+		held, err := q.DateHoldExists(ctx, visitDate)
+		if err != nil {
+			return fmt.Errorf("date hold exists: %w", err)
+		}
+		if held {
+			return domain.ErrAppointmentDateTaken
+		}
+		before, err := lockAppointment(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		row, err := q.RescheduleDailyAppointment(ctx, sqlcappts.RescheduleDailyAppointmentParams{AppointmentDate: visitDate, ID: id})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return notBooked(ctx, q, id)
+			}
+			if isUniqueViolation(err) {
+				return domain.ErrAppointmentDateTaken
+			}
+			return fmt.Errorf("reschedule daily appointment: %w", err)
+		}
+		appt = toAppointment(row)
+		if err := q.DeleteSentReminders(ctx, id); err != nil {
+			return fmt.Errorf("delete sent reminders: %w", err)
+		}
+		if err := appendAudit(ctx, q, domain.AuditRescheduled, before, appt); err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, domain.EventAppointmentRescheduled, appt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appt, nil
+}
+
+// notBooked explains why an update scoped to booked appointments matched nothing.
+func notBooked(ctx context.Context, q *sqlcappts.Queries, id int32) error {
+	if _, err := q.GetDailyAppointment(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrAppointmentNotFound
+		}
+		return fmt.Errorf("get daily appointment: %w", err)
+	}
+	return domain.ErrAppointmentCancelled
+}
+
+func toManageToken(row sqlcappts.ApptsManageToken) *domain.ManageToken {
+	return &domain.ManageToken{
+		ID:            row.ID.Bytes,
+		AppointmentID: row.AppointmentID,
+		ExpiresAt:     row.ExpiresAt.Time,
+		Revoked:       row.RevokedAt.Valid,
+	}
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/jcooney/appts/domain"
+)
+
+func (r *Repository) ListAuditEntries(_ context.Context, appointmentID int32) ([]*domain.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := []*domain.AuditEntry{}
+	for _, entry := range r.s.audit {
+		if entry.AppointmentID != appointmentID {
+			continue
+		}
+		if entry.Before != nil {
+			entry.Before = copyAppointment(*entry.Before)
+		}
+		entry.After = copyAppointment(*entry.After)
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// appendAudit records who changed the appointment, it must be called with the lock held by the call making the change.
+func (r *Repository) appendAudit(ctx context.Context, action domain.AuditAction, before *domain.Appointment, after domain.Appointment) {
+	r.s.audit = append(r.s.audit, domain.AuditEntry{
+		ID:            int64(len(r.s.audit) + 1),
+		AppointmentID: after.ID,
+		Action:        action,
+		Actor:         domain.AuditActor(ctx),
+		RequestID:     domain.RequestIDFromContext(ctx),
+		Before:        before,
+		After:         copyAppointment(after),
+		CreatedAt:     r.now(),
+	})
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/jcooney/appts/domain"
+	"k8s.io/utils/ptr"
+)
+
+type apiKey struct {
+	domain.APIKey
+	hash []byte
+}
+
+func (r *Repository) CreateAPIKey(_ context.Context, name string, role domain.Role, prefix string, hash []byte) (*domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.s.apiKeys {
+		if bytes.Equal(key.hash, hash) {
+			return nil, errors.New("create api key: a key with the same hash exists")
+		}
+	}
+	key := apiKey{
+		APIKey: domain.APIKey{
+			ID:        int32(len(r.s.apiKeys) + 1),
+			Name:      name,
+			Role:      role,
+			Prefix:    prefix,
+			CreatedAt: r.now(),
+		},
+		hash: bytes.Clone(hash),
+	}
+	r.s.apiKeys = append(r.s.apiKeys, key)
+	return copyAPIKey(key.APIKey), nil
+}
+
+func (r *Repository) ListAPIKeys(_ context.Context) ([]*domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]*domain.APIKey, len(r.s.apiKeys))
+	for i, key := range r.s.apiKeys {
+		keys[i] = copyAPIKey(key.APIKey)
+	}
+	return keys, nil
+}
+
+func (r *Repository) GetActiveAPIKey(_ context.Context, hash []byte) (*domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.s.apiKeys {
+		if key.RevokedAt == nil && bytes.Equal(key.hash, hash) {
+			return copyAPIKey(key.APIKey), nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+// RevokeAPIKey revokes the key, keeping the time it was first revoked if it already was.
+func (r *Repository) RevokeAPIKey(_ context.Context, id int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id < 1 || int(id) > len(r.s.apiKeys) {
+		return domain.ErrAPIKeyNotFound
+	}
+	if key := &r.s.apiKeys[id-1]; key.RevokedAt == nil {
+		key.RevokedAt = ptr.To(r.now())
+	}
+	return nil
+}
+
+func copyAPIKey(key domain.APIKey) *domain.APIKey {
+	if key.RevokedAt != nil {
+		key.RevokedAt = ptr.To(*key.RevokedAt)
+	}
+	return &key
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// EnqueueConfirmationEmail queues a confirmation of the appointment the event is about, unless the patient gave no
+// email address. Enqueueing the same event twice is a no-op, so it is safe to call from an at-least-once relay.
+func (r *Repository) EnqueueConfirmationEmail(_ context.Context, event *domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	appt, ok := r.s.appointments[event.AppointmentID]
+	if !ok || appt.Email == "" {
+		return nil
+	}
+	for _, email := range r.s.confirmations {
+		if email.EventID == event.ID {
+			return nil
+		}
+	}
+	id := int64(len(r.s.confirmations) + 1)
+	now := r.now()
+	r.s.confirmations[id] = domain.ConfirmationEmail{
+		ID:            id,
+		EventID:       event.ID,
+		AppointmentID: event.AppointmentID,
+		Status:        domain.ConfirmationEmailPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	return nil
+}
+
+// ClaimDueConfirmationEmails takes up to limit pending confirmations whose next attempt is due and pushes that attempt
+// back by lease, so other senders leave them alone while they are in flight.
+func (r *Repository) ClaimDueConfirmationEmails(_ context.Context, limit int32, lease time.Duration) ([]*domain.ConfirmationEmail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	var due []domain.ConfirmationEmail
+	for _, email := range r.s.confirmations {
+		if email.Status == domain.ConfirmationEmailPending && !email.NextAttemptAt.After(now) {
+			due = append(due, email)
+		}
+	}
+	slices.SortFunc(due, func(a, b domain.ConfirmationEmail) int {
+		if c := a.NextAttemptAt.Compare(b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	due = due[:min(len(due), int(limit))]
+	slices.SortFunc(due, func(a, b domain.ConfirmationEmail) int { return cmp.Compare(a.ID, b.ID) })
+
+	claimed := make([]*domain.ConfirmationEmail, 0, len(due))
+	for _, email := range due {
+		email.NextAttemptAt = now.Add(lease)
+		r.s.confirmations[email.ID] = email
+		claimed = append(claimed, &email)
+	}
+	return claimed, nil
+}
+
+// RecordConfirmationAttempt saves the outcome of an attempt, as set on email by the sender.
+func (r *Repository) RecordConfirmationAttempt(_ context.Context, email *domain.ConfirmationEmail) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.s.confirmations[email.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = email.Status
+	stored.Attempts = email.Attempts
+	stored.NextAttemptAt = email.NextAttemptAt
+	stored.LastError = email.LastError
+	stored.UpdatedAt = r.now()
+	r.s.confirmations[email.ID] = stored
+	return nil
+}
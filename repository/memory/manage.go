@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+)
+
+func (r *Repository) CreateManageToken(_ context.Context, appointmentID int32, expiresAt time.Time) (*domain.ManageToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.s.appointments[appointmentID]; !ok {
+		return nil, fmt.Errorf("create manage token: appointment %d does not exist", appointmentID)
+	}
+	token := domain.ManageToken{ID: domain.ManageTokenID(uuid.New()), AppointmentID: appointmentID, ExpiresAt: expiresAt}
+	r.s.manageTokens[token.ID] = token
+	return &token, nil
+}
+
+func (r *Repository) GetManageToken(_ context.Context, id domain.ManageTokenID) (*domain.ManageToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.s.manageTokens[id]
+	if !ok {
+		return nil, domain.ErrInvalidManageToken
+	}
+	return &token, nil
+}
+
+func (r *Repository) RevokeManageToken(_ context.Context, id domain.ManageTokenID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.s.manageTokens[id]
+	if !ok || token.Revoked {
+		return domain.ErrInvalidManageToken
+	}
+	token.Revoked = true
+	r.s.manageTokens[id] = token
+	return nil
+}
+
+// CancelAppointment marks a booked appointment cancelled, freeing its date.
+func (r *Repository) CancelAppointment(ctx context.Context, id int32) (*domain.Appointment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before, err := r.bookedAppointment(id)
+	if err != nil {
+		return nil, err
+	}
+	after := before
+	after.Status = domain.AppointmentStatusCancelled
+	after.Sequence++
+	after.UpdatedAt = r.now()
+	if err := r.appendEvent(domain.EventAppointmentCancelled, after); err != nil {
+		return nil, err
+	}
+	r.appendAudit(ctx, domain.AuditCancelled, copyAppointment(before), after)
+	r.s.appointments[id] = after
+	return copyAppointment(after), nil
+}
+
+// RescheduleAppointment moves a booked appointment to date under the same rules as booking it: dates that are booked
+// or held by anyone are taken. The reminders already sent for the old date are forgotten, so the new date gets its own.
+func (r *Repository) RescheduleAppointment(ctx context.Context, id int32, date *time.Time) (*domain.Appointment, error) {
+	visitDate := date.UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteExpiredHolds()
+	if r.dateHeld(visitDate) {
+		return nil, domain.ErrAppointmentDateTaken
+	}
+	before, err := r.bookedAppointment(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.dateBooked(visitDate, id) {
+		return nil, domain.ErrAppointmentDateTaken
+	}
+	after := before
+	after.VisitDate = &visitDate
+	after.Sequence++
+	after.UpdatedAt = r.now()
+	if err := r.appendEvent(domain.EventAppointmentRescheduled, after); err != nil {
+		return nil, err
+	}
+	r.appendAudit(ctx, domain.AuditRescheduled, copyAppointment(before), after)
+	r.s.appointments[id] = after
+	maps.DeleteFunc(r.s.sentReminders, func(key reminderKey, _ struct{}) bool {
+		return key.appointmentID == id
+	})
+	return copyAppointment(after), nil
+}
+
+// bookedAppointment returns the appointment if it can be changed, or why it cannot.
+func (r *Repository) bookedAppointment(id int32) (domain.Appointment, error) {
+	appt, ok := r.s.appointments[id]
+	if !ok {
+		return domain.Appointment{}, domain.ErrAppointmentNotFound
+	}
+	if appt.Status != domain.AppointmentStatusBooked {
+		return domain.Appointment{}, domain.ErrAppointmentCancelled
+	}
+	return appt, nil
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+type rateLimitKey struct {
+	bucket      string
+	windowStart time.Time
+}
+
+type rateLimit struct {
+	hits      int
+	expiresAt time.Time
+}
+
+// IncrementRateLimit counts a hit against the bucket's window, which is kept until expiresAt.
+func (r *Repository) IncrementRateLimit(_ context.Context, bucket string, windowStart time.Time, expiresAt time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := rateLimitKey{bucket: bucket, windowStart: windowStart.UTC()}
+	limit, ok := r.s.rateLimits[key]
+	if !ok {
+		limit.expiresAt = expiresAt
+	}
+	limit.hits++
+	r.s.rateLimits[key] = limit
+	return limit.hits, nil
+}
+
+func (r *Repository) DeleteExpiredRateLimits(_ context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	maps.DeleteFunc(r.s.rateLimits, func(_ rateLimitKey, limit rateLimit) bool {
+		return !limit.expiresAt.After(now)
+	})
+	return nil
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+type reminderKey struct {
+	appointmentID int32
+	lead          time.Duration
+	channel       string
+}
+
+// ListReminderCandidates returns booked appointments on visit dates from to to, inclusive, that have not had the
+// reminder for lead sent over channel.
+func (r *Repository) ListReminderCandidates(_ context.Context, from time.Time, to time.Time, lead time.Duration, channel string) ([]*domain.Appointment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	appts := r.appointmentsWhere(func(appt domain.Appointment) bool {
+		_, sent := r.s.sentReminders[reminderKey{appointmentID: appt.ID, lead: lead.Truncate(time.Minute), channel: channel}]
+		return appt.Status == domain.AppointmentStatusBooked && !appt.VisitDate.Before(from) && !appt.VisitDate.After(to) && !sent
+	})
+	if appts == nil {
+		appts = []*domain.Appointment{}
+	}
+	return appts, nil
+}
+
+// ClaimReminder records the reminder as sent, returning false if it already was.
+func (r *Repository) ClaimReminder(_ context.Context, appointmentID int32, lead time.Duration, channel string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.s.appointments[appointmentID]; !ok {
+		return false, fmt.Errorf("claim reminder: appointment %d does not exist", appointmentID)
+	}
+	key := reminderKey{appointmentID: appointmentID, lead: lead.Truncate(time.Minute), channel: channel}
+	if _, claimed := r.s.sentReminders[key]; claimed {
+		return false, nil
+	}
+	r.s.sentReminders[key] = struct{}{}
+	return true, nil
+}
+
+// ReleaseReminder forgets a claimed reminder that could not be sent, so it is tried again.
+func (r *Repository) ReleaseReminder(_ context.Context, appointmentID int32, lead time.Duration, channel string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.s.sentReminders, reminderKey{appointmentID: appointmentID, lead: lead.Truncate(time.Minute), channel: channel})
+	return nil
+}
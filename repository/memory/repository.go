@@ -0,0 +1,346 @@
+// Package memory is a repository kept in process memory, for running the service without Postgres during local
+// development and in tests. It has the same semantics as the Postgres repository, down to the domain errors it returns,
+// but everything is lost when the process stops.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"k8s.io/utils/ptr"
+)
+
+// Repository is safe for concurrent use. A single lock serialises every call, which stands in for the row and date
+// locks the Postgres repository takes.
+type Repository struct {
+	mu  sync.Mutex
+	s   *state
+	now func() time.Time
+}
+
+// state is everything stored. Records are held by value so a transaction can work on a copy of the maps and slices.
+type state struct {
+	appointments    map[int32]domain.Appointment
+	holds           map[uuid.UUID]hold
+	events          []event // an event's ID is its index plus one
+	audit           []domain.AuditEntry
+	manageTokens    map[domain.ManageTokenID]domain.ManageToken
+	apiKeys         []apiKey // a key's ID is its index plus one
+	rateLimits      map[rateLimitKey]rateLimit
+	sentReminders   map[reminderKey]struct{}
+	webhooks        map[int32]domain.WebhookSubscription
+	deliveries      map[int64]domain.WebhookDelivery
+	confirmations   map[int64]domain.ConfirmationEmail
+	lastWebhook     int32
+	lastDelivery    int64
+	lastAppointment int32
+}
+
+type hold struct {
+	date      time.Time
+	expiresAt time.Time
+}
+
+type event struct {
+	domain.Event
+	published bool
+	inFlight  bool // being published, the lock PublishPending holds on it
+}
+
+func NewRepository() *Repository {
+	return &Repository{
+		s: &state{
+			appointments:  map[int32]domain.Appointment{},
+			holds:         map[uuid.UUID]hold{},
+			manageTokens:  map[domain.ManageTokenID]domain.ManageToken{},
+			rateLimits:    map[rateLimitKey]rateLimit{},
+			sentReminders: map[reminderKey]struct{}{},
+			webhooks:      map[int32]domain.WebhookSubscription{},
+			deliveries:    map[int64]domain.WebhookDelivery{},
+			confirmations: map[int64]domain.ConfirmationEmail{},
+		},
+		now: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *state) clone() *state {
+	c := *s
+	c.appointments = maps.Clone(s.appointments)
+	c.holds = maps.Clone(s.holds)
+	c.events = slices.Clone(s.events)
+	c.audit = slices.Clone(s.audit)
+	c.manageTokens = maps.Clone(s.manageTokens)
+	c.apiKeys = slices.Clone(s.apiKeys)
+	c.rateLimits = maps.Clone(s.rateLimits)
+	c.sentReminders = maps.Clone(s.sentReminders)
+	c.webhooks = maps.Clone(s.webhooks)
+	c.deliveries = maps.Clone(s.deliveries)
+	c.confirmations = maps.Clone(s.confirmations)
+	return &c
+}
+
+// CreateAppointment books the visit date, consuming the hold identified by appt.HoldToken if one is given. Dates held
+// by anyone else are reported as taken.
+func (r *Repository) CreateAppointment(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	return r.createAppointment(ctx, appt, nil)
+}
+
+// CreateAppointmentWithinLimit books like CreateAppointment, but only while the patient is under limit.
+func (r *Repository) CreateAppointmentWithinLimit(ctx context.Context, appt *domain.Appointment, limit domain.PatientLimit) (*domain.Appointment, error) {
+	return r.createAppointment(ctx, appt, &limit)
+}
+
+func (r *Repository) createAppointment(ctx context.Context, appt *domain.Appointment, limit *domain.PatientLimit) (*domain.Appointment, error) {
+	publicID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("new public id: %w", err)
+	}
+	visitDate := appt.VisitDate.UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteExpiredHolds()
+	if limit != nil && r.patientAppointments(appt, limit.From) >= limit.Max {
+		return nil, domain.ErrPatientBookingLimit
+	}
+	if appt.HoldToken != "" {
+		if err := r.consumeHold(appt.HoldToken, visitDate); err != nil {
+			return nil, err
+		}
+	}
+	if r.dateHeld(visitDate) || r.dateBooked(visitDate, 0) {
+		return nil, domain.ErrAppointmentDateTaken
+	}
+
+	r.s.lastAppointment++
+	now := r.now()
+	created := domain.Appointment{
+		ID:        r.s.lastAppointment,
+		PublicID:  publicID,
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+		VisitDate: &visitDate,
+		Email:     appt.Email,
+		Phone:     appt.Phone,
+		Status:    domain.AppointmentStatusBooked,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := r.appendEvent(domain.EventAppointmentCreated, created); err != nil {
+		return nil, err
+	}
+	r.appendAudit(ctx, domain.AuditBooked, nil, created)
+	r.s.appointments[created.ID] = created
+	return copyAppointment(created), nil
+}
+
+func (r *Repository) GetAppointment(_ context.Context, id int32) (*domain.Appointment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	appt, ok := r.s.appointments[id]
+	if !ok {
+		return nil, domain.ErrAppointmentNotFound
+	}
+	return copyAppointment(appt), nil
+}
+
+func (r *Repository) GetAppointmentByPublicID(_ context.Context, id uuid.UUID) (*domain.Appointment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, appt := range r.s.appointments {
+		if appt.PublicID == id {
+			return copyAppointment(appt), nil
+		}
+	}
+	return nil, domain.ErrAppointmentNotFound
+}
+
+// patientAppointments counts the booked appointments on or after from belonging to the same patient as appt: those
+// with the same email or phone, or the same name when neither has any contact details.
+func (r *Repository) patientAppointments(appt *domain.Appointment, from time.Time) int {
+	count := 0
+	for _, booked := range r.s.appointments {
+		if booked.Status != domain.AppointmentStatusBooked || booked.VisitDate.Before(from) {
+			continue
+		}
+		sameEmail := appt.Email != "" && strings.EqualFold(booked.Email, appt.Email)
+		samePhone := appt.Phone != "" && booked.Phone == appt.Phone
+		sameName := appt.Email == "" && appt.Phone == "" && booked.Email == "" && booked.Phone == "" &&
+			strings.EqualFold(booked.FirstName, appt.FirstName) && strings.EqualFold(booked.LastName, appt.LastName)
+		if sameEmail || samePhone || sameName {
+			count++
+		}
+	}
+	return count
+}
+
+// ListAppointments calls fn for matching appointments as they were when it was called, without holding the lock, so fn
+// may use the repository.
+func (r *Repository) ListAppointments(_ context.Context, filter domain.AppointmentFilter, fn func(*domain.Appointment) error) error {
+	r.mu.Lock()
+	matching := r.appointmentsWhere(func(appt domain.Appointment) bool {
+		return (filter.From == nil || !appt.VisitDate.Before(*filter.From)) &&
+			(filter.To == nil || !appt.VisitDate.After(*filter.To)) &&
+			(filter.Status == "" || appt.Status == filter.Status)
+	})
+	r.mu.Unlock()
+
+	for _, appt := range matching {
+		if err := fn(appt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appointmentsWhere returns copies of the matching appointments in visit date order.
+func (r *Repository) appointmentsWhere(match func(domain.Appointment) bool) []*domain.Appointment {
+	var matching []*domain.Appointment
+	for _, appt := range r.s.appointments {
+		if match(appt) {
+			matching = append(matching, copyAppointment(appt))
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].VisitDate.Equal(*matching[j].VisitDate) {
+			return matching[i].VisitDate.Before(*matching[j].VisitDate)
+		}
+		return matching[i].ID < matching[j].ID
+	})
+	return matching
+}
+
+// CreateHold reserves the visit date until hold.ExpiresAt, failing if it is already booked or held.
+func (r *Repository) CreateHold(_ context.Context, h *domain.Hold) (*domain.Hold, error) {
+	visitDate := h.VisitDate.UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteExpiredHolds()
+	if r.dateBooked(visitDate, 0) || r.dateHeld(visitDate) {
+		return nil, domain.ErrAppointmentDateTaken
+	}
+	token := uuid.New()
+	r.s.holds[token] = hold{date: visitDate, expiresAt: h.ExpiresAt}
+	return &domain.Hold{Token: token.String(), VisitDate: &visitDate, ExpiresAt: h.ExpiresAt}, nil
+}
+
+func (r *Repository) deleteExpiredHolds() {
+	now := r.now()
+	maps.DeleteFunc(r.s.holds, func(_ uuid.UUID, h hold) bool {
+		return !h.expiresAt.After(now)
+	})
+}
+
+func (r *Repository) consumeHold(token string, date time.Time) error {
+	id, err := uuid.Parse(token)
+	if err != nil {
+		return domain.ErrHoldNotFound
+	}
+	h, ok := r.s.holds[id]
+	if !ok || !h.date.Equal(date) {
+		return domain.ErrHoldNotFound
+	}
+	delete(r.s.holds, id)
+	return nil
+}
+
+func (r *Repository) dateHeld(date time.Time) bool {
+	for _, h := range r.s.holds {
+		if h.date.Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// dateBooked reports whether an appointment other than except is booked on date.
+func (r *Repository) dateBooked(date time.Time, except int32) bool {
+	for _, appt := range r.s.appointments {
+		if appt.ID != except && appt.Status == domain.AppointmentStatusBooked && appt.VisitDate.Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendEvent adds an event to the outbox, it must be called with the lock held by the call making the change and
+// before anything else is changed, being the only step that can fail.
+func (r *Repository) appendEvent(eventType domain.EventType, appt domain.Appointment) error {
+	e, err := domain.NewAppointmentEvent(eventType, &appt)
+	if err != nil {
+		return err
+	}
+	e.ID = int64(len(r.s.events) + 1)
+	e.CreatedAt = r.now()
+	r.s.events = append(r.s.events, event{Event: *e})
+	return nil
+}
+
+// PublishPending passes up to limit unpublished events, oldest first, to publish until it fails, and marks those that
+// were published. Events being published by another call are skipped, as the Postgres repository skips locked rows.
+// The lock is not held while publishing, so publish may use the repository. The count of published events is returned
+// even when publish fails part way through.
+func (r *Repository) PublishPending(ctx context.Context, limit int32, publish func(ctx context.Context, event *domain.Event) error) (int, error) {
+	r.mu.Lock()
+	var pending []domain.Event
+	for i := range r.s.events {
+		if len(pending) == int(limit) {
+			break
+		}
+		if e := &r.s.events[i]; !e.published && !e.inFlight {
+			e.inFlight = true
+			pending = append(pending, e.Event)
+		}
+	}
+	r.mu.Unlock()
+
+	published := 0
+	var publishErr error
+	for _, e := range pending {
+		e.Payload = slices.Clone(e.Payload)
+		if publishErr = publish(ctx, &e); publishErr != nil {
+			break
+		}
+		published++
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range pending {
+		stored := &r.s.events[e.ID-1]
+		stored.inFlight = false
+		stored.published = i < published
+	}
+	if publishErr != nil {
+		return published, fmt.Errorf("publish event: %w", publishErr)
+	}
+	return published, nil
+}
+
+// InTx runs fn against a repository holding a copy of everything stored, which replaces the original only if fn
+// succeeds. Every other call waits until fn returns.
+func (r *Repository) InTx(_ context.Context, fn func(repo domain.AppointmentPersistorRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tx := &Repository{s: r.s.clone(), now: r.now}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.s = tx.s
+	return nil
+}
+
+// copyAppointment returns a copy of appt that the caller is free to change.
+func copyAppointment(appt domain.Appointment) *domain.Appointment {
+	appt.VisitDate = ptr.To(*appt.VisitDate)
+	return &appt
+}
@@ -0,0 +1,32 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/memory"
+	"github.com/jcooney/appts/repository/repositorytest"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestRepository(t *testing.T) {
+	repositorytest.Run(t, func(*testing.T) repositorytest.Store {
+		return memory.NewRepository()
+	})
+}
+
+func TestReturnedAppointmentsAreCopies(t *testing.T) {
+	underTest := memory.NewRepository()
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	*created.VisitDate = time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC)
+	created.Status = domain.AppointmentStatusCancelled
+
+	got, err := underTest.GetAppointment(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), *got.VisitDate)
+	require.Equal(t, domain.AppointmentStatusBooked, got.Status)
+}
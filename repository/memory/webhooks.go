@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+// webhookDeliveryLogSize caps how many deliveries ListWebhookDeliveries returns.
+const webhookDeliveryLogSize = 100
+
+func (r *Repository) CreateWebhookSubscription(_ context.Context, sub *domain.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.s.lastWebhook++
+	created := domain.WebhookSubscription{
+		ID:         r.s.lastWebhook,
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		EventTypes: slices.Clone(sub.EventTypes),
+		CreatedAt:  r.now(),
+	}
+	if created.EventTypes == nil {
+		created.EventTypes = []domain.EventType{}
+	}
+	r.s.webhooks[created.ID] = created
+	return copyWebhookSubscription(created), nil
+}
+
+func (r *Repository) ListWebhookSubscriptions(_ context.Context) ([]*domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs := make([]*domain.WebhookSubscription, 0, len(r.s.webhooks))
+	for _, id := range slices.Sorted(maps.Keys(r.s.webhooks)) {
+		subs = append(subs, copyWebhookSubscription(r.s.webhooks[id]))
+	}
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes the subscription along with its delivery log.
+func (r *Repository) DeleteWebhookSubscription(_ context.Context, id int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.s.webhooks[id]; !ok {
+		return domain.ErrWebhookNotFound
+	}
+	delete(r.s.webhooks, id)
+	maps.DeleteFunc(r.s.deliveries, func(_ int64, delivery domain.WebhookDelivery) bool {
+		return delivery.SubscriptionID == id
+	})
+	return nil
+}
+
+// ListWebhookDeliveries returns the latest webhookDeliveryLogSize deliveries to the subscription, newest first.
+func (r *Repository) ListWebhookDeliveries(_ context.Context, subscriptionID int32, status domain.WebhookDeliveryStatus) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.s.webhooks[subscriptionID]; !ok {
+		return nil, domain.ErrWebhookNotFound
+	}
+	deliveries := []*domain.WebhookDelivery{}
+	for _, id := range slices.Backward(slices.Sorted(maps.Keys(r.s.deliveries))) {
+		delivery := r.s.deliveries[id]
+		if delivery.SubscriptionID != subscriptionID || (status != "" && delivery.Status != status) {
+			continue
+		}
+		if deliveries = append(deliveries, copyWebhookDelivery(delivery)); len(deliveries) == webhookDeliveryLogSize {
+			break
+		}
+	}
+	return deliveries, nil
+}
+
+// RetryWebhookDelivery makes a dead delivery due now with its attempts reset.
+func (r *Repository) RetryWebhookDelivery(_ context.Context, subscriptionID int32, deliveryID int64) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery, ok := r.s.deliveries[deliveryID]
+	if !ok || delivery.SubscriptionID != subscriptionID {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+	if delivery.Status != domain.WebhookDeliveryDead {
+		return nil, domain.ErrWebhookDeliveryNotDead
+	}
+	now := r.now()
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+	r.s.deliveries[deliveryID] = delivery
+	return copyWebhookDelivery(delivery), nil
+}
+
+// EnqueueWebhookDeliveries queues the event for every subscription interested in it. Enqueueing the same event twice
+// is a no-op, so it is safe to call from an at-least-once relay.
+func (r *Repository) EnqueueWebhookDeliveries(_ context.Context, event *domain.Event) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queued := int64(0)
+	now := r.now()
+	for _, id := range slices.Sorted(maps.Keys(r.s.webhooks)) {
+		sub := r.s.webhooks[id]
+		if len(sub.EventTypes) > 0 && !slices.Contains(sub.EventTypes, event.Type) {
+			continue
+		}
+		if r.delivered(id, event.ID) {
+			continue
+		}
+		r.s.lastDelivery++
+		r.s.deliveries[r.s.lastDelivery] = domain.WebhookDelivery{
+			ID:             r.s.lastDelivery,
+			SubscriptionID: id,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        slices.Clone(event.Payload),
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// delivered reports whether the event has already been queued for the subscription.
+func (r *Repository) delivered(subscriptionID int32, eventID int64) bool {
+	for _, delivery := range r.s.deliveries {
+		if delivery.SubscriptionID == subscriptionID && delivery.EventID == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimDueWebhookDeliveries takes up to limit pending deliveries whose next attempt is due and pushes that attempt back
+// by lease, so other dispatchers leave them alone while they are in flight.
+func (r *Repository) ClaimDueWebhookDeliveries(_ context.Context, limit int32, lease time.Duration) ([]*domain.DueWebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	var due []domain.WebhookDelivery
+	for _, delivery := range r.s.deliveries {
+		if delivery.Status == domain.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	slices.SortFunc(due, func(a, b domain.WebhookDelivery) int {
+		if c := a.NextAttemptAt.Compare(b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	due = due[:min(len(due), int(limit))]
+	slices.SortFunc(due, func(a, b domain.WebhookDelivery) int { return cmp.Compare(a.ID, b.ID) })
+
+	claimed := make([]*domain.DueWebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = now.Add(lease)
+		r.s.deliveries[delivery.ID] = delivery
+		sub := r.s.webhooks[delivery.SubscriptionID]
+		claimed = append(claimed, &domain.DueWebhookDelivery{
+			WebhookDelivery: copyWebhookDelivery(delivery),
+			URL:             sub.URL,
+			Secret:          sub.Secret,
+		})
+	}
+	return claimed, nil
+}
+
+// RecordWebhookAttempt saves the outcome of an attempt, as set on delivery by the dispatcher.
+func (r *Repository) RecordWebhookAttempt(_ context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.s.deliveries[delivery.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.LastStatusCode = delivery.LastStatusCode
+	stored.LastError = delivery.LastError
+	stored.UpdatedAt = r.now()
+	r.s.deliveries[delivery.ID] = stored
+	return nil
+}
+
+func copyWebhookSubscription(sub domain.WebhookSubscription) *domain.WebhookSubscription {
+	sub.EventTypes = slices.Clone(sub.EventTypes)
+	return &sub
+}
+
+func copyWebhookDelivery(delivery domain.WebhookDelivery) *domain.WebhookDelivery {
+	delivery.Payload = slices.Clone(delivery.Payload)
+	return &delivery
+}
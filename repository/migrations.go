@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// migrationVersion is read directly as schema_migrations belongs to golang-migrate rather than the sqlc schema.
+const migrationVersion = `SELECT version, dirty FROM public.schema_migrations LIMIT 1`
+
+// MigrationVersion returns the version golang-migrate last applied and whether it failed part way.
+func (r *Repository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	if err := r.db.QueryRow(ctx, migrationVersion).Scan(&version, &dirty); err != nil {
+		return 0, false, fmt.Errorf("migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
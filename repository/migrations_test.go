@@ -0,0 +1,19 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/jcooney/appts/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationVersion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	version, dirty, err := repository.NewRepository(migratedPool(t)).MigrationVersion(t.Context())
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, uint(15), version)
+}
@@ -1,4 +1,267 @@
 -- name: CreateDailyAppointment :one
-insert into appts.daily_appointments (first_name, last_name, appointment_date)
-values (sqlc.arg(first_name), sqlc.arg(last_name), sqlc.arg(appointment_date))
-returning *;
\ No newline at end of file
+insert into appts.daily_appointments (first_name, last_name, appointment_date, email, phone, public_id)
+values (sqlc.arg(first_name), sqlc.arg(last_name), sqlc.arg(appointment_date), sqlc.arg(email), sqlc.arg(phone), sqlc.arg(public_id))
+returning *;
+
+-- name: CreateDateHold :one
+insert into appts.date_holds (hold_date, expires_at)
+values (sqlc.arg(hold_date), sqlc.arg(expires_at))
+returning *;
+
+-- name: DailyAppointmentExists :one
+select exists(select 1 from appts.daily_appointments where appointment_date = sqlc.arg(appointment_date) and status = 'booked');
+
+-- name: DateHoldExists :one
+select exists(select 1 from appts.date_holds where hold_date = sqlc.arg(hold_date));
+
+-- name: DeleteDateHold :execrows
+delete from appts.date_holds
+where token = sqlc.arg(token) and hold_date = sqlc.arg(hold_date);
+
+-- name: DeleteExpiredDateHolds :exec
+delete from appts.date_holds
+where expires_at <= now();
+
+-- name: LockAppointmentDate :exec
+select pg_advisory_xact_lock(extract(epoch from sqlc.arg(appointment_date)::timestamptz)::bigint);
+
+-- name: ListDailyAppointmentsPage :many
+select * from appts.daily_appointments
+where (sqlc.narg(from_date)::timestamptz is null or appointment_date >= sqlc.narg(from_date))
+  and (sqlc.narg(to_date)::timestamptz is null or appointment_date <= sqlc.narg(to_date))
+  and (sqlc.narg(status)::text is null or status = sqlc.narg(status))
+  and (sqlc.narg(after_date)::timestamptz is null or (appointment_date, id) > (sqlc.narg(after_date), sqlc.narg(after_id)::integer))
+order by appointment_date, id
+limit sqlc.arg(page_size);
+
+-- name: GetDailyAppointment :one
+select * from appts.daily_appointments
+where id = sqlc.arg(id);
+
+-- name: GetDailyAppointmentByPublicID :one
+select * from appts.daily_appointments
+where public_id = sqlc.arg(public_id);
+
+-- name: InsertOutboxEvent :exec
+insert into appts.outbox_events (event_type, aggregate_id, payload)
+values (sqlc.arg(event_type), sqlc.arg(aggregate_id), sqlc.arg(payload));
+
+-- name: LockPendingOutboxEvents :many
+select * from appts.outbox_events
+where published_at is null
+order by id
+limit sqlc.arg(batch_size)
+for update skip locked;
+
+-- name: MarkOutboxEventsPublished :exec
+update appts.outbox_events
+set published_at = now()
+where id = any(sqlc.arg(ids)::bigint[]);
+
+-- name: CreateWebhookSubscription :one
+insert into appts.webhook_subscriptions (url, secret, event_types)
+values (sqlc.arg(url), sqlc.arg(secret), sqlc.arg(event_types))
+returning *;
+
+-- name: ListWebhookSubscriptions :many
+select * from appts.webhook_subscriptions
+order by id;
+
+-- name: GetWebhookSubscription :one
+select * from appts.webhook_subscriptions
+where id = sqlc.arg(id);
+
+-- name: DeleteWebhookSubscription :execrows
+delete from appts.webhook_subscriptions
+where id = sqlc.arg(id);
+
+-- name: EnqueueWebhookDeliveries :execrows
+insert into appts.webhook_deliveries (subscription_id, event_id, event_type, payload)
+select id, sqlc.arg(event_id)::bigint, sqlc.arg(event_type)::text, sqlc.arg(payload)::jsonb
+from appts.webhook_subscriptions
+where cardinality(event_types) = 0 or sqlc.arg(event_type)::text = any(event_types)
+order by id
+on conflict (subscription_id, event_id) do nothing;
+
+-- name: ClaimDueWebhookDeliveries :many
+with claimed as (
+    update appts.webhook_deliveries
+    set next_attempt_at = now() + sqlc.arg(lease)::interval
+    where webhook_deliveries.id in (
+        select id from appts.webhook_deliveries
+        where status = 'pending' and next_attempt_at <= now()
+        order by next_attempt_at, id
+        limit sqlc.arg(batch_size)
+        for update skip locked
+    )
+    returning *
+)
+select claimed.*, s.url, s.secret
+from claimed
+join appts.webhook_subscriptions s on s.id = claimed.subscription_id
+order by claimed.id;
+
+-- name: RecordWebhookDeliveryAttempt :exec
+update appts.webhook_deliveries
+set status = sqlc.arg(status),
+    attempts = sqlc.arg(attempts),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_status_code = sqlc.narg(last_status_code),
+    last_error = sqlc.narg(last_error),
+    updated_at = now()
+where id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+select * from appts.webhook_deliveries
+where subscription_id = sqlc.arg(subscription_id)
+  and (sqlc.narg(status)::text is null or status = sqlc.narg(status))
+order by id desc
+limit sqlc.arg(page_size);
+
+-- name: GetWebhookDelivery :one
+select * from appts.webhook_deliveries
+where id = sqlc.arg(id) and subscription_id = sqlc.arg(subscription_id);
+
+-- name: RetryDeadWebhookDelivery :one
+update appts.webhook_deliveries
+set status = 'pending', attempts = 0, next_attempt_at = now(), updated_at = now()
+where id = sqlc.arg(id) and subscription_id = sqlc.arg(subscription_id) and status = 'dead'
+returning *;
+
+-- name: ListReminderCandidates :many
+select * from appts.daily_appointments a
+where a.status = 'booked'
+  and a.appointment_date >= sqlc.arg(from_date)
+  and a.appointment_date <= sqlc.arg(to_date)
+  and not exists (
+    select 1 from appts.sent_reminders r
+    where r.appointment_id = a.id and r.lead_minutes = sqlc.arg(lead_minutes) and r.channel = sqlc.arg(channel)
+  )
+order by a.appointment_date, a.id;
+
+-- name: ClaimReminder :execrows
+insert into appts.sent_reminders (appointment_id, lead_minutes, channel)
+values (sqlc.arg(appointment_id), sqlc.arg(lead_minutes), sqlc.arg(channel))
+on conflict do nothing;
+
+-- name: ReleaseReminder :exec
+delete from appts.sent_reminders
+where appointment_id = sqlc.arg(appointment_id) and lead_minutes = sqlc.arg(lead_minutes) and channel = sqlc.arg(channel);
+
+-- name: DeleteSentReminders :exec
+delete from appts.sent_reminders
+where appointment_id = sqlc.arg(appointment_id);
+
+-- name: CancelDailyAppointment :one
+update appts.daily_appointments
+set status = 'cancelled', sequence = sequence + 1, updated_at = now()
+where id = sqlc.arg(id) and status = 'booked'
+returning *;
+
+-- name: RescheduleDailyAppointment :one
+update appts.daily_appointments
+set appointment_date = sqlc.arg(appointment_date), sequence = sequence + 1, updated_at = now()
+where id = sqlc.arg(id) and status = 'booked'
+returning *;
+
+-- name: CreateManageToken :one
+insert into appts.manage_tokens (appointment_id, expires_at)
+values (sqlc.arg(appointment_id), sqlc.arg(expires_at))
+returning *;
+
+-- name: GetManageToken :one
+select * from appts.manage_tokens
+where id = sqlc.arg(id);
+
+-- name: RevokeManageToken :execrows
+update appts.manage_tokens
+set revoked_at = now()
+where id = sqlc.arg(id) and revoked_at is null;
+
+-- name: CreateAPIKey :one
+insert into appts.api_keys (name, role, prefix, key_hash)
+values (sqlc.arg(name), sqlc.arg(role), sqlc.arg(prefix), sqlc.arg(key_hash))
+returning *;
+
+-- name: ListAPIKeys :many
+select * from appts.api_keys
+order by id;
+
+-- name: GetActiveAPIKeyByHash :one
+select * from appts.api_keys
+where key_hash = sqlc.arg(key_hash) and revoked_at is null;
+
+-- name: RevokeAPIKey :execrows
+update appts.api_keys
+set revoked_at = coalesce(revoked_at, now())
+where id = sqlc.arg(id);
+
+-- name: IncrementRateLimit :one
+insert into appts.rate_limits (bucket, window_start, hits, expires_at)
+values (sqlc.arg(bucket), sqlc.arg(window_start), 1, sqlc.arg(expires_at))
+on conflict (bucket, window_start) do update set hits = rate_limits.hits + 1
+returning hits;
+
+-- name: DeleteExpiredRateLimits :exec
+delete from appts.rate_limits
+where expires_at <= now();
+
+-- name: CountPatientAppointments :one
+-- CountPatientAppointments matches on email or phone, and on name only between bookings that have neither, so
+-- different people sharing a name are not counted together.
+select count(*) from appts.daily_appointments
+where status = 'booked'
+  and appointment_date >= sqlc.arg(from_date)
+  and ((sqlc.arg(email)::text <> '' and lower(email) = lower(sqlc.arg(email)::text))
+    or (sqlc.arg(phone)::text <> '' and phone = sqlc.arg(phone)::text)
+    or (sqlc.arg(email)::text = '' and sqlc.arg(phone)::text = '' and email = '' and phone = ''
+      and lower(first_name) = lower(sqlc.arg(first_name)::text) and lower(last_name) = lower(sqlc.arg(last_name)::text)));
+
+-- name: LockPatient :exec
+select pg_advisory_xact_lock(hashtext('appts.patient'), hashtext(lower(sqlc.arg(key)::text)));
+
+-- name: GetDailyAppointmentForUpdate :one
+select * from appts.daily_appointments
+where id = sqlc.arg(id)
+for update;
+
+-- name: InsertAuditEntry :exec
+insert into appts.appointment_audit (appointment_id, action, actor, request_id, before, after)
+values (sqlc.arg(appointment_id), sqlc.arg(action), sqlc.arg(actor), sqlc.arg(request_id), sqlc.arg(before), sqlc.arg(after));
+
+-- name: ListAuditEntries :many
+select * from appts.appointment_audit
+where appointment_id = sqlc.arg(appointment_id)
+order by id;
+
+-- name: EnqueueConfirmationEmail :execrows
+insert into appts.confirmation_emails (event_id, appointment_id)
+select sqlc.arg(event_id)::bigint, id
+from appts.daily_appointments
+where id = sqlc.arg(appointment_id) and email <> ''
+on conflict (event_id) do nothing;
+
+-- name: ClaimDueConfirmationEmails :many
+with claimed as (
+    update appts.confirmation_emails
+    set next_attempt_at = now() + sqlc.arg(lease)::interval
+    where confirmation_emails.id in (
+        select id from appts.confirmation_emails
+        where status = 'pending' and next_attempt_at <= now()
+        order by next_attempt_at, id
+        limit sqlc.arg(batch_size)
+        for update skip locked
+    )
+    returning *
+)
+select * from claimed
+order by id;
+
+-- name: RecordConfirmationEmailAttempt :exec
+update appts.confirmation_emails
+set status = sqlc.arg(status),
+    attempts = sqlc.arg(attempts),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_error = sqlc.narg(last_error),
+    updated_at = now()
+where id = sqlc.arg(id);
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jcooney/appts/repository/gen"
+)
+
+// IncrementRateLimit upserts the bucket's counter for the window, so concurrent hits from any replica are all counted.
+func (r *Repository) IncrementRateLimit(ctx context.Context, bucket string, windowStart time.Time, expiresAt time.Time) (int, error) {
+	hits, err := r.queries.IncrementRateLimit(ctx, sqlcappts.IncrementRateLimitParams{
+		Bucket:      bucket,
+		WindowStart: pgtype.Timestamptz{Time: windowStart, Valid: true},
+		ExpiresAt:   pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("increment rate limit: %w", err)
+	}
+	return int(hits), nil
+}
+
+func (r *Repository) DeleteExpiredRateLimits(ctx context.Context) error {
+	if err := r.queries.DeleteExpiredRateLimits(ctx); err != nil {
+		return fmt.Errorf("delete expired rate limits: %w", err)
+	}
+	return nil
+}
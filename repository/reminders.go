@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/gen"
+)
+
+// ListReminderCandidates returns booked appointments on visit dates from to to, inclusive, that have not had the
+// reminder for lead sent over channel.
+func (r *Repository) ListReminderCandidates(ctx context.Context, from time.Time, to time.Time, lead time.Duration, channel string) ([]*domain.Appointment, error) {
+	rows, err := r.queries.ListReminderCandidates(ctx, sqlcappts.ListReminderCandidatesParams{
+		FromDate:    pgtype.Timestamptz{Time: from, Valid: true},
+		ToDate:      pgtype.Timestamptz{Time: to, Valid: true},
+		LeadMinutes: leadMinutes(lead),
+		Channel:     channel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list reminder candidates: %w", err)
+	}
+	appts := make([]*domain.Appointment, 0, len(rows))
+	for _, row := range rows {
+		appts = append(appts, toAppointment(row))
+	}
+	return appts, nil
+}
+
+// ClaimReminder records the reminder as sent, returning false if it already was. Claiming before sending means a
+// restart can never send a reminder twice.
+func (r *Repository) ClaimReminder(ctx context.Context, appointmentID int32, lead time.Duration, channel string) (bool, error) {
+	claimed, err := r.queries.ClaimReminder(ctx, sqlcappts.ClaimReminderParams{
+		AppointmentID: appointmentID,
+		LeadMinutes:   leadMinutes(lead),
+		Channel:       channel,
+	})
+	if err != nil {
+		return false, fmt.Errorf("claim reminder: %w", err)
+	}
+	return claimed == 1, nil
+}
+
+// ReleaseReminder forgets a claimed reminder that could not be sent, so it is tried again.
+func (r *Repository) ReleaseReminder(ctx context.Context, appointmentID int32, lead time.Duration, channel string) error {
+	if err := r.queries.ReleaseReminder(ctx, sqlcappts.ReleaseReminderParams{
+		AppointmentID: appointmentID,
+		LeadMinutes:   leadMinutes(lead),
+		Channel:       channel,
+	}); err != nil {
+		return fmt.Errorf("release reminder: %w", err)
+	}
+	return nil
+}
+
+func leadMinutes(lead time.Duration) int32 {
+	return int32(lead / time.Minute)
+}
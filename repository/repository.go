@@ -5,35 +5,366 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jcooney/appts/domain"
 	"github.com/jcooney/appts/repository/gen"
 )
 
+// DB is satisfied by both *pgxpool.Pool and pgx.Tx, the latter nesting transactions as savepoints.
+type DB interface {
+	sqlcappts.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 type Repository struct {
+	db      DB
 	queries *sqlcappts.Queries
 }
 
-func NewRepository(db sqlcappts.DBTX) *Repository {
-	return &Repository{queries: sqlcappts.New(db)}
+func NewRepository(db DB) *Repository {
+	return &Repository{db: db, queries: sqlcappts.New(db)}
 }
 
+// CreateAppointment books the visit date, consuming the hold identified by appt.HoldToken if one is given. Dates held
+// by anyone else are reported as taken.
 func (r *Repository) CreateAppointment(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
-	appointmentRow, err := r.queries.CreateDailyAppointment(ctx, sqlcappts.CreateDailyAppointmentParams{
-		FirstName:       appt.FirstName,
-		LastName:        appt.LastName,
-		AppointmentDate: pgtype.Timestamptz{Time: *appt.VisitDate, Valid: true},
+	return r.createAppointment(ctx, appt, nil)
+}
+
+// CreateAppointmentWithinLimit books like CreateAppointment, but only while the patient is under limit.
+func (r *Repository) CreateAppointmentWithinLimit(ctx context.Context, appt *domain.Appointment, limit domain.PatientLimit) (*domain.Appointment, error) {
+	return r.createAppointment(ctx, appt, &limit)
+}
+
+func (r *Repository) createAppointment(ctx context.Context, appt *domain.Appointment, limit *domain.PatientLimit) (*domain.Appointment, error) {
+	visitDate := pgtype.Timestamptz{Time: *appt.VisitDate, Valid: true}
+	publicID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("new public id: %w", err)
+	}
+	var appointmentRow sqlcappts.ApptsDailyAppointment
+	err = r.withTx(ctx, func(q *sqlcappts.Queries) error {
+		if err := lockDate(ctx, q, visitDate); err != nil {
+			return err
+		}
+		if limit != nil {
+			if err := checkPatientLimit(ctx, q, appt, *limit); err != nil {
+				return err
+			}
+		}
+		if appt.HoldToken != "" {
+			if err := consumeHold(ctx, q, appt.HoldToken, visitDate); err != nil {
+				return err
+			}
+		}
+		held, err := q.DateHoldExists(ctx, visitDate)
+		if err != nil {
+			return fmt.Errorf("date hold exists: %w", err)
+		}
+		if held {
+			return domain.ErrAppointmentDateTaken
+		}
+
+		appointmentRow, err = q.CreateDailyAppointment(ctx, sqlcappts.CreateDailyAppointmentParams{
+			FirstName:       appt.FirstName,
+			LastName:        appt.LastName,
+			AppointmentDate: visitDate,
+			Email:           appt.Email,
+			Phone:           appt.Phone,
+			PublicID:        pgtype.UUID{Bytes: publicID, Valid: true},
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return domain.ErrAppointmentDateTaken
+			}
+			return fmt.Errorf("create daily appointment: %w", err)
+		}
+		created := toAppointment(appointmentRow)
+		if err := appendAudit(ctx, q, domain.AuditBooked, nil, created); err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, domain.EventAppointmentCreated, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toAppointment(appointmentRow), nil
+}
+
+func (r *Repository) GetAppointment(ctx context.Context, id int32) (*domain.Appointment, error) {
+	row, err := r.queries.GetDailyAppointment(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get daily appointment: %w", err)
+	}
+	return toAppointment(row), nil
+}
+
+func (r *Repository) GetAppointmentByPublicID(ctx context.Context, id uuid.UUID) (*domain.Appointment, error) {
+	row, err := r.queries.GetDailyAppointmentByPublicID(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get daily appointment by public id: %w", err)
+	}
+	return toAppointment(row), nil
+}
+
+// checkPatientLimit locks the patient appt belongs to, then refuses the booking if they are already at limit. Their
+// email and their phone are locked separately, as another booking matching either belongs to the same patient, and
+// always in that order so two bookings cannot each wait on the lock the other holds. Only a booking with neither is
+// matched, and so locked, by name.
+func checkPatientLimit(ctx context.Context, q *sqlcappts.Queries, appt *domain.Appointment, limit domain.PatientLimit) error {
+	for _, key := range patientLockKeys(appt) {
+		if err := q.LockPatient(ctx, key); err != nil {
+			return fmt.Errorf("lock patient: %w", err)
+		}
+	}
+	count, err := q.CountPatientAppointments(ctx, sqlcappts.CountPatientAppointmentsParams{
+		FromDate:  pgtype.Timestamptz{Time: limit.From, Valid: true},
+		Email:     appt.Email,
+		Phone:     appt.Phone,
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+	})
+	if err != nil {
+		return fmt.Errorf("count patient appointments: %w", err)
+	}
+	if int(count) >= limit.Max {
+		return domain.ErrPatientBookingLimit
+	}
+	return nil
+}
+
+// patientLockKeys are the keys checkPatientLimit locks for appt, in the order it must take them.
+func patientLockKeys(appt *domain.Appointment) []string {
+	var keys []string
+	if appt.Email != "" {
+		keys = append(keys, "email:"+appt.Email)
+	}
+	if appt.Phone != "" {
+		keys = append(keys, "phone:"+appt.Phone)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, "name:"+appt.FirstName+" "+appt.LastName)
+	}
+	return keys
+}
+
+// listPageSize is how many rows ListAppointments holds in memory at once.
+const listPageSize = 500
+
+// ListAppointments pages through matching appointments with a keyset on (appointment_date, id), so memory use is
+// bounded by listPageSize however many rows match.
+func (r *Repository) ListAppointments(ctx context.Context, filter domain.AppointmentFilter, fn func(*domain.Appointment) error) error {
+	params := sqlcappts.ListDailyAppointmentsPageParams{PageSize: listPageSize}
+	if filter.From != nil {
+		params.FromDate = pgtype.Timestamptz{Time: *filter.From, Valid: true}
+	}
+	if filter.To != nil {
+		params.ToDate = pgtype.Timestamptz{Time: *filter.To, Valid: true}
+	}
+	if filter.Status != "" {
+		params.Status = pgtype.Text{String: string(filter.Status), Valid: true}
+	}
+	for {
+		page, err := r.queries.ListDailyAppointmentsPage(ctx, params)
+		if err != nil {
+			return fmt.Errorf("list daily appointments page: %w", err)
+		}
+		for i := range page {
+			if err := fn(toAppointment(page[i])); err != nil {
+				return err
+			}
+		}
+		if len(page) < listPageSize {
+			return nil
+		}
+		last := page[len(page)-1]
+		params.AfterDate = last.AppointmentDate
+		params.AfterID = pgtype.Int4{Int32: last.ID, Valid: true}
+	}
+}
+
+func toAppointment(row sqlcappts.ApptsDailyAppointment) *domain.Appointment {
+	visitDate := row.AppointmentDate.Time.UTC()
+	appt := domain.NewAppointment(row.FirstName, row.LastName, &visitDate)
+	appt.ID = row.ID
+	appt.PublicID = row.PublicID.Bytes
+	appt.Email = row.Email
+	appt.Phone = row.Phone
+	appt.Status = domain.AppointmentStatus(row.Status)
+	appt.CreatedAt = row.CreatedAt.Time
+	appt.UpdatedAt = row.UpdatedAt.Time
+	appt.Sequence = row.Sequence
+	return appt
+}
+
+// CreateHold reserves the visit date until hold.ExpiresAt, failing if it is already booked or held.
+func (r *Repository) CreateHold(ctx context.Context, hold *domain.Hold) (*domain.Hold, error) {
+	holdDate := pgtype.Timestamptz{Time: *hold.VisitDate, Valid: true}
+	var holdRow sqlcappts.ApptsDateHold
+	err := r.withTx(ctx, func(q *sqlcappts.Queries) error {
+		if err := lockDate(ctx, q, holdDate); err != nil {
+			return err
+		}
+		booked, err := q.DailyAppointmentExists(ctx, holdDate)
+		if err != nil {
+			return fmt.Errorf("daily appointment exists: %w", err)
+		}
+		if booked {
+			return domain.ErrAppointmentDateTaken
+		}
+
+		holdRow, err = q.CreateDateHold(ctx, sqlcappts.CreateDateHoldParams{
+			HoldDate:  holdDate,
+			ExpiresAt: pgtype.Timestamptz{Time: hold.ExpiresAt, Valid: true},
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return domain.ErrAppointmentDateTaken
+			}
+			return fmt.Errorf("create date hold: %w", err)
+		}
+		return nil
 	})
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" {
-				return nil, domain.ErrAppointmentDateTaken
+		return nil, err
+	}
+
+	return &domain.Hold{
+		Token:     holdRow.Token.String(),
+		VisitDate: &holdRow.HoldDate.Time,
+		ExpiresAt: holdRow.ExpiresAt.Time,
+	}, nil
+}
+
+// lockDate serialises writers for a single date so holds and appointments, which live in separate tables, can be
+// checked against each other. Expired holds are cleared while the lock is held.
+func lockDate(ctx context.Context, q *sqlcappts.Queries, date pgtype.Timestamptz) error {
+	if err := q.LockAppointmentDate(ctx, date); err != nil {
+		return fmt.Errorf("lock appointment date: %w", err)
+	}
+	if err := q.DeleteExpiredDateHolds(ctx); err != nil {
+		return fmt.Errorf("delete expired date holds: %w", err)
+	}
+	return nil
+}
+
+// appendEvent writes an event to the outbox, it must be called in the transaction making the change it describes.
+func appendEvent(ctx context.Context, q *sqlcappts.Queries, eventType domain.EventType, appt *domain.Appointment) error {
+	event, err := domain.NewAppointmentEvent(eventType, appt)
+	if err != nil {
+		return err
+	}
+	if err := q.InsertOutboxEvent(ctx, sqlcappts.InsertOutboxEventParams{
+		EventType:   string(event.Type),
+		AggregateID: event.AppointmentID,
+		Payload:     event.Payload,
+	}); err != nil {
+		return fmt.Errorf("insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// PublishPending locks up to limit unpublished events, oldest first, and passes them to publish until it fails. The
+// events that were published are marked as such in the same transaction, so an event is only published again if the
+// process dies before committing. Locked rows are skipped, letting several relays run side by side. The count of
+// published events is returned even when publish fails part way through.
+func (r *Repository) PublishPending(ctx context.Context, limit int32, publish func(ctx context.Context, event *domain.Event) error) (int, error) {
+	published := 0
+	var publishErr error
+	err := r.withTx(ctx, func(q *sqlcappts.Queries) error {
+		rows, err := q.LockPendingOutboxEvents(ctx, limit)
+		if err != nil {
+			return fmt.Errorf("lock pending outbox events: %w", err)
+		}
+		ids := make([]int64, 0, len(rows))
+		for _, row := range rows {
+			if publishErr = publish(ctx, &domain.Event{
+				ID:            row.ID,
+				Type:          domain.EventType(row.EventType),
+				AppointmentID: row.AggregateID,
+				Payload:       row.Payload,
+				CreatedAt:     row.CreatedAt.Time,
+			}); publishErr != nil {
+				break
+			}
+			ids = append(ids, row.ID)
+		}
+		if len(ids) > 0 {
+			if err := q.MarkOutboxEventsPublished(ctx, ids); err != nil {
+				return fmt.Errorf("mark outbox events published: %w", err)
 			}
 		}
-		return nil, fmt.Errorf("create daily appointment: %w", err)
+		published = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if publishErr != nil {
+		return published, fmt.Errorf("publish event: %w", publishErr)
+	}
+	return published, nil
+}
+
+func consumeHold(ctx context.Context, q *sqlcappts.Queries, token string, date pgtype.Timestamptz) error {
+	var holdToken pgtype.UUID
+	if err := holdToken.Scan(token); err != nil {
+		return domain.ErrHoldNotFound
+	}
+	deleted, err := q.DeleteDateHold(ctx, sqlcappts.DeleteDateHoldParams{Token: holdToken, HoldDate: date})
+	if err != nil {
+		return fmt.Errorf("delete date hold: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrHoldNotFound
+	}
+	return nil
+}
+
+// InTx runs fn against a repository bound to a single transaction, committing only if fn succeeds. Writes made through
+// the transactional repository nest as savepoints, so a failed write does not poison the rest of the transaction.
+func (r *Repository) InTx(ctx context.Context, fn func(repo domain.AppointmentPersistorRepository) error) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		return fn(&Repository{db: tx, queries: r.queries.WithTx(tx)})
+	})
+}
+
+func (r *Repository) withTx(ctx context.Context, fn func(q *sqlcappts.Queries) error) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		return fn(r.queries.WithTx(tx))
+	})
+}
+
+// inTx begins a transaction, or a savepoint when r is already bound to one, and commits it only if fn succeeds.
+func (r *Repository) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx) // no-op once committed
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
 	}
+	return nil
+}
 
-	return domain.NewAppointment(appointmentRow.FirstName, appointmentRow.LastName, &appointmentRow.AppointmentDate.Time), nil
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
 }
@@ -0,0 +1,189 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testInsertAppointment(t *testing.T, underTest Store) {
+	appointment, err := underTest.CreateAppointment(t.Context(),
+		&domain.Appointment{
+			FirstName: "first",
+			LastName:  "last",
+			VisitDate: ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)),
+		})
+	require.NoError(t, err)
+	require.Equal(t, "first", appointment.FirstName)
+	require.Equal(t, "last", appointment.LastName)
+	require.Equal(t, time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), appointment.VisitDate.UTC())
+}
+
+func testInsertDuplicateAppointmentError(t *testing.T, underTest Store) {
+	_, err := underTest.CreateAppointment(t.Context(),
+		&domain.Appointment{
+			FirstName: "first",
+			LastName:  "last",
+			VisitDate: ptr.To(time.Date(2024, 12, 25, 9, 0, 0, 0, time.UTC)),
+		})
+	require.NoError(t, err)
+
+	_, dupeErr := underTest.CreateAppointment(t.Context(),
+		&domain.Appointment{
+			FirstName: "first",
+			LastName:  "last",
+			VisitDate: ptr.To(time.Date(2024, 12, 25, 9, 0, 0, 0, time.UTC)),
+		})
+	require.Error(t, dupeErr)
+	require.ErrorIs(t, dupeErr, domain.ErrAppointmentDateTaken)
+}
+
+func testHoldBlocksOtherBookings(t *testing.T, underTest Store) {
+	visitDate := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	hold, err := underTest.CreateHold(t.Context(), domain.NewHold(&visitDate, time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	require.NotEmpty(t, hold.Token)
+
+	_, err = underTest.CreateHold(t.Context(), domain.NewHold(&visitDate, time.Now().Add(time.Hour)))
+	require.ErrorIs(t, err, domain.ErrAppointmentDateTaken)
+
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("other", "patient", &visitDate))
+	require.ErrorIs(t, err, domain.ErrAppointmentDateTaken)
+
+	wrongToken := domain.NewAppointment("first", "last", &visitDate)
+	wrongToken.HoldToken = "0198f0a4-0000-7000-8000-000000000000"
+	_, err = underTest.CreateAppointment(t.Context(), wrongToken)
+	require.ErrorIs(t, err, domain.ErrHoldNotFound)
+
+	withHold := domain.NewAppointment("first", "last", &visitDate)
+	withHold.HoldToken = hold.Token
+	appointment, err := underTest.CreateAppointment(t.Context(), withHold)
+	require.NoError(t, err)
+	require.Equal(t, visitDate, appointment.VisitDate.UTC())
+
+	_, err = underTest.CreateAppointment(t.Context(), withHold)
+	require.ErrorIs(t, err, domain.ErrHoldNotFound)
+}
+
+func testExpiredHoldDoesNotBlockBookings(t *testing.T, underTest Store) {
+	visitDate := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	_, err := underTest.CreateHold(t.Context(), domain.NewHold(&visitDate, time.Now().Add(-time.Second)))
+	require.NoError(t, err)
+
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", &visitDate))
+	require.NoError(t, err)
+}
+
+func testInTxRollsBackOnError(t *testing.T, underTest Store) {
+	visitDate := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	wantErr := errors.New("abort")
+	err := underTest.InTx(t.Context(), func(repo domain.AppointmentPersistorRepository) error {
+		_, err := repo.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", &visitDate))
+		require.NoError(t, err)
+		// a conflicting write must not poison the rest of the transaction
+		_, err = repo.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", &visitDate))
+		require.ErrorIs(t, err, domain.ErrAppointmentDateTaken)
+		_, err = repo.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(visitDate.AddDate(0, 0, 1))))
+		require.NoError(t, err)
+		return wantErr
+	})
+	require.ErrorIs(t, err, wantErr)
+
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", &visitDate))
+	require.NoError(t, err)
+}
+
+func testListAppointments(t *testing.T, underTest Store) {
+	for _, day := range []int{27, 25, 26} {
+		_, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, day, 0, 0, 0, 0, time.UTC))))
+		require.NoError(t, err)
+	}
+
+	var got []time.Time
+	err := underTest.ListAppointments(t.Context(), domain.AppointmentFilter{
+		From:   ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)),
+		To:     ptr.To(time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC)),
+		Status: domain.AppointmentStatusBooked,
+	}, func(appt *domain.Appointment) error {
+		require.NotZero(t, appt.ID)
+		require.NotZero(t, appt.CreatedAt)
+		require.Equal(t, domain.AppointmentStatusBooked, appt.Status)
+		got = append(got, *appt.VisitDate)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC),
+	}, got)
+}
+
+func testGetAppointment(t *testing.T, underTest Store) {
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	got, err := underTest.GetAppointment(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created, got)
+
+	_, err = underTest.GetAppointment(t.Context(), created.ID+1)
+	require.ErrorIs(t, err, domain.ErrAppointmentNotFound)
+
+	require.Equal(t, uuid.Version(7), created.PublicID.Version())
+	got, err = underTest.GetAppointmentByPublicID(t.Context(), created.PublicID)
+	require.NoError(t, err)
+	require.Equal(t, created, got)
+
+	_, err = underTest.GetAppointmentByPublicID(t.Context(), uuid.Must(uuid.NewV7()))
+	require.ErrorIs(t, err, domain.ErrAppointmentNotFound)
+}
+
+func testCreateAppointmentWritesOutboxEvent(t *testing.T, underTest Store) {
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.ErrorIs(t, err, domain.ErrAppointmentDateTaken)
+
+	var got []*domain.Event
+	collect := func(_ context.Context, event *domain.Event) error {
+		got = append(got, event)
+		return nil
+	}
+	published, err := underTest.PublishPending(t.Context(), 10, collect)
+	require.NoError(t, err)
+	require.Equal(t, 1, published, "the failed booking must not leave an event behind")
+	require.Equal(t, domain.EventAppointmentCreated, got[0].Type)
+	require.Equal(t, created.ID, got[0].AppointmentID)
+
+	published, err = underTest.PublishPending(t.Context(), 10, collect)
+	require.NoError(t, err)
+	require.Zero(t, published)
+}
+
+func testPublishPendingKeepsFailedEvents(t *testing.T, underTest Store) {
+	for _, day := range []int{25, 26} {
+		_, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, day, 0, 0, 0, 0, time.UTC))))
+		require.NoError(t, err)
+	}
+
+	calls := 0
+	published, err := underTest.PublishPending(t.Context(), 10, func(_ context.Context, _ *domain.Event) error {
+		calls++
+		if calls == 2 {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	})
+	require.ErrorContains(t, err, "downstream unavailable")
+	require.Equal(t, 1, published)
+
+	published, err = underTest.PublishPending(t.Context(), 10, func(_ context.Context, _ *domain.Event) error { return nil })
+	require.NoError(t, err)
+	require.Equal(t, 1, published)
+}
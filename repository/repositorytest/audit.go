@@ -0,0 +1,43 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testAuditTrail(t *testing.T, underTest Store) {
+	receptionist := domain.WithRequestID(domain.WithPrincipal(t.Context(), &domain.Principal{Kind: domain.PrincipalJWT, Subject: "user-1"}), "host/abc-000001")
+	created, err := underTest.CreateAppointment(receptionist, domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+	_, err = underTest.RescheduleAppointment(t.Context(), created.ID, ptr.To(time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, err)
+	_, err = underTest.CancelAppointment(receptionist, created.ID)
+	require.NoError(t, err)
+	_, err = underTest.CancelAppointment(receptionist, created.ID)
+	require.ErrorIs(t, err, domain.ErrAppointmentCancelled, "failed changes are not audited")
+
+	entries, err := underTest.ListAuditEntries(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	require.Equal(t, domain.AuditBooked, entries[0].Action)
+	require.Equal(t, "jwt:user-1", entries[0].Actor)
+	require.Equal(t, "host/abc-000001", entries[0].RequestID)
+	require.Nil(t, entries[0].Before)
+	require.Equal(t, created.PublicID, entries[0].After.PublicID)
+
+	require.Equal(t, domain.AuditRescheduled, entries[1].Action)
+	require.Equal(t, domain.AuditSystemActor, entries[1].Actor)
+	require.Empty(t, entries[1].RequestID)
+	require.Equal(t, time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC), entries[1].Before.VisitDate.UTC())
+	require.Equal(t, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), entries[1].After.VisitDate.UTC())
+
+	require.Equal(t, domain.AuditCancelled, entries[2].Action)
+	require.Equal(t, domain.AppointmentStatusBooked, entries[2].Before.Status)
+	require.Equal(t, domain.AppointmentStatusCancelled, entries[2].After.Status)
+	require.False(t, entries[2].CreatedAt.IsZero())
+}
@@ -0,0 +1,33 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func testAPIKeys(t *testing.T, underTest Store) {
+	created, err := underTest.CreateAPIKey(t.Context(), "billing", domain.RoleIntegration, "appts_ABCDEF", []byte("hash"))
+	require.NoError(t, err)
+	require.Equal(t, domain.RoleIntegration, created.Role)
+	require.Equal(t, "billing", created.Name)
+	require.Nil(t, created.RevokedAt)
+
+	got, err := underTest.GetActiveAPIKey(t.Context(), []byte("hash"))
+	require.NoError(t, err)
+	require.Equal(t, created, got)
+	_, err = underTest.GetActiveAPIKey(t.Context(), []byte("other"))
+	require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+	require.NoError(t, underTest.RevokeAPIKey(t.Context(), created.ID))
+	require.NoError(t, underTest.RevokeAPIKey(t.Context(), created.ID), "revoking twice is a no-op")
+	_, err = underTest.GetActiveAPIKey(t.Context(), []byte("hash"))
+	require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+	require.ErrorIs(t, underTest.RevokeAPIKey(t.Context(), created.ID+1), domain.ErrAPIKeyNotFound)
+
+	keys, err := underTest.ListAPIKeys(t.Context())
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].RevokedAt, "revoked keys are kept")
+}
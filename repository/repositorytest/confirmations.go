@@ -0,0 +1,55 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/notify"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testConfirmationEmailLifecycle(t *testing.T, underTest Store) {
+	withEmail := domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)))
+	withEmail.Email = "first@example.com"
+	created, err := underTest.CreateAppointment(t.Context(), withEmail)
+	require.NoError(t, err)
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("other", "last", ptr.To(time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+	published, err := underTest.PublishPending(t.Context(), 10, notify.NewConfirmationQueue(underTest).Publish)
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+
+	due, err := underTest.ClaimDueConfirmationEmails(t.Context(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1, "only bookings with an email address are confirmed")
+	require.Equal(t, created.ID, due[0].AppointmentID)
+	require.Equal(t, domain.ConfirmationEmailPending, due[0].Status)
+
+	again, err := underTest.ClaimDueConfirmationEmails(t.Context(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, again, "claimed confirmations are leased")
+
+	require.NoError(t, underTest.EnqueueConfirmationEmail(t.Context(), &domain.Event{ID: due[0].EventID, Type: domain.EventAppointmentCreated, AppointmentID: created.ID}))
+	due[0].Attempts = 1
+	due[0].LastError = "send mail: 421 try again later"
+	due[0].NextAttemptAt = time.Now().Add(-time.Minute)
+	require.NoError(t, underTest.RecordConfirmationAttempt(t.Context(), due[0]))
+
+	retried, err := underTest.ClaimDueConfirmationEmails(t.Context(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, retried, 1, "enqueueing is idempotent")
+	require.Equal(t, due[0].ID, retried[0].ID)
+	require.Equal(t, int32(1), retried[0].Attempts)
+	require.Equal(t, "send mail: 421 try again later", retried[0].LastError)
+
+	retried[0].Status = domain.ConfirmationEmailSent
+	retried[0].Attempts = 2
+	retried[0].LastError = ""
+	retried[0].NextAttemptAt = time.Now().Add(-time.Minute)
+	require.NoError(t, underTest.RecordConfirmationAttempt(t.Context(), retried[0]))
+	sent, err := underTest.ClaimDueConfirmationEmails(t.Context(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, sent, "sent confirmations are never claimed again")
+}
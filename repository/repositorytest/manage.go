@@ -0,0 +1,86 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testCancelFreesTheDate(t *testing.T, underTest Store) {
+	date := ptr.To(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC))
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", date))
+	require.NoError(t, err)
+
+	cancelled, err := underTest.CancelAppointment(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.AppointmentStatusCancelled, cancelled.Status)
+	_, err = underTest.CancelAppointment(t.Context(), created.ID)
+	require.ErrorIs(t, err, domain.ErrAppointmentCancelled)
+	_, err = underTest.CancelAppointment(t.Context(), created.ID+100)
+	require.ErrorIs(t, err, domain.ErrAppointmentNotFound)
+
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("other", "last", date))
+	require.NoError(t, err, "a cancelled appointment does not hold its date")
+}
+
+func testRescheduleAppointment(t *testing.T, underTest Store) {
+	taken := ptr.To(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC))
+	_, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("other", "last", taken))
+	require.NoError(t, err)
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	_, err = underTest.RescheduleAppointment(t.Context(), created.ID, taken)
+	require.ErrorIs(t, err, domain.ErrAppointmentDateTaken)
+
+	moved, err := underTest.RescheduleAppointment(t.Context(), created.ID, ptr.To(time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), moved.VisitDate.UTC())
+}
+
+func testChangesBumpTheSequence(t *testing.T, underTest Store) {
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+	require.Zero(t, created.Sequence)
+	require.False(t, created.UpdatedAt.IsZero())
+
+	moved, err := underTest.RescheduleAppointment(t.Context(), created.ID, ptr.To(time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), moved.Sequence)
+	require.False(t, moved.UpdatedAt.Before(created.UpdatedAt))
+
+	cancelled, err := underTest.CancelAppointment(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), cancelled.Sequence)
+
+	got, err := underTest.GetAppointment(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), got.Sequence)
+	require.Equal(t, cancelled.UpdatedAt.UTC(), got.UpdatedAt.UTC())
+}
+
+func testManageTokens(t *testing.T, underTest Store) {
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	expiresAt := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+	token, err := underTest.CreateManageToken(t.Context(), created.ID, expiresAt)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, token.AppointmentID)
+
+	got, err := underTest.GetManageToken(t.Context(), token.ID)
+	require.NoError(t, err)
+	require.Equal(t, expiresAt, got.ExpiresAt.UTC())
+	require.False(t, got.Revoked)
+
+	require.NoError(t, underTest.RevokeManageToken(t.Context(), token.ID))
+	got, err = underTest.GetManageToken(t.Context(), token.ID)
+	require.NoError(t, err)
+	require.True(t, got.Revoked)
+
+	_, err = underTest.GetManageToken(t.Context(), domain.ManageTokenID{1})
+	require.ErrorIs(t, err, domain.ErrInvalidManageToken)
+}
@@ -0,0 +1,191 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/webhook"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+// racers is how many goroutines each race starts.
+const racers = 10
+
+// race calls fn from racers goroutines released at once, returning what each returned in the order they were started.
+func race[T any](fn func(i int) (T, error)) ([]T, []error) {
+	start := make(chan struct{})
+	results := make([]T, racers)
+	errs := make([]error, racers)
+	var wg sync.WaitGroup
+	for i := range racers {
+		wg.Go(func() {
+			<-start
+			results[i], errs[i] = fn(i)
+		})
+	}
+	close(start)
+	wg.Wait()
+	return results, errs
+}
+
+// requireOneWinner fails unless exactly one of errs is nil and every other is loserErr.
+func requireOneWinner(t *testing.T, errs []error, loserErr error) {
+	t.Helper()
+	won := 0
+	for _, err := range errs {
+		if err == nil {
+			won++
+			continue
+		}
+		require.ErrorIs(t, err, loserErr)
+	}
+	require.Equal(t, 1, won)
+}
+
+func testRaceToBookADate(t *testing.T, underTest Store) {
+	visitDate := ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))
+	_, errs := race(func(int) (*domain.Appointment, error) {
+		return underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", visitDate))
+	})
+	requireOneWinner(t, errs, domain.ErrAppointmentDateTaken)
+}
+
+func testRaceToHoldOrBookADate(t *testing.T, underTest Store) {
+	visitDate := ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))
+	_, errs := race(func(i int) (any, error) {
+		if i%2 == 0 {
+			return underTest.CreateHold(t.Context(), domain.NewHold(visitDate, time.Now().Add(time.Hour)))
+		}
+		return underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", visitDate))
+	})
+	requireOneWinner(t, errs, domain.ErrAppointmentDateTaken)
+}
+
+func testRaceToBookPastThePatientLimit(t *testing.T, underTest Store) {
+	limit := domain.PatientLimit{Max: 1, From: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)}
+	_, errs := race(func(i int) (*domain.Appointment, error) {
+		appt := domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 1+i, 0, 0, 0, 0, time.UTC)))
+		return underTest.CreateAppointmentWithinLimit(t.Context(), appt, limit)
+	})
+	requireOneWinner(t, errs, domain.ErrPatientBookingLimit)
+}
+
+func testRaceToRescheduleOntoADate(t *testing.T, underTest Store) {
+	ids := make([]int32, racers)
+	for i := range ids {
+		created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 1+i, 0, 0, 0, 0, time.UTC))))
+		require.NoError(t, err)
+		ids[i] = created.ID
+	}
+
+	target := ptr.To(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	_, errs := race(func(i int) (*domain.Appointment, error) {
+		return underTest.RescheduleAppointment(t.Context(), ids[i], target)
+	})
+	requireOneWinner(t, errs, domain.ErrAppointmentDateTaken)
+}
+
+func testRaceToCancel(t *testing.T, underTest Store) {
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	_, errs := race(func(int) (*domain.Appointment, error) {
+		return underTest.CancelAppointment(t.Context(), created.ID)
+	})
+	requireOneWinner(t, errs, domain.ErrAppointmentCancelled)
+
+	entries, err := underTest.ListAuditEntries(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "booked and cancelled once")
+}
+
+func testRaceToClaimAReminder(t *testing.T, underTest Store) {
+	created, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	claims, errs := race(func(int) (bool, error) {
+		return underTest.ClaimReminder(t.Context(), created.ID, 24*time.Hour, "email")
+	})
+	claimed := 0
+	for i := range claims {
+		require.NoError(t, errs[i])
+		if claims[i] {
+			claimed++
+		}
+	}
+	require.Equal(t, 1, claimed)
+}
+
+func testRaceToIncrementARateLimit(t *testing.T, underTest Store) {
+	window := time.Now().Truncate(time.Minute)
+	hits, errs := race(func(int) (int, error) {
+		return underTest.IncrementRateLimit(t.Context(), "ip:192.0.2.1", window, window.Add(time.Hour))
+	})
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	want := make([]int, racers)
+	for i := range want {
+		want[i] = i + 1
+	}
+	require.ElementsMatch(t, want, hits, "every hit is counted once")
+}
+
+func testRaceToPublishEvents(t *testing.T, underTest Store) {
+	for day := 1; day <= 5; day++ {
+		_, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, day, 0, 0, 0, 0, time.UTC))))
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	seen := map[int64]int{}
+	counts, errs := race(func(int) (int, error) {
+		return underTest.PublishPending(t.Context(), 10, func(_ context.Context, event *domain.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[event.ID]++
+			return nil
+		})
+	})
+	published := 0
+	for i := range counts {
+		require.NoError(t, errs[i])
+		published += counts[i]
+	}
+	require.Equal(t, 5, published)
+	require.Len(t, seen, 5)
+	for id, times := range seen {
+		require.Equal(t, 1, times, "event %d is published once", id)
+	}
+}
+
+func testRaceToClaimWebhookDeliveries(t *testing.T, underTest Store) {
+	_, err := underTest.CreateWebhookSubscription(t.Context(), &domain.WebhookSubscription{URL: "https://a.example.com", Secret: "a"})
+	require.NoError(t, err)
+	for day := 1; day <= 5; day++ {
+		_, err := underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, day, 0, 0, 0, 0, time.UTC))))
+		require.NoError(t, err)
+	}
+	published, err := underTest.PublishPending(t.Context(), 10, webhook.NewFanout(underTest).Publish)
+	require.NoError(t, err)
+	require.Equal(t, 5, published)
+
+	claims, errs := race(func(int) ([]*domain.DueWebhookDelivery, error) {
+		return underTest.ClaimDueWebhookDeliveries(t.Context(), 2, time.Minute)
+	})
+	seen := map[int64]int{}
+	for i := range claims {
+		require.NoError(t, errs[i])
+		for _, delivery := range claims[i] {
+			seen[delivery.ID]++
+		}
+	}
+	require.Len(t, seen, 5)
+	for id, times := range seen {
+		require.Equal(t, 1, times, "delivery %d is claimed once", id)
+	}
+}
@@ -0,0 +1,68 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testIncrementRateLimit(t *testing.T, underTest Store) {
+	window := time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC)
+	for want := 1; want <= 3; want++ {
+		hits, err := underTest.IncrementRateLimit(t.Context(), "ip:192.0.2.1", window, window.Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, want, hits)
+	}
+	hits, err := underTest.IncrementRateLimit(t.Context(), "ip:192.0.2.1", window.Add(time.Minute), window.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, hits, "each window is counted separately")
+
+	require.NoError(t, underTest.DeleteExpiredRateLimits(t.Context()))
+	hits, err = underTest.IncrementRateLimit(t.Context(), "ip:192.0.2.1", window, window.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, hits, "expired windows are deleted")
+}
+
+func testPatientLimit(t *testing.T, underTest Store) {
+	book := func(first string, last string, email string, phone string, day int) *domain.Appointment {
+		appt := domain.NewAppointment(first, last, ptr.To(time.Date(2024, 12, day, 0, 0, 0, 0, time.UTC)))
+		appt.Email = email
+		appt.Phone = phone
+		created, err := underTest.CreateAppointment(t.Context(), appt)
+		require.NoError(t, err)
+		return created
+	}
+	book("Jane", "Doe", "", "", 20)
+	book("Jane", "Doe", "", "", 23)
+	book("jane", "DOE", "", "", 24)
+	book("Jane", "Doe", "jane.doe@example.org", "", 25)
+	book("J", "Doe", "jane@example.com", "", 26)
+	book("Janet", "Smith", "", "+447700900123", 27)
+	cancelled := book("Jane", "Doe", "jane@example.com", "", 28)
+	_, err := underTest.CancelAppointment(t.Context(), cancelled.ID)
+	require.NoError(t, err)
+
+	from := time.Date(2024, 12, 22, 0, 0, 0, 0, time.UTC)
+	patient := func(email string, phone string, day int) *domain.Appointment {
+		appt := domain.NewAppointment("Jane", "Doe", ptr.To(time.Date(2024, 12, day, 0, 0, 0, 0, time.UTC)))
+		appt.Email = email
+		appt.Phone = phone
+		return appt
+	}
+	_, err = underTest.CreateAppointmentWithinLimit(t.Context(), patient("JANE@example.com", "+447700900123", 29), domain.PatientLimit{Max: 2, From: from})
+	require.ErrorIs(t, err, domain.ErrPatientBookingLimit, "upcoming bookings with the same email, ignoring case, or phone count")
+	created, err := underTest.CreateAppointmentWithinLimit(t.Context(), patient("JANE@example.com", "+447700900123", 29), domain.PatientLimit{Max: 3, From: from})
+	require.NoError(t, err)
+	require.Equal(t, "Jane", created.FirstName)
+
+	_, err = underTest.CreateAppointmentWithinLimit(t.Context(), patient("", "", 30), domain.PatientLimit{Max: 2, From: from})
+	require.ErrorIs(t, err, domain.ErrPatientBookingLimit, "without contact details, upcoming bookings with the same name and none either count")
+	_, err = underTest.CreateAppointmentWithinLimit(t.Context(), patient("", "", 30), domain.PatientLimit{Max: 3, From: from})
+	require.NoError(t, err)
+
+	_, err = underTest.CreateAppointmentWithinLimit(t.Context(), patient("someone@example.net", "", 31), domain.PatientLimit{Max: 1, From: from})
+	require.NoError(t, err, "people sharing a name are told apart by their contact details")
+}
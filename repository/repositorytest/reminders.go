@@ -0,0 +1,67 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testReminderClaims(t *testing.T, underTest Store) {
+	appt := domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)))
+	appt.Email = "first@example.com"
+	created, err := underTest.CreateAppointment(t.Context(), appt)
+	require.NoError(t, err)
+	require.Equal(t, "first@example.com", created.Email)
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("other", "last", ptr.To(time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	from := time.Date(2024, 12, 23, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+	due, err := underTest.ListReminderCandidates(t.Context(), from, to, 24*time.Hour, "email")
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, created.ID, due[0].ID)
+	require.Equal(t, "first@example.com", due[0].Email)
+
+	claimed, err := underTest.ClaimReminder(t.Context(), created.ID, 24*time.Hour, "email")
+	require.NoError(t, err)
+	require.True(t, claimed)
+	claimed, err = underTest.ClaimReminder(t.Context(), created.ID, 24*time.Hour, "email")
+	require.NoError(t, err)
+	require.False(t, claimed, "a reminder is only claimed once")
+
+	due, err = underTest.ListReminderCandidates(t.Context(), from, to, 24*time.Hour, "email")
+	require.NoError(t, err)
+	require.Empty(t, due)
+	due, err = underTest.ListReminderCandidates(t.Context(), from, to, 24*time.Hour, "sms")
+	require.NoError(t, err)
+	require.Len(t, due, 1, "reminders are tracked per channel")
+
+	require.NoError(t, underTest.ReleaseReminder(t.Context(), created.ID, 24*time.Hour, "email"))
+	due, err = underTest.ListReminderCandidates(t.Context(), from, to, 24*time.Hour, "email")
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+}
+
+func testRescheduleForgetsSentReminders(t *testing.T, underTest Store) {
+	appt := domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)))
+	appt.Email = "first@example.com"
+	created, err := underTest.CreateAppointment(t.Context(), appt)
+	require.NoError(t, err)
+	claimed, err := underTest.ClaimReminder(t.Context(), created.ID, 24*time.Hour, "email")
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	_, err = underTest.RescheduleAppointment(t.Context(), created.ID, ptr.To(time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, err)
+
+	from := time.Date(2024, 12, 29, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)
+	due, err := underTest.ListReminderCandidates(t.Context(), from, to, 24*time.Hour, "email")
+	require.NoError(t, err)
+	require.Len(t, due, 1, "the new date is reminded of again")
+	require.Equal(t, created.ID, due[0].ID)
+}
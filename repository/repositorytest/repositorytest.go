@@ -0,0 +1,84 @@
+// Package repositorytest is the conformance suite every repository backend runs, so they all have the semantics the
+// domain relies on: the errors they return, the order they list in and the races they must settle. A backend's tests
+// call Run with a Factory that creates an empty store.
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/notify"
+	"github.com/jcooney/appts/outbox"
+	"github.com/jcooney/appts/reminder"
+	"github.com/jcooney/appts/webhook"
+)
+
+// Store is every method the service needs from a repository.
+type Store interface {
+	domain.AppointmentPersistorRepository
+	domain.AppointmentTransactor
+	domain.AppointmentListerRepository
+	domain.AppointmentGetterRepository
+	domain.LimitedAppointmentPersistor
+	domain.HoldPersistorRepository
+	domain.ManageRepository
+	domain.AuditRepository
+	domain.APIKeyRepository
+	domain.RateLimitRepository
+	domain.WebhookRepository
+	outbox.Store
+	webhook.Store
+	webhook.FanoutStore
+	reminder.Store
+	notify.ConfirmationQueueStore
+	notify.ConfirmationStore
+}
+
+// Factory returns an empty store, which must be safe for concurrent use. It is called once per test, and may skip it.
+type Factory func(t *testing.T) Store
+
+var tests = []struct {
+	name string
+	test func(t *testing.T, underTest Store)
+}{
+	{"InsertAppointment", testInsertAppointment},
+	{"InsertDuplicateAppointmentError", testInsertDuplicateAppointmentError},
+	{"HoldBlocksOtherBookings", testHoldBlocksOtherBookings},
+	{"ExpiredHoldDoesNotBlockBookings", testExpiredHoldDoesNotBlockBookings},
+	{"InTxRollsBackOnError", testInTxRollsBackOnError},
+	{"ListAppointments", testListAppointments},
+	{"GetAppointment", testGetAppointment},
+	{"CreateAppointmentWritesOutboxEvent", testCreateAppointmentWritesOutboxEvent},
+	{"PublishPendingKeepsFailedEvents", testPublishPendingKeepsFailedEvents},
+	{"AuditTrail", testAuditTrail},
+	{"APIKeys", testAPIKeys},
+	{"CancelFreesTheDate", testCancelFreesTheDate},
+	{"RescheduleAppointment", testRescheduleAppointment},
+	{"ChangesBumpTheSequence", testChangesBumpTheSequence},
+	{"ManageTokens", testManageTokens},
+	{"IncrementRateLimit", testIncrementRateLimit},
+	{"PatientLimit", testPatientLimit},
+	{"ReminderClaims", testReminderClaims},
+	{"RescheduleForgetsSentReminders", testRescheduleForgetsSentReminders},
+	{"WebhookDeliveryLifecycle", testWebhookDeliveryLifecycle},
+	{"ConfirmationEmailLifecycle", testConfirmationEmailLifecycle},
+	{"RaceToBookADate", testRaceToBookADate},
+	{"RaceToHoldOrBookADate", testRaceToHoldOrBookADate},
+	{"RaceToBookPastThePatientLimit", testRaceToBookPastThePatientLimit},
+	{"RaceToRescheduleOntoADate", testRaceToRescheduleOntoADate},
+	{"RaceToCancel", testRaceToCancel},
+	{"RaceToClaimAReminder", testRaceToClaimAReminder},
+	{"RaceToIncrementARateLimit", testRaceToIncrementARateLimit},
+	{"RaceToPublishEvents", testRaceToPublishEvents},
+	{"RaceToClaimWebhookDeliveries", testRaceToClaimWebhookDeliveries},
+}
+
+// Run runs the suite against stores made by newStore, each test as a subtest with a store of its own.
+func Run(t *testing.T, newStore Factory) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/webhook"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func testWebhookDeliveryLifecycle(t *testing.T, underTest Store) {
+	everything, err := underTest.CreateWebhookSubscription(t.Context(), &domain.WebhookSubscription{URL: "https://a.example.com", Secret: "a"})
+	require.NoError(t, err)
+	created, err := underTest.CreateWebhookSubscription(t.Context(), &domain.WebhookSubscription{URL: "https://b.example.com", Secret: "b", EventTypes: []domain.EventType{domain.EventAppointmentCreated}})
+	require.NoError(t, err)
+	_, err = underTest.CreateWebhookSubscription(t.Context(), &domain.WebhookSubscription{URL: "https://c.example.com", Secret: "c", EventTypes: []domain.EventType{domain.EventAppointmentCancelled}})
+	require.NoError(t, err)
+
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+	fanout := webhook.NewFanout(underTest)
+	published, err := underTest.PublishPending(t.Context(), 10, fanout.Publish)
+	require.NoError(t, err)
+	require.Equal(t, 1, published)
+
+	due, err := underTest.ClaimDueWebhookDeliveries(t.Context(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 2, "only subscriptions interested in the event get a delivery")
+	require.Equal(t, everything.ID, due[0].SubscriptionID)
+	require.Equal(t, "https://a.example.com", due[0].URL)
+	require.Equal(t, "a", due[0].Secret)
+	require.Equal(t, created.ID, due[1].SubscriptionID)
+
+	again, err := underTest.ClaimDueWebhookDeliveries(t.Context(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, again, "claimed deliveries are leased")
+
+	queued, err := underTest.EnqueueWebhookDeliveries(t.Context(), &domain.Event{ID: due[0].EventID, Type: domain.EventAppointmentCreated, Payload: due[0].Payload})
+	require.NoError(t, err)
+	require.Zero(t, queued, "enqueueing is idempotent")
+
+	due[0].Status = domain.WebhookDeliveryDelivered
+	due[0].Attempts = 1
+	due[0].LastStatusCode = 200
+	require.NoError(t, underTest.RecordWebhookAttempt(t.Context(), due[0].WebhookDelivery))
+	due[1].Status = domain.WebhookDeliveryDead
+	due[1].Attempts = webhook.MaxAttempts
+	due[1].LastStatusCode = 500
+	due[1].LastError = "unexpected status 500"
+	require.NoError(t, underTest.RecordWebhookAttempt(t.Context(), due[1].WebhookDelivery))
+
+	deliveries, err := underTest.ListWebhookDeliveries(t.Context(), created.ID, domain.WebhookDeliveryDead)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, int32(webhook.MaxAttempts), deliveries[0].Attempts)
+	require.Equal(t, int32(500), deliveries[0].LastStatusCode)
+	require.Equal(t, "unexpected status 500", deliveries[0].LastError)
+
+	_, err = underTest.RetryWebhookDelivery(t.Context(), everything.ID, due[0].ID)
+	require.ErrorIs(t, err, domain.ErrWebhookDeliveryNotDead)
+	_, err = underTest.RetryWebhookDelivery(t.Context(), everything.ID, due[1].ID)
+	require.ErrorIs(t, err, domain.ErrWebhookDeliveryNotFound, "deliveries belong to a single subscription")
+	retried, err := underTest.RetryWebhookDelivery(t.Context(), created.ID, due[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.WebhookDeliveryPending, retried.Status)
+	require.Zero(t, retried.Attempts)
+
+	require.NoError(t, underTest.DeleteWebhookSubscription(t.Context(), created.ID))
+	require.ErrorIs(t, underTest.DeleteWebhookSubscription(t.Context(), created.ID), domain.ErrWebhookNotFound)
+	_, err = underTest.ListWebhookDeliveries(t.Context(), created.ID, "")
+	require.ErrorIs(t, err, domain.ErrWebhookNotFound)
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/sqlite/gen"
+	"k8s.io/utils/ptr"
+)
+
+// auditSnapshot is how an appointment is stored in the audit log, in the same format as the Postgres repository so the
+// two can be compared.
+type auditSnapshot struct {
+	ID        int32                    `json:"id"`
+	PublicID  uuid.UUID                `json:"publicId"`
+	FirstName string                   `json:"firstName"`
+	LastName  string                   `json:"lastName"`
+	VisitDate time.Time                `json:"visitDate"`
+	Email     string                   `json:"email,omitempty"`
+	Phone     string                   `json:"phone,omitempty"`
+	Status    domain.AppointmentStatus `json:"status"`
+	CreatedAt time.Time                `json:"createdAt"`
+}
+
+func (r *Repository) ListAuditEntries(ctx context.Context, appointmentID int32) ([]*domain.AuditEntry, error) {
+	rows, err := r.queries.ListAuditEntries(ctx, int64(appointmentID))
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+	entries := make([]*domain.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entry, err := toAuditEntry(row)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// appendAudit records who changed the appointment, it must be called in the transaction making the change.
+func appendAudit(ctx context.Context, q *sqlcsqlite.Queries, now time.Time, action domain.AuditAction, before *domain.Appointment, after *domain.Appointment) error {
+	var beforeJSON sql.NullString
+	if before != nil {
+		data, err := json.Marshal(toAuditSnapshot(before))
+		if err != nil {
+			return fmt.Errorf("marshal audit snapshot: %w", err)
+		}
+		beforeJSON = sql.NullString{String: string(data), Valid: true}
+	}
+	afterJSON, err := json.Marshal(toAuditSnapshot(after))
+	if err != nil {
+		return fmt.Errorf("marshal audit snapshot: %w", err)
+	}
+	if err := q.InsertAuditEntry(ctx, sqlcsqlite.InsertAuditEntryParams{
+		AppointmentID: int64(after.ID),
+		Action:        string(action),
+		Actor:         domain.AuditActor(ctx),
+		RequestID:     domain.RequestIDFromContext(ctx),
+		Before:        beforeJSON,
+		After:         string(afterJSON),
+		Now:           now.UnixMicro(),
+	}); err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// getAppointmentBefore reads the appointment as it is before a change. The write lock every transaction takes keeps it
+// from changing underneath the caller.
+func getAppointmentBefore(ctx context.Context, q *sqlcsqlite.Queries, id int32) (*domain.Appointment, error) {
+	appointmentRow, err := q.GetDailyAppointment(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get daily appointment: %w", err)
+	}
+	return toAppointment(appointmentRow)
+}
+
+func toAuditSnapshot(appt *domain.Appointment) auditSnapshot {
+	return auditSnapshot{
+		ID:        appt.ID,
+		PublicID:  appt.PublicID,
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+		VisitDate: *appt.VisitDate,
+		Email:     appt.Email,
+		Phone:     appt.Phone,
+		Status:    appt.Status,
+		CreatedAt: appt.CreatedAt,
+	}
+}
+
+func fromAuditSnapshot(data string) (*domain.Appointment, error) {
+	var snapshot auditSnapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return nil, fmt.Errorf("unmarshal audit snapshot: %w", err)
+	}
+	return &domain.Appointment{
+		ID:        snapshot.ID,
+		PublicID:  snapshot.PublicID,
+		FirstName: snapshot.FirstName,
+		LastName:  snapshot.LastName,
+		VisitDate: ptr.To(snapshot.VisitDate),
+		Email:     snapshot.Email,
+		Phone:     snapshot.Phone,
+		Status:    snapshot.Status,
+		CreatedAt: snapshot.CreatedAt,
+	}, nil
+}
+
+func toAuditEntry(row sqlcsqlite.AppointmentAudit) (*domain.AuditEntry, error) {
+	entry := &domain.AuditEntry{
+		ID:            row.ID,
+		AppointmentID: int32(row.AppointmentID),
+		Action:        domain.AuditAction(row.Action),
+		Actor:         row.Actor,
+		RequestID:     row.RequestID,
+		CreatedAt:     *fromMicros(row.CreatedAt),
+	}
+	var err error
+	if row.Before.Valid {
+		if entry.Before, err = fromAuditSnapshot(row.Before.String); err != nil {
+			return nil, err
+		}
+	}
+	if entry.After, err = fromAuditSnapshot(row.After); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/sqlite/gen"
+)
+
+func (r *Repository) CreateAPIKey(ctx context.Context, name string, role domain.Role, prefix string, hash []byte) (*domain.APIKey, error) {
+	row, err := r.queries.CreateAPIKey(ctx, sqlcsqlite.CreateAPIKeyParams{
+		Name:    name,
+		Role:    string(role),
+		Prefix:  prefix,
+		KeyHash: hash,
+		Now:     r.now().UnixMicro(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create api key: %w", err)
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := r.queries.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	keys := make([]*domain.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, toAPIKey(row))
+	}
+	return keys, nil
+}
+
+func (r *Repository) GetActiveAPIKey(ctx context.Context, hash []byte) (*domain.APIKey, error) {
+	row, err := r.queries.GetActiveAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("get active api key by hash: %w", err)
+	}
+	return toAPIKey(row), nil
+}
+
+func (r *Repository) RevokeAPIKey(ctx context.Context, id int32) error {
+	revoked, err := r.queries.RevokeAPIKey(ctx, sqlcsqlite.RevokeAPIKeyParams{Now: r.now().UnixMicro(), ID: int64(id)})
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if revoked == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func toAPIKey(row sqlcsqlite.ApiKey) *domain.APIKey {
+	key := &domain.APIKey{
+		ID:        int32(row.ID),
+		Name:      row.Name,
+		Role:      domain.Role(row.Role),
+		Prefix:    row.Prefix,
+		CreatedAt: *fromMicros(row.CreatedAt),
+	}
+	if row.RevokedAt.Valid {
+		key.RevokedAt = fromMicros(row.RevokedAt.Int64)
+	}
+	return key
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/sqlite/gen"
+)
+
+// EnqueueConfirmationEmail queues a confirmation of the appointment the event is about, unless the patient gave no
+// email address. Enqueueing the same event twice is a no-op, so it is safe to call from an at-least-once relay.
+func (r *Repository) EnqueueConfirmationEmail(ctx context.Context, event *domain.Event) error {
+	_, err := r.queries.EnqueueConfirmationEmail(ctx, sqlcsqlite.EnqueueConfirmationEmailParams{
+		EventID:       event.ID,
+		Now:           r.now().UnixMicro(),
+		AppointmentID: int64(event.AppointmentID),
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue confirmation email: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueConfirmationEmails takes up to limit pending confirmations whose next attempt is due and pushes that attempt
+// back by lease, so other senders leave them alone while they are in flight.
+func (r *Repository) ClaimDueConfirmationEmails(ctx context.Context, limit int32, lease time.Duration) ([]*domain.ConfirmationEmail, error) {
+	var due []*domain.ConfirmationEmail
+	err := r.withTx(ctx, func(q *sqlcsqlite.Queries) error {
+		now := r.now()
+		rows, err := q.ListDueConfirmationEmails(ctx, sqlcsqlite.ListDueConfirmationEmailsParams{
+			Now:       now.UnixMicro(),
+			BatchSize: int64(limit),
+		})
+		if err != nil {
+			return fmt.Errorf("list due confirmation emails: %w", err)
+		}
+		leasedUntil := now.Add(lease).UnixMicro()
+		due = make([]*domain.ConfirmationEmail, 0, len(rows))
+		for _, row := range rows {
+			if err := q.LeaseConfirmationEmail(ctx, sqlcsqlite.LeaseConfirmationEmailParams{
+				NextAttemptAt: leasedUntil,
+				ID:            row.ID,
+			}); err != nil {
+				return fmt.Errorf("lease confirmation email: %w", err)
+			}
+			row.NextAttemptAt = leasedUntil
+			due = append(due, toConfirmationEmail(row))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+// RecordConfirmationAttempt saves the outcome of an attempt, as set on email by the sender.
+func (r *Repository) RecordConfirmationAttempt(ctx context.Context, email *domain.ConfirmationEmail) error {
+	err := r.queries.RecordConfirmationEmailAttempt(ctx, sqlcsqlite.RecordConfirmationEmailAttemptParams{
+		Status:        string(email.Status),
+		Attempts:      int64(email.Attempts),
+		NextAttemptAt: email.NextAttemptAt.UnixMicro(),
+		LastError:     sql.NullString{String: email.LastError, Valid: email.LastError != ""},
+		Now:           r.now().UnixMicro(),
+		ID:            email.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("record confirmation email attempt: %w", err)
+	}
+	return nil
+}
+
+func toConfirmationEmail(row sqlcsqlite.ConfirmationEmail) *domain.ConfirmationEmail {
+	return &domain.ConfirmationEmail{
+		ID:            row.ID,
+		EventID:       row.EventID,
+		AppointmentID: int32(row.AppointmentID),
+		Status:        domain.ConfirmationEmailStatus(row.Status),
+		Attempts:      int32(row.Attempts),
+		NextAttemptAt: *fromMicros(row.NextAttemptAt),
+		LastError:     row.LastError.String,
+		CreatedAt:     *fromMicros(row.CreatedAt),
+		UpdatedAt:     *fromMicros(row.UpdatedAt),
+	}
+}
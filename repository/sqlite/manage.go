@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/sqlite/gen"
+)
+
+func (r *Repository) CreateManageToken(ctx context.Context, appointmentID int32, expiresAt time.Time) (*domain.ManageToken, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("new manage token id: %w", err)
+	}
+	row, err := r.queries.CreateManageToken(ctx, sqlcsqlite.CreateManageTokenParams{
+		ID:            id.String(),
+		AppointmentID: int64(appointmentID),
+		ExpiresAt:     expiresAt.UnixMicro(),
+		Now:           r.now().UnixMicro(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create manage token: %w", err)
+	}
+	return toManageToken(row)
+}
+
+func (r *Repository) GetManageToken(ctx context.Context, id domain.ManageTokenID) (*domain.ManageToken, error) {
+	row, err := r.queries.GetManageToken(ctx, uuid.UUID(id).String())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrInvalidManageToken
+		}
+		return nil, fmt.Errorf("get manage token: %w", err)
+	}
+	return toManageToken(row)
+}
+
+func (r *Repository) RevokeManageToken(ctx context.Context, id domain.ManageTokenID) error {
+	revoked, err := r.queries.RevokeManageToken(ctx, sqlcsqlite.RevokeManageTokenParams{
+		RevokedAt: sql.NullInt64{Int64: r.now().UnixMicro(), Valid: true},
+		ID:        uuid.UUID(id).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("revoke manage token: %w", err)
+	}
+	if revoked == 0 {
+		return domain.ErrInvalidManageToken
+	}
+	return nil
+}
+
+// CancelAppointment marks a booked appointment cancelled, freeing its date.
+func (r *Repository) CancelAppointment(ctx context.Context, id int32) (*domain.Appointment, error) {
+	var appt *domain.Appointment
+	err := r.withTx(ctx, func(q *sqlcsqlite.Queries) error {
+		before, err := getAppointmentBefore(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		now := r.now()
+		appointmentRow, err := q.CancelDailyAppointment(ctx, sqlcsqlite.CancelDailyAppointmentParams{
+			Now: now.UnixMicro(),
+			ID:  int64(id),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrAppointmentCancelled // it exists, as it was just read
+			}
+			return fmt.Errorf("cancel daily appointment: %w", err)
+		}
+		if appt, err = toAppointment(appointmentRow); err != nil {
+			return err
+		}
+		if err := appendAudit(ctx, q, now, domain.AuditCancelled, before, appt); err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, now, domain.EventAppointmentCancelled, appt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appt, nil
+}
+
+// RescheduleAppointment moves a booked appointment to date under the same rules as booking it: dates that are booked
+// or held by anyone are taken. The reminders already sent for the old date are forgotten, so the new date gets its own.
+func (r *Repository) RescheduleAppointment(ctx context.Context, id int32, date *time.Time) (*domain.Appointment, error) {
+	visitDate := date.UnixMicro()
+	var appt *domain.Appointment
+	err := r.withTx(ctx, func(q *sqlcsqlite.Queries) error {
+		now := r.now()
+		if err := deleteExpiredHolds(ctx, q, now); err != nil {
+			return err
+		}
+		held, err := q.DateHoldExists(ctx, visitDate)
+		if err != nil {
+			return fmt.Errorf("date hold exists: %w", err)
+		}
+		if held != 0 {
+			return domain.ErrAppointmentDateTaken
+		}
+		before, err := getAppointmentBefore(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		appointmentRow, err := q.RescheduleDailyAppointment(ctx, sqlcsqlite.RescheduleDailyAppointmentParams{
+			AppointmentDate: visitDate,
+			Now:             now.UnixMicro(),
+			ID:              int64(id),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrAppointmentCancelled // it exists, as it was just read
+			}
+			if isUniqueViolation(err) {
+				return domain.ErrAppointmentDateTaken
+			}
+			return fmt.Errorf("reschedule daily appointment: %w", err)
+		}
+		if appt, err = toAppointment(appointmentRow); err != nil {
+			return err
+		}
+		if err := q.DeleteSentReminders(ctx, int64(id)); err != nil {
+			return fmt.Errorf("delete sent reminders: %w", err)
+		}
+		if err := appendAudit(ctx, q, now, domain.AuditRescheduled, before, appt); err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, now, domain.EventAppointmentRescheduled, appt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appt, nil
+}
+
+func toManageToken(row sqlcsqlite.ManageToken) (*domain.ManageToken, error) {
+	id, err := uuid.Parse(row.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse manage token id: %w", err)
+	}
+	return &domain.ManageToken{
+		ID:            domain.ManageTokenID(id),
+		AppointmentID: int32(row.AppointmentID),
+		ExpiresAt:     *fromMicros(row.ExpiresAt),
+		Revoked:       row.RevokedAt.Valid,
+	}, nil
+}
@@ -0,0 +1,20 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// migrationVersion is written out rather than generated, as schema_migrations belongs to golang-migrate and is not
+// in the schema sqlc reads.
+const migrationVersion = `select version, dirty from schema_migrations limit 1`
+
+// MigrationVersion returns the version golang-migrate last applied and whether it failed part way.
+func (r *Repository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	if err := r.conn().QueryRowContext(ctx, migrationVersion).Scan(&version, &dirty); err != nil {
+		return 0, false, fmt.Errorf("migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
@@ -0,0 +1,247 @@
+-- name: CreateDailyAppointment :one
+insert into daily_appointments (public_id, first_name, last_name, appointment_date, email, phone, created_at, updated_at)
+values (sqlc.arg(public_id), sqlc.arg(first_name), sqlc.arg(last_name), sqlc.arg(appointment_date), sqlc.arg(email), sqlc.arg(phone), sqlc.arg(now), sqlc.arg(now))
+returning *;
+
+-- name: CreateDateHold :one
+insert into date_holds (token, hold_date, expires_at)
+values (sqlc.arg(token), sqlc.arg(hold_date), sqlc.arg(expires_at))
+returning *;
+
+-- name: DailyAppointmentExists :one
+select exists(select 1 from daily_appointments where appointment_date = sqlc.arg(appointment_date) and status = 'booked');
+
+-- name: DateHoldExists :one
+select exists(select 1 from date_holds where hold_date = sqlc.arg(hold_date));
+
+-- name: DeleteDateHold :execrows
+delete from date_holds
+where token = sqlc.arg(token) and hold_date = sqlc.arg(hold_date);
+
+-- name: DeleteExpiredDateHolds :exec
+delete from date_holds
+where expires_at <= sqlc.arg(now);
+
+-- name: ListDailyAppointmentsPage :many
+select * from daily_appointments
+where (cast(sqlc.narg(from_date) as integer) is null or appointment_date >= sqlc.narg(from_date))
+  and (cast(sqlc.narg(to_date) as integer) is null or appointment_date <= sqlc.narg(to_date))
+  and (cast(sqlc.narg(status) as text) is null or status = sqlc.narg(status))
+  and (cast(sqlc.narg(after_date) as integer) is null or (appointment_date, ID) > (sqlc.narg(after_date), cast(sqlc.arg(after_id) as integer)))
+order by appointment_date, ID
+limit sqlc.arg(page_size);
+
+-- name: GetDailyAppointment :one
+select * from daily_appointments
+where ID = sqlc.arg(id);
+
+-- name: GetDailyAppointmentByPublicID :one
+select * from daily_appointments
+where public_id = sqlc.arg(public_id);
+
+-- name: InsertOutboxEvent :exec
+insert into outbox_events (event_type, aggregate_id, payload, created_at)
+values (sqlc.arg(event_type), sqlc.arg(aggregate_id), sqlc.arg(payload), sqlc.arg(now));
+
+-- name: ListPendingOutboxEvents :many
+select * from outbox_events
+where published_at is null
+order by ID
+limit sqlc.arg(batch_size);
+
+-- name: MarkOutboxEventPublished :exec
+update outbox_events
+set published_at = sqlc.arg(published_at)
+where ID = sqlc.arg(id);
+
+-- name: CreateWebhookSubscription :one
+insert into webhook_subscriptions (url, secret, event_types, created_at)
+values (sqlc.arg(url), sqlc.arg(secret), sqlc.arg(event_types), sqlc.arg(now))
+returning *;
+
+-- name: ListWebhookSubscriptions :many
+select * from webhook_subscriptions
+order by ID;
+
+-- name: GetWebhookSubscription :one
+select * from webhook_subscriptions
+where ID = sqlc.arg(id);
+
+-- name: DeleteWebhookSubscription :execrows
+delete from webhook_subscriptions
+where ID = sqlc.arg(id);
+
+-- name: EnqueueWebhookDeliveries :execrows
+-- EnqueueWebhookDeliveries matches event types against the JSON array each subscription stores, an empty array
+-- matching every type.
+insert into webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at, created_at, updated_at)
+select ID, cast(sqlc.arg(event_id) as integer), cast(sqlc.arg(event_type) as text), cast(sqlc.arg(payload) as text), cast(sqlc.arg(now) as integer), cast(sqlc.arg(now) as integer), cast(sqlc.arg(now) as integer)
+from webhook_subscriptions
+where json_array_length(event_types) = 0 or exists (select 1 from json_each(event_types) where value = cast(sqlc.arg(event_type) as text))
+order by ID
+on conflict (subscription_id, event_id) do nothing;
+
+-- name: ListDueWebhookDeliveries :many
+select d.*, s.url, s.secret
+from webhook_deliveries d
+join webhook_subscriptions s on s.ID = d.subscription_id
+where d.status = 'pending' and d.next_attempt_at <= sqlc.arg(now)
+order by d.next_attempt_at, d.ID
+limit sqlc.arg(batch_size);
+
+-- name: LeaseWebhookDelivery :exec
+update webhook_deliveries
+set next_attempt_at = sqlc.arg(next_attempt_at)
+where ID = sqlc.arg(id);
+
+-- name: RecordWebhookDeliveryAttempt :exec
+update webhook_deliveries
+set status = sqlc.arg(status),
+    attempts = sqlc.arg(attempts),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_status_code = sqlc.narg(last_status_code),
+    last_error = sqlc.narg(last_error),
+    updated_at = sqlc.arg(now)
+where ID = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+select * from webhook_deliveries
+where subscription_id = sqlc.arg(subscription_id)
+  and (cast(sqlc.narg(status) as text) is null or status = sqlc.narg(status))
+order by ID desc
+limit sqlc.arg(page_size);
+
+-- name: GetWebhookDelivery :one
+select * from webhook_deliveries
+where ID = sqlc.arg(id) and subscription_id = sqlc.arg(subscription_id);
+
+-- name: RetryDeadWebhookDelivery :one
+update webhook_deliveries
+set status = 'pending', attempts = 0, next_attempt_at = sqlc.arg(now), updated_at = sqlc.arg(now)
+where ID = sqlc.arg(id) and subscription_id = sqlc.arg(subscription_id) and status = 'dead'
+returning *;
+
+-- name: ListReminderCandidates :many
+select * from daily_appointments a
+where a.status = 'booked'
+  and a.appointment_date >= sqlc.arg(from_date)
+  and a.appointment_date <= sqlc.arg(to_date)
+  and not exists (
+    select 1 from sent_reminders r
+    where r.appointment_id = a.ID and r.lead_minutes = sqlc.arg(lead_minutes) and r.channel = sqlc.arg(channel)
+  )
+order by a.appointment_date, a.ID;
+
+-- name: ClaimReminder :execrows
+insert into sent_reminders (appointment_id, lead_minutes, channel, sent_at)
+values (sqlc.arg(appointment_id), sqlc.arg(lead_minutes), sqlc.arg(channel), sqlc.arg(now))
+on conflict do nothing;
+
+-- name: ReleaseReminder :exec
+delete from sent_reminders
+where appointment_id = sqlc.arg(appointment_id) and lead_minutes = sqlc.arg(lead_minutes) and channel = sqlc.arg(channel);
+
+-- name: DeleteSentReminders :exec
+delete from sent_reminders
+where appointment_id = sqlc.arg(appointment_id);
+
+-- name: CancelDailyAppointment :one
+update daily_appointments
+set status = 'cancelled', sequence = sequence + 1, updated_at = sqlc.arg(now)
+where ID = sqlc.arg(id) and status = 'booked'
+returning *;
+
+-- name: RescheduleDailyAppointment :one
+update daily_appointments
+set appointment_date = sqlc.arg(appointment_date), sequence = sequence + 1, updated_at = sqlc.arg(now)
+where ID = sqlc.arg(id) and status = 'booked'
+returning *;
+
+-- name: CreateManageToken :one
+insert into manage_tokens (ID, appointment_id, expires_at, created_at)
+values (sqlc.arg(id), sqlc.arg(appointment_id), sqlc.arg(expires_at), sqlc.arg(now))
+returning *;
+
+-- name: GetManageToken :one
+select * from manage_tokens
+where ID = sqlc.arg(id);
+
+-- name: RevokeManageToken :execrows
+update manage_tokens
+set revoked_at = sqlc.arg(revoked_at)
+where ID = sqlc.arg(id) and revoked_at is null;
+
+-- name: CreateAPIKey :one
+insert into api_keys (name, role, prefix, key_hash, created_at)
+values (sqlc.arg(name), sqlc.arg(role), sqlc.arg(prefix), sqlc.arg(key_hash), sqlc.arg(now))
+returning *;
+
+-- name: ListAPIKeys :many
+select * from api_keys
+order by ID;
+
+-- name: GetActiveAPIKeyByHash :one
+select * from api_keys
+where key_hash = sqlc.arg(key_hash) and revoked_at is null;
+
+-- name: RevokeAPIKey :execrows
+update api_keys
+set revoked_at = coalesce(revoked_at, cast(sqlc.arg(now) as integer))
+where ID = sqlc.arg(id);
+
+-- name: IncrementRateLimit :one
+insert into rate_limits (bucket, window_start, hits, expires_at)
+values (sqlc.arg(bucket), sqlc.arg(window_start), 1, sqlc.arg(expires_at))
+on conflict (bucket, window_start) do update set hits = rate_limits.hits + 1
+returning hits;
+
+-- name: DeleteExpiredRateLimits :exec
+delete from rate_limits
+where expires_at <= sqlc.arg(now);
+
+-- name: CountPatientAppointments :one
+-- CountPatientAppointments matches on email or phone, and on name only between bookings that have neither, comparing
+-- with SQLite's lower, which only folds ASCII letters.
+select count(*) from daily_appointments
+where status = 'booked'
+  and appointment_date >= sqlc.arg(from_date)
+  and ((cast(sqlc.arg(email) as text) <> '' and lower(email) = lower(cast(sqlc.arg(email) as text)))
+    or (cast(sqlc.arg(phone) as text) <> '' and phone = cast(sqlc.arg(phone) as text))
+    or (cast(sqlc.arg(email) as text) = '' and cast(sqlc.arg(phone) as text) = '' and email = '' and phone = ''
+      and lower(first_name) = lower(cast(sqlc.arg(first_name) as text)) and lower(last_name) = lower(cast(sqlc.arg(last_name) as text))));
+
+-- name: InsertAuditEntry :exec
+insert into appointment_audit (appointment_id, action, actor, request_id, before, after, created_at)
+values (sqlc.arg(appointment_id), sqlc.arg(action), sqlc.arg(actor), sqlc.arg(request_id), sqlc.arg(before), sqlc.arg(after), sqlc.arg(now));
+
+-- name: ListAuditEntries :many
+select * from appointment_audit
+where appointment_id = sqlc.arg(appointment_id)
+order by ID;
+
+-- name: EnqueueConfirmationEmail :execrows
+insert into confirmation_emails (event_id, appointment_id, next_attempt_at, created_at, updated_at)
+select cast(sqlc.arg(event_id) as integer), ID, cast(sqlc.arg(now) as integer), cast(sqlc.arg(now) as integer), cast(sqlc.arg(now) as integer)
+from daily_appointments
+where ID = sqlc.arg(appointment_id) and email <> ''
+on conflict (event_id) do nothing;
+
+-- name: ListDueConfirmationEmails :many
+select * from confirmation_emails
+where status = 'pending' and next_attempt_at <= sqlc.arg(now)
+order by next_attempt_at, ID
+limit sqlc.arg(batch_size);
+
+-- name: LeaseConfirmationEmail :exec
+update confirmation_emails
+set next_attempt_at = sqlc.arg(next_attempt_at)
+where ID = sqlc.arg(id);
+
+-- name: RecordConfirmationEmailAttempt :exec
+update confirmation_emails
+set status = sqlc.arg(status),
+    attempts = sqlc.arg(attempts),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_error = sqlc.narg(last_error),
+    updated_at = sqlc.arg(now)
+where ID = sqlc.arg(id);
@@ -0,0 +1,29 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jcooney/appts/repository/sqlite/gen"
+)
+
+// IncrementRateLimit upserts the bucket's counter for the window, so concurrent hits are all counted.
+func (r *Repository) IncrementRateLimit(ctx context.Context, bucket string, windowStart time.Time, expiresAt time.Time) (int, error) {
+	hits, err := r.queries.IncrementRateLimit(ctx, sqlcsqlite.IncrementRateLimitParams{
+		Bucket:      bucket,
+		WindowStart: windowStart.UnixMicro(),
+		ExpiresAt:   expiresAt.UnixMicro(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("increment rate limit: %w", err)
+	}
+	return int(hits), nil
+}
+
+func (r *Repository) DeleteExpiredRateLimits(ctx context.Context) error {
+	if err := r.queries.DeleteExpiredRateLimits(ctx, r.now().UnixMicro()); err != nil {
+		return fmt.Errorf("delete expired rate limits: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/sqlite/gen"
+)
+
+// ListReminderCandidates returns booked appointments on visit dates from to to, inclusive, that have not had the
+// reminder for lead sent over channel.
+func (r *Repository) ListReminderCandidates(ctx context.Context, from time.Time, to time.Time, lead time.Duration, channel string) ([]*domain.Appointment, error) {
+	rows, err := r.queries.ListReminderCandidates(ctx, sqlcsqlite.ListReminderCandidatesParams{
+		FromDate:    from.UnixMicro(),
+		ToDate:      to.UnixMicro(),
+		LeadMinutes: leadMinutes(lead),
+		Channel:     channel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list reminder candidates: %w", err)
+	}
+	return toAppointments(rows)
+}
+
+// ClaimReminder records the reminder as sent, returning false if it already was. Claiming before sending means a
+// restart can never send a reminder twice.
+func (r *Repository) ClaimReminder(ctx context.Context, appointmentID int32, lead time.Duration, channel string) (bool, error) {
+	claimed, err := r.queries.ClaimReminder(ctx, sqlcsqlite.ClaimReminderParams{
+		AppointmentID: int64(appointmentID),
+		LeadMinutes:   leadMinutes(lead),
+		Channel:       channel,
+		Now:           r.now().UnixMicro(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("claim reminder: %w", err)
+	}
+	return claimed == 1, nil
+}
+
+// ReleaseReminder forgets a claimed reminder that could not be sent, so it is tried again.
+func (r *Repository) ReleaseReminder(ctx context.Context, appointmentID int32, lead time.Duration, channel string) error {
+	if err := r.queries.ReleaseReminder(ctx, sqlcsqlite.ReleaseReminderParams{
+		AppointmentID: int64(appointmentID),
+		LeadMinutes:   leadMinutes(lead),
+		Channel:       channel,
+	}); err != nil {
+		return fmt.Errorf("release reminder: %w", err)
+	}
+	return nil
+}
+
+func leadMinutes(lead time.Duration) int64 {
+	return int64(lead / time.Minute)
+}
@@ -0,0 +1,435 @@
+// Package sqlite is a repository kept in a single SQLite file, for running the service on one machine without a
+// Postgres server. It uses a pure Go driver, so the binary still builds without cgo, and has the same semantics as the
+// Postgres repository down to the domain errors it returns. Its migrations are schema.SQLite.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/sqlite/gen"
+	moderncsqlite "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// dsnOptions enforce foreign keys, let reads carry on alongside the writer with WAL, wait for the write lock rather
+// than failing at once, and take that lock as a transaction begins. Taking it up front serialises writers the way the
+// date locks of the Postgres repository do, and stops a reading transaction failing when it later needs to write.
+const dsnOptions = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+// Open opens the database file at path, creating it if it does not exist.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?"+dsnOptions)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+// Repository is safe for concurrent use by a single process. Times are stored as microseconds since the unix epoch,
+// which is the precision Postgres keeps.
+type Repository struct {
+	db        *sql.DB
+	tx        *sql.Tx // set on the repository InTx hands out, whose writes nest as savepoints
+	queries   *sqlcsqlite.Queries
+	now       func() time.Time
+	publishMu *sync.Mutex
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db, queries: sqlcsqlite.New(db), now: time.Now, publishMu: &sync.Mutex{}}
+}
+
+// conn is for the queries sqlc does not generate, see MigrationVersion.
+func (r *Repository) conn() sqlcsqlite.DBTX {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// CreateAppointment books the visit date, consuming the hold identified by appt.HoldToken if one is given. Dates held
+// by anyone else are reported as taken.
+func (r *Repository) CreateAppointment(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	return r.createAppointment(ctx, appt, nil)
+}
+
+// CreateAppointmentWithinLimit books like CreateAppointment, but only while the patient is under limit. The write lock
+// every transaction takes as it begins stands in for the patient locks of the Postgres repository.
+func (r *Repository) CreateAppointmentWithinLimit(ctx context.Context, appt *domain.Appointment, limit domain.PatientLimit) (*domain.Appointment, error) {
+	return r.createAppointment(ctx, appt, &limit)
+}
+
+func (r *Repository) createAppointment(ctx context.Context, appt *domain.Appointment, limit *domain.PatientLimit) (*domain.Appointment, error) {
+	visitDate := appt.VisitDate.UnixMicro()
+	publicID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("new public id: %w", err)
+	}
+	var created *domain.Appointment
+	err = r.withTx(ctx, func(q *sqlcsqlite.Queries) error {
+		now := r.now()
+		if err := deleteExpiredHolds(ctx, q, now); err != nil {
+			return err
+		}
+		if limit != nil {
+			if err := checkPatientLimit(ctx, q, appt, *limit); err != nil {
+				return err
+			}
+		}
+		if appt.HoldToken != "" {
+			if err := consumeHold(ctx, q, appt.HoldToken, visitDate); err != nil {
+				return err
+			}
+		}
+		held, err := q.DateHoldExists(ctx, visitDate)
+		if err != nil {
+			return fmt.Errorf("date hold exists: %w", err)
+		}
+		if held != 0 {
+			return domain.ErrAppointmentDateTaken
+		}
+
+		appointmentRow, err := q.CreateDailyAppointment(ctx, sqlcsqlite.CreateDailyAppointmentParams{
+			PublicID:        publicID.String(),
+			FirstName:       appt.FirstName,
+			LastName:        appt.LastName,
+			AppointmentDate: visitDate,
+			Email:           appt.Email,
+			Phone:           appt.Phone,
+			Now:             now.UnixMicro(),
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return domain.ErrAppointmentDateTaken
+			}
+			return fmt.Errorf("create daily appointment: %w", err)
+		}
+		if created, err = toAppointment(appointmentRow); err != nil {
+			return err
+		}
+		if err := appendAudit(ctx, q, now, domain.AuditBooked, nil, created); err != nil {
+			return err
+		}
+		return appendEvent(ctx, q, now, domain.EventAppointmentCreated, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *Repository) GetAppointment(ctx context.Context, id int32) (*domain.Appointment, error) {
+	appointmentRow, err := r.queries.GetDailyAppointment(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get daily appointment: %w", err)
+	}
+	return toAppointment(appointmentRow)
+}
+
+func (r *Repository) GetAppointmentByPublicID(ctx context.Context, id uuid.UUID) (*domain.Appointment, error) {
+	appointmentRow, err := r.queries.GetDailyAppointmentByPublicID(ctx, id.String())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("get daily appointment by public id: %w", err)
+	}
+	return toAppointment(appointmentRow)
+}
+
+// checkPatientLimit refuses the booking if the patient appt belongs to is already at limit.
+func checkPatientLimit(ctx context.Context, q *sqlcsqlite.Queries, appt *domain.Appointment, limit domain.PatientLimit) error {
+	count, err := q.CountPatientAppointments(ctx, sqlcsqlite.CountPatientAppointmentsParams{
+		FromDate:  limit.From.UnixMicro(),
+		Email:     appt.Email,
+		Phone:     appt.Phone,
+		FirstName: appt.FirstName,
+		LastName:  appt.LastName,
+	})
+	if err != nil {
+		return fmt.Errorf("count patient appointments: %w", err)
+	}
+	if count >= int64(limit.Max) {
+		return domain.ErrPatientBookingLimit
+	}
+	return nil
+}
+
+// listPageSize is how many rows ListAppointments holds in memory at once.
+const listPageSize = 500
+
+// ListAppointments pages through matching appointments with a keyset on (appointment_date, id), so memory use is
+// bounded by listPageSize however many rows match.
+func (r *Repository) ListAppointments(ctx context.Context, filter domain.AppointmentFilter, fn func(*domain.Appointment) error) error {
+	params := sqlcsqlite.ListDailyAppointmentsPageParams{PageSize: listPageSize}
+	if filter.From != nil {
+		params.FromDate = sql.NullInt64{Int64: filter.From.UnixMicro(), Valid: true}
+	}
+	if filter.To != nil {
+		params.ToDate = sql.NullInt64{Int64: filter.To.UnixMicro(), Valid: true}
+	}
+	if filter.Status != "" {
+		params.Status = sql.NullString{String: string(filter.Status), Valid: true}
+	}
+	for {
+		page, err := r.queries.ListDailyAppointmentsPage(ctx, params)
+		if err != nil {
+			return fmt.Errorf("list daily appointments page: %w", err)
+		}
+		for _, row := range page {
+			appt, err := toAppointment(row)
+			if err != nil {
+				return err
+			}
+			if err := fn(appt); err != nil {
+				return err
+			}
+		}
+		if len(page) < listPageSize {
+			return nil
+		}
+		last := page[len(page)-1]
+		params.AfterDate = sql.NullInt64{Int64: last.AppointmentDate, Valid: true}
+		params.AfterID = last.ID
+	}
+}
+
+// toAppointments converts the rows of a query listing appointments.
+func toAppointments(rows []sqlcsqlite.DailyAppointment) ([]*domain.Appointment, error) {
+	appts := make([]*domain.Appointment, 0, len(rows))
+	for _, row := range rows {
+		appt, err := toAppointment(row)
+		if err != nil {
+			return nil, err
+		}
+		appts = append(appts, appt)
+	}
+	return appts, nil
+}
+
+func toAppointment(row sqlcsqlite.DailyAppointment) (*domain.Appointment, error) {
+	publicID, err := uuid.Parse(row.PublicID)
+	if err != nil {
+		return nil, fmt.Errorf("parse public id: %w", err)
+	}
+	return &domain.Appointment{
+		ID:        int32(row.ID),
+		PublicID:  publicID,
+		FirstName: row.FirstName,
+		LastName:  row.LastName,
+		VisitDate: fromMicros(row.AppointmentDate),
+		Email:     row.Email,
+		Phone:     row.Phone,
+		Status:    domain.AppointmentStatus(row.Status),
+		CreatedAt: *fromMicros(row.CreatedAt),
+		UpdatedAt: *fromMicros(row.UpdatedAt),
+		Sequence:  int32(row.Sequence),
+	}, nil
+}
+
+// CreateHold reserves the visit date until hold.ExpiresAt, failing if it is already booked or held.
+func (r *Repository) CreateHold(ctx context.Context, hold *domain.Hold) (*domain.Hold, error) {
+	holdDate := hold.VisitDate.UnixMicro()
+	token, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("new hold token: %w", err)
+	}
+	var holdRow sqlcsqlite.DateHold
+	err = r.withTx(ctx, func(q *sqlcsqlite.Queries) error {
+		if err := deleteExpiredHolds(ctx, q, r.now()); err != nil {
+			return err
+		}
+		booked, err := q.DailyAppointmentExists(ctx, holdDate)
+		if err != nil {
+			return fmt.Errorf("daily appointment exists: %w", err)
+		}
+		if booked != 0 {
+			return domain.ErrAppointmentDateTaken
+		}
+
+		holdRow, err = q.CreateDateHold(ctx, sqlcsqlite.CreateDateHoldParams{
+			Token:     token.String(),
+			HoldDate:  holdDate,
+			ExpiresAt: hold.ExpiresAt.UnixMicro(),
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return domain.ErrAppointmentDateTaken
+			}
+			return fmt.Errorf("create date hold: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Hold{
+		Token:     holdRow.Token,
+		VisitDate: fromMicros(holdRow.HoldDate),
+		ExpiresAt: *fromMicros(holdRow.ExpiresAt),
+	}, nil
+}
+
+// deleteExpiredHolds clears holds that have run out, so they no longer count against a date. Callers hold the write
+// lock, which stands in for the date lock of the Postgres repository.
+func deleteExpiredHolds(ctx context.Context, q *sqlcsqlite.Queries, now time.Time) error {
+	if err := q.DeleteExpiredDateHolds(ctx, now.UnixMicro()); err != nil {
+		return fmt.Errorf("delete expired date holds: %w", err)
+	}
+	return nil
+}
+
+func consumeHold(ctx context.Context, q *sqlcsqlite.Queries, token string, date int64) error {
+	holdToken, err := uuid.Parse(token)
+	if err != nil {
+		return domain.ErrHoldNotFound
+	}
+	deleted, err := q.DeleteDateHold(ctx, sqlcsqlite.DeleteDateHoldParams{Token: holdToken.String(), HoldDate: date})
+	if err != nil {
+		return fmt.Errorf("delete date hold: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrHoldNotFound
+	}
+	return nil
+}
+
+// appendEvent writes an event to the outbox, it must be called in the transaction making the change it describes.
+func appendEvent(ctx context.Context, q *sqlcsqlite.Queries, now time.Time, eventType domain.EventType, appt *domain.Appointment) error {
+	event, err := domain.NewAppointmentEvent(eventType, appt)
+	if err != nil {
+		return err
+	}
+	if err := q.InsertOutboxEvent(ctx, sqlcsqlite.InsertOutboxEventParams{
+		EventType:   string(event.Type),
+		AggregateID: int64(event.AppointmentID),
+		Payload:     string(event.Payload),
+		Now:         now.UnixMicro(),
+	}); err != nil {
+		return fmt.Errorf("insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// PublishPending passes up to limit unpublished events, oldest first, to publish until it fails, marking each as
+// published once it has been. Unlike the Postgres repository it cannot hold the events' rows while publishing, as a
+// publisher writing to the database would then wait on itself, so calls are serialised within the process instead. An
+// event is only published again if the process dies between publishing and marking it. The count of published events
+// is returned even when publish fails part way through.
+func (r *Repository) PublishPending(ctx context.Context, limit int32, publish func(ctx context.Context, event *domain.Event) error) (int, error) {
+	r.publishMu.Lock()
+	defer r.publishMu.Unlock()
+
+	rows, err := r.queries.ListPendingOutboxEvents(ctx, int64(limit))
+	if err != nil {
+		return 0, fmt.Errorf("list pending outbox events: %w", err)
+	}
+	published := 0
+	for _, row := range rows {
+		if err := publish(ctx, toEvent(row)); err != nil {
+			return published, fmt.Errorf("publish event: %w", err)
+		}
+		if err := r.queries.MarkOutboxEventPublished(ctx, sqlcsqlite.MarkOutboxEventPublishedParams{
+			PublishedAt: sql.NullInt64{Int64: r.now().UnixMicro(), Valid: true},
+			ID:          row.ID,
+		}); err != nil {
+			return published, fmt.Errorf("mark outbox event published: %w", err)
+		}
+		published++
+	}
+	return published, nil
+}
+
+func toEvent(row sqlcsqlite.OutboxEvent) *domain.Event {
+	return &domain.Event{
+		ID:            row.ID,
+		Type:          domain.EventType(row.EventType),
+		AppointmentID: int32(row.AggregateID),
+		Payload:       []byte(row.Payload),
+		CreatedAt:     *fromMicros(row.CreatedAt),
+	}
+}
+
+// InTx runs fn against a repository bound to a single transaction, committing only if fn succeeds. Writes made through
+// the transactional repository nest as savepoints, so a failed write does not poison the rest of the transaction.
+func (r *Repository) InTx(ctx context.Context, fn func(repo domain.AppointmentPersistorRepository) error) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return fn(&Repository{db: r.db, tx: tx, queries: r.queries.WithTx(tx), now: r.now, publishMu: r.publishMu})
+	})
+}
+
+func (r *Repository) withTx(ctx context.Context, fn func(q *sqlcsqlite.Queries) error) error {
+	if r.tx != nil {
+		return withSavepoint(ctx, r.tx, func() error {
+			return fn(r.queries)
+		})
+	}
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return fn(r.queries.WithTx(tx))
+	})
+}
+
+// inTx begins a transaction and commits it only if fn succeeds.
+func (r *Repository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // no-op once committed
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withSavepoint runs fn within a savepoint of tx, undoing only fn's writes if it fails.
+func withSavepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, `savepoint nested`); err != nil {
+		return fmt.Errorf("savepoint: %w", err)
+	}
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, `rollback to nested`); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback to savepoint: %w", rollbackErr))
+		}
+		if _, releaseErr := tx.ExecContext(ctx, `release nested`); releaseErr != nil {
+			return errors.Join(err, fmt.Errorf("release savepoint: %w", releaseErr))
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `release nested`); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *moderncsqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// fromMicros returns the UTC time us microseconds after the unix epoch.
+func fromMicros(us int64) *time.Time {
+	t := time.UnixMicro(us).UTC()
+	return &t
+}
@@ -0,0 +1,60 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/repositorytest"
+	"github.com/jcooney/appts/repository/sqlite"
+	"github.com/jcooney/appts/schema"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func newRepository(t *testing.T) *sqlite.Repository {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "appts.db")
+	db, err := sqlite.Open(path)
+	require.NoError(t, err)
+	m, err := schema.NewSQLiteMigrate(db)
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	_, _ = m.Close() // closes db too
+
+	db, err = sqlite.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return sqlite.NewRepository(db)
+}
+
+func TestRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Store {
+		return newRepository(t)
+	})
+}
+
+func TestPublisherCanWriteToTheDatabase(t *testing.T) {
+	underTest := newRepository(t)
+	_, err := underTest.CreateWebhookSubscription(t.Context(), &domain.WebhookSubscription{URL: "https://example.com", Secret: "secret"})
+	require.NoError(t, err)
+	_, err = underTest.CreateAppointment(t.Context(), domain.NewAppointment("first", "last", ptr.To(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	// the webhook fanout enqueues deliveries as it publishes, which must not wait on the relay's own lock
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	published, err := underTest.PublishPending(ctx, 10, func(ctx context.Context, event *domain.Event) error {
+		_, err := underTest.EnqueueWebhookDeliveries(ctx, event)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, published)
+
+	due, err := underTest.ClaimDueWebhookDeliveries(t.Context(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, "https://example.com", due[0].URL)
+}
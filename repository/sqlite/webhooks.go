@@ -0,0 +1,231 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/sqlite/gen"
+)
+
+// webhookDeliveryLogSize caps how many deliveries ListWebhookDeliveries returns.
+const webhookDeliveryLogSize = 100
+
+func (r *Repository) CreateWebhookSubscription(ctx context.Context, sub *domain.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	eventTypes := make([]string, 0, len(sub.EventTypes))
+	for _, t := range sub.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+	eventTypesJSON, err := json.Marshal(eventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event types: %w", err)
+	}
+	row, err := r.queries.CreateWebhookSubscription(ctx, sqlcsqlite.CreateWebhookSubscriptionParams{
+		Url:        sub.URL,
+		Secret:     sub.Secret,
+		EventTypes: string(eventTypesJSON),
+		Now:        r.now().UnixMicro(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create webhook subscription: %w", err)
+	}
+	return toWebhookSubscription(row)
+}
+
+func (r *Repository) ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.queries.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	subs := make([]*domain.WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		sub, err := toWebhookSubscription(row)
+		if err != nil {
+			return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes the subscription along with its delivery log.
+func (r *Repository) DeleteWebhookSubscription(ctx context.Context, id int32) error {
+	deleted, err := r.queries.DeleteWebhookSubscription(ctx, int64(id))
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the latest webhookDeliveryLogSize deliveries to the subscription, newest first.
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, subscriptionID int32, status domain.WebhookDeliveryStatus) ([]*domain.WebhookDelivery, error) {
+	if _, err := r.queries.GetWebhookSubscription(ctx, int64(subscriptionID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("get webhook subscription: %w", err)
+	}
+	params := sqlcsqlite.ListWebhookDeliveriesParams{SubscriptionID: int64(subscriptionID), PageSize: webhookDeliveryLogSize}
+	if status != "" {
+		params.Status = sql.NullString{String: string(status), Valid: true}
+	}
+	rows, err := r.queries.ListWebhookDeliveries(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	deliveries := make([]*domain.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, toWebhookDelivery(row))
+	}
+	return deliveries, nil
+}
+
+// RetryWebhookDelivery makes a dead delivery due now with its attempts reset.
+func (r *Repository) RetryWebhookDelivery(ctx context.Context, subscriptionID int32, deliveryID int64) (*domain.WebhookDelivery, error) {
+	row, err := r.queries.RetryDeadWebhookDelivery(ctx, sqlcsqlite.RetryDeadWebhookDeliveryParams{
+		Now:            r.now().UnixMicro(),
+		ID:             deliveryID,
+		SubscriptionID: int64(subscriptionID),
+	})
+	if err == nil {
+		return toWebhookDelivery(row), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("retry dead webhook delivery: %w", err)
+	}
+	if _, err := r.queries.GetWebhookDelivery(ctx, sqlcsqlite.GetWebhookDeliveryParams{
+		ID:             deliveryID,
+		SubscriptionID: int64(subscriptionID),
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+	return nil, domain.ErrWebhookDeliveryNotDead
+}
+
+// EnqueueWebhookDeliveries queues the event for every subscription interested in it. Enqueueing the same event twice
+// is a no-op, so it is safe to call from an at-least-once relay.
+func (r *Repository) EnqueueWebhookDeliveries(ctx context.Context, event *domain.Event) (int64, error) {
+	queued, err := r.queries.EnqueueWebhookDeliveries(ctx, sqlcsqlite.EnqueueWebhookDeliveriesParams{
+		EventID:   event.ID,
+		EventType: string(event.Type),
+		Payload:   string(event.Payload),
+		Now:       r.now().UnixMicro(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return queued, nil
+}
+
+// ClaimDueWebhookDeliveries takes up to limit pending deliveries whose next attempt is due and pushes that attempt back
+// by lease, so other dispatchers leave them alone while they are in flight. A claim that is never recorded, because the
+// process died, simply becomes due again once the lease runs out.
+func (r *Repository) ClaimDueWebhookDeliveries(ctx context.Context, limit int32, lease time.Duration) ([]*domain.DueWebhookDelivery, error) {
+	var due []*domain.DueWebhookDelivery
+	err := r.withTx(ctx, func(q *sqlcsqlite.Queries) error {
+		now := r.now()
+		rows, err := q.ListDueWebhookDeliveries(ctx, sqlcsqlite.ListDueWebhookDeliveriesParams{
+			Now:       now.UnixMicro(),
+			BatchSize: int64(limit),
+		})
+		if err != nil {
+			return fmt.Errorf("list due webhook deliveries: %w", err)
+		}
+		leasedUntil := now.Add(lease).UnixMicro()
+		due = make([]*domain.DueWebhookDelivery, 0, len(rows))
+		for _, row := range rows {
+			if err := q.LeaseWebhookDelivery(ctx, sqlcsqlite.LeaseWebhookDeliveryParams{
+				NextAttemptAt: leasedUntil,
+				ID:            row.ID,
+			}); err != nil {
+				return fmt.Errorf("lease webhook delivery: %w", err)
+			}
+			row.NextAttemptAt = leasedUntil
+			due = append(due, toDueWebhookDelivery(row))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+// RecordWebhookAttempt saves the outcome of an attempt, as set on delivery by the dispatcher.
+func (r *Repository) RecordWebhookAttempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	err := r.queries.RecordWebhookDeliveryAttempt(ctx, sqlcsqlite.RecordWebhookDeliveryAttemptParams{
+		Status:         string(delivery.Status),
+		Attempts:       int64(delivery.Attempts),
+		NextAttemptAt:  delivery.NextAttemptAt.UnixMicro(),
+		LastStatusCode: sql.NullInt64{Int64: int64(delivery.LastStatusCode), Valid: delivery.LastStatusCode != 0},
+		LastError:      sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""},
+		Now:            r.now().UnixMicro(),
+		ID:             delivery.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+func toWebhookSubscription(row sqlcsqlite.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	var eventTypes []domain.EventType
+	if err := json.Unmarshal([]byte(row.EventTypes), &eventTypes); err != nil {
+		return nil, fmt.Errorf("unmarshal event types: %w", err)
+	}
+	return &domain.WebhookSubscription{
+		ID:         int32(row.ID),
+		URL:        row.Url,
+		Secret:     row.Secret,
+		EventTypes: append([]domain.EventType{}, eventTypes...),
+		CreatedAt:  *fromMicros(row.CreatedAt),
+	}, nil
+}
+
+func toWebhookDelivery(row sqlcsqlite.WebhookDelivery) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             row.ID,
+		SubscriptionID: int32(row.SubscriptionID),
+		EventID:        row.EventID,
+		EventType:      domain.EventType(row.EventType),
+		Payload:        []byte(row.Payload),
+		Status:         domain.WebhookDeliveryStatus(row.Status),
+		Attempts:       int32(row.Attempts),
+		NextAttemptAt:  *fromMicros(row.NextAttemptAt),
+		LastStatusCode: int32(row.LastStatusCode.Int64),
+		LastError:      row.LastError.String,
+		CreatedAt:      *fromMicros(row.CreatedAt),
+		UpdatedAt:      *fromMicros(row.UpdatedAt),
+	}
+}
+
+func toDueWebhookDelivery(row sqlcsqlite.ListDueWebhookDeliveriesRow) *domain.DueWebhookDelivery {
+	return &domain.DueWebhookDelivery{
+		WebhookDelivery: toWebhookDelivery(sqlcsqlite.WebhookDelivery{
+			ID:             row.ID,
+			SubscriptionID: row.SubscriptionID,
+			EventID:        row.EventID,
+			EventType:      row.EventType,
+			Payload:        row.Payload,
+			Status:         row.Status,
+			Attempts:       row.Attempts,
+			NextAttemptAt:  row.NextAttemptAt,
+			LastStatusCode: row.LastStatusCode,
+			LastError:      row.LastError,
+			CreatedAt:      row.CreatedAt,
+			UpdatedAt:      row.UpdatedAt,
+		}),
+		URL:    row.Url,
+		Secret: row.Secret,
+	}
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jcooney/appts/domain"
+	"github.com/jcooney/appts/repository/gen"
+)
+
+// webhookDeliveryLogSize caps how many deliveries ListWebhookDeliveries returns.
+const webhookDeliveryLogSize = 100
+
+func (r *Repository) CreateWebhookSubscription(ctx context.Context, sub *domain.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	eventTypes := make([]string, 0, len(sub.EventTypes))
+	for _, t := range sub.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+	row, err := r.queries.CreateWebhookSubscription(ctx, sqlcappts.CreateWebhookSubscriptionParams{
+		Url:        sub.URL,
+		Secret:     sub.Secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create webhook subscription: %w", err)
+	}
+	return toWebhookSubscription(row), nil
+}
+
+func (r *Repository) ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.queries.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	subs := make([]*domain.WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		subs = append(subs, toWebhookSubscription(row))
+	}
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes the subscription along with its delivery log.
+func (r *Repository) DeleteWebhookSubscription(ctx context.Context, id int32) error {
+	deleted, err := r.queries.DeleteWebhookSubscription(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the latest webhookDeliveryLogSize deliveries to the subscription, newest first.
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, subscriptionID int32, status domain.WebhookDeliveryStatus) ([]*domain.WebhookDelivery, error) {
+	if _, err := r.queries.GetWebhookSubscription(ctx, subscriptionID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("get webhook subscription: %w", err)
+	}
+	params := sqlcappts.ListWebhookDeliveriesParams{SubscriptionID: subscriptionID, PageSize: webhookDeliveryLogSize}
+	if status != "" {
+		params.Status = pgtype.Text{String: string(status), Valid: true}
+	}
+	rows, err := r.queries.ListWebhookDeliveries(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	deliveries := make([]*domain.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, toWebhookDelivery(row))
+	}
+	return deliveries, nil
+}
+
+// RetryWebhookDelivery makes a dead delivery due now with its attempts reset.
+func (r *Repository) RetryWebhookDelivery(ctx context.Context, subscriptionID int32, deliveryID int64) (*domain.WebhookDelivery, error) {
+	row, err := r.queries.RetryDeadWebhookDelivery(ctx, sqlcappts.RetryDeadWebhookDeliveryParams{ID: deliveryID, SubscriptionID: subscriptionID})
+	if err == nil {
+		return toWebhookDelivery(row), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("retry dead webhook delivery: %w", err)
+	}
+	if _, err := r.queries.GetWebhookDelivery(ctx, sqlcappts.GetWebhookDeliveryParams{ID: deliveryID, SubscriptionID: subscriptionID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+	return nil, domain.ErrWebhookDeliveryNotDead
+}
+
+// EnqueueWebhookDeliveries queues the event for every subscription interested in it. Enqueueing the same event twice
+// is a no-op, so it is safe to call from an at-least-once relay.
+func (r *Repository) EnqueueWebhookDeliveries(ctx context.Context, event *domain.Event) (int64, error) {
+	queued, err := r.queries.EnqueueWebhookDeliveries(ctx, sqlcappts.EnqueueWebhookDeliveriesParams{
+		EventID:   event.ID,
+		EventType: string(event.Type),
+		Payload:   event.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return queued, nil
+}
+
+// ClaimDueWebhookDeliveries takes up to limit pending deliveries whose next attempt is due and pushes that attempt back
+// by lease, so other dispatchers leave them alone while they are in flight. A claim that is never recorded, because the
+// process died, simply becomes due again once the lease runs out.
+func (r *Repository) ClaimDueWebhookDeliveries(ctx context.Context, limit int32, lease time.Duration) ([]*domain.DueWebhookDelivery, error) {
+	rows, err := r.queries.ClaimDueWebhookDeliveries(ctx, sqlcappts.ClaimDueWebhookDeliveriesParams{
+		Lease:     pgtype.Interval{Microseconds: lease.Microseconds(), Valid: true},
+		BatchSize: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim due webhook deliveries: %w", err)
+	}
+	due := make([]*domain.DueWebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		due = append(due, &domain.DueWebhookDelivery{
+			WebhookDelivery: toWebhookDelivery(sqlcappts.ApptsWebhookDelivery{
+				ID:             row.ID,
+				SubscriptionID: row.SubscriptionID,
+				EventID:        row.EventID,
+				EventType:      row.EventType,
+				Payload:        row.Payload,
+				Status:         row.Status,
+				Attempts:       row.Attempts,
+				NextAttemptAt:  row.NextAttemptAt,
+				LastStatusCode: row.LastStatusCode,
+				LastError:      row.LastError,
+				CreatedAt:      row.CreatedAt,
+				UpdatedAt:      row.UpdatedAt,
+			}),
+			URL:    row.Url,
+			Secret: row.Secret,
+		})
+	}
+	return due, nil
+}
+
+// RecordWebhookAttempt saves the outcome of an attempt, as set on delivery by the dispatcher.
+func (r *Repository) RecordWebhookAttempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	err := r.queries.RecordWebhookDeliveryAttempt(ctx, sqlcappts.RecordWebhookDeliveryAttemptParams{
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  pgtype.Timestamptz{Time: delivery.NextAttemptAt, Valid: true},
+		LastStatusCode: pgtype.Int4{Int32: delivery.LastStatusCode, Valid: delivery.LastStatusCode != 0},
+		LastError:      pgtype.Text{String: delivery.LastError, Valid: delivery.LastError != ""},
+		ID:             delivery.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+func toWebhookSubscription(row sqlcappts.ApptsWebhookSubscription) *domain.WebhookSubscription {
+	eventTypes := make([]domain.EventType, 0, len(row.EventTypes))
+	for _, t := range row.EventTypes {
+		eventTypes = append(eventTypes, domain.EventType(t))
+	}
+	return &domain.WebhookSubscription{
+		ID:         row.ID,
+		URL:        row.Url,
+		Secret:     row.Secret,
+		EventTypes: eventTypes,
+		CreatedAt:  row.CreatedAt.Time,
+	}
+}
+
+func toWebhookDelivery(row sqlcappts.ApptsWebhookDelivery) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             row.ID,
+		SubscriptionID: row.SubscriptionID,
+		EventID:        row.EventID,
+		EventType:      domain.EventType(row.EventType),
+		Payload:        row.Payload,
+		Status:         domain.WebhookDeliveryStatus(row.Status),
+		Attempts:       row.Attempts,
+		NextAttemptAt:  row.NextAttemptAt.Time,
+		LastStatusCode: row.LastStatusCode.Int32,
+		LastError:      row.LastError.String,
+		CreatedAt:      row.CreatedAt.Time,
+		UpdatedAt:      row.UpdatedAt.Time,
+	}
+}
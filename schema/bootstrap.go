@@ -0,0 +1,90 @@
+// Package schema holds the golang-migrate migrations, for postgres in ddl/ and for SQLite in sqlite/, and bootstraps
+// the postgres role they grant to.
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AppRole is granted every privilege the service needs by the migrations, which expect it to exist already.
+const AppRole = "appt_user"
+
+// DB is satisfied by a *pgx.Conn or *pgxpool.Pool.
+type DB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Bootstrap creates the login role the service connects as, or resets its password when it already exists, so it can
+// be rerun to rotate the password. A login other than AppRole is made a member of AppRole, which is created without
+// login if it is missing, and so inherits the migrations' grants. db must be connected as a role allowed to create
+// roles.
+func Bootstrap(ctx context.Context, db DB, login string, password string) error {
+	if login == "" || password == "" {
+		return errors.New("bootstrap needs a login and password")
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if login != AppRole {
+		exists, err := roleExists(ctx, tx, AppRole)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := execFormat(ctx, tx, "CREATE ROLE %I NOLOGIN", AppRole); err != nil {
+				return fmt.Errorf("create role %s: %w", AppRole, err)
+			}
+		}
+	}
+	exists, err := roleExists(ctx, tx, login)
+	if err != nil {
+		return err
+	}
+	verb := "CREATE"
+	if exists {
+		verb = "ALTER"
+	}
+	if err := execFormat(ctx, tx, verb+" ROLE %I WITH LOGIN PASSWORD %L", login, password); err != nil {
+		return fmt.Errorf("%s role %s: %w", strings.ToLower(verb), login, err)
+	}
+	if login != AppRole {
+		if err := execFormat(ctx, tx, "GRANT %I TO %I", AppRole, login); err != nil {
+			return fmt.Errorf("grant %s to %s: %w", AppRole, login, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func roleExists(ctx context.Context, tx pgx.Tx, role string) (bool, error) {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)`, role).Scan(&exists); err != nil {
+		return false, fmt.Errorf("role %s exists: %w", role, err)
+	}
+	return exists, nil
+}
+
+// execFormat has the server quote identifiers (%I) and literals (%L) into the statement, as utility statements such
+// as CREATE ROLE cannot take bind parameters.
+func execFormat(ctx context.Context, tx pgx.Tx, format string, args ...any) error {
+	placeholders := ""
+	for i := range args {
+		placeholders += fmt.Sprintf(", $%d::text", i+1)
+	}
+	var stmt string
+	if err := tx.QueryRow(ctx, "SELECT format('"+format+"'"+placeholders+")", args...).Scan(&stmt); err != nil {
+		return fmt.Errorf("format statement: %w", err)
+	}
+	_, err := tx.Exec(ctx, stmt)
+	return err
+}
@@ -0,0 +1,3 @@
+drop TABLE IF EXISTS appts.daily_appointments;
+
+drop SCHEMA IF EXISTS appts;
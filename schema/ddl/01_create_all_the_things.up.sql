@@ -1,6 +1,6 @@
 create SCHEMA appts;
 
-create user appt_user with PASSWORD 'appt_password';
+-- appt_user is created outside the migrations, by `appts bootstrap` or the platform's own tooling
 
 grant USAGE on SCHEMA appts TO appt_user;
 
@@ -0,0 +1,9 @@
+create TABLE IF NOT EXISTS appts.date_holds (
+    token uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    hold_date timestamp with time zone NOT NULL,
+    expires_at timestamp with time zone NOT NULL
+);
+
+grant select, insert, update, delete on appts.date_holds TO appt_user;
+
+create unique index unique_hold_day on appts.date_holds (hold_date);
@@ -0,0 +1,3 @@
+alter table appts.daily_appointments
+    drop column IF EXISTS status,
+    drop column IF EXISTS created_at;
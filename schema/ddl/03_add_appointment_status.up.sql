@@ -0,0 +1,3 @@
+alter table appts.daily_appointments
+    add column status varchar(20) NOT NULL DEFAULT 'booked',
+    add column created_at timestamp with time zone NOT NULL DEFAULT now();
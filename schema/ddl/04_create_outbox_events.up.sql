@@ -0,0 +1,12 @@
+create TABLE IF NOT EXISTS appts.outbox_events (
+    ID bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    event_type varchar(100) NOT NULL,
+    aggregate_id integer NOT NULL,
+    payload jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    published_at timestamp with time zone
+);
+
+grant select, insert, update, delete on appts.outbox_events TO appt_user;
+
+create index outbox_events_pending on appts.outbox_events (ID) where published_at is null;
@@ -0,0 +1,3 @@
+drop TABLE IF EXISTS appts.webhook_deliveries;
+
+drop TABLE IF EXISTS appts.webhook_subscriptions;
@@ -0,0 +1,28 @@
+create TABLE IF NOT EXISTS appts.webhook_subscriptions (
+    ID integer GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    url text NOT NULL,
+    secret text NOT NULL,
+    event_types text[] NOT NULL DEFAULT '{}',
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+create TABLE IF NOT EXISTS appts.webhook_deliveries (
+    ID bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    subscription_id integer NOT NULL REFERENCES appts.webhook_subscriptions (ID) ON DELETE CASCADE,
+    event_id bigint NOT NULL REFERENCES appts.outbox_events (ID),
+    event_type varchar(100) NOT NULL,
+    payload jsonb NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone NOT NULL DEFAULT now(),
+    last_status_code integer,
+    last_error text,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    UNIQUE (subscription_id, event_id)
+);
+
+grant select, insert, update, delete on appts.webhook_subscriptions TO appt_user;
+grant select, insert, update, delete on appts.webhook_deliveries TO appt_user;
+
+create index webhook_deliveries_due on appts.webhook_deliveries (next_attempt_at) where status = 'pending';
@@ -0,0 +1,5 @@
+drop TABLE IF EXISTS appts.sent_reminders;
+
+alter table appts.daily_appointments
+    drop column IF EXISTS email,
+    drop column IF EXISTS phone;
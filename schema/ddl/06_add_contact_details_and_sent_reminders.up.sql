@@ -0,0 +1,13 @@
+alter table appts.daily_appointments
+    add column email varchar(254) NOT NULL DEFAULT '',
+    add column phone varchar(20) NOT NULL DEFAULT '';
+
+create TABLE IF NOT EXISTS appts.sent_reminders (
+    appointment_id integer NOT NULL REFERENCES appts.daily_appointments (ID) ON DELETE CASCADE,
+    lead_minutes integer NOT NULL,
+    channel varchar(20) NOT NULL,
+    sent_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (appointment_id, lead_minutes, channel)
+);
+
+grant select, insert, update, delete on appts.sent_reminders TO appt_user;
@@ -0,0 +1,14 @@
+drop TABLE IF EXISTS appts.manage_tokens;
+
+-- every date holds a single appointment again, so cancelled appointments sharing their date with a booked one, or with
+-- an earlier cancelled one, are deleted
+delete from appts.daily_appointments cancelled
+where cancelled.status = 'cancelled'
+  and exists (
+    select 1 from appts.daily_appointments other
+    where other.appointment_date = cancelled.appointment_date
+      and other.ID <> cancelled.ID
+      and (other.status = 'booked' or other.ID < cancelled.ID)
+  );
+drop index IF EXISTS appts.unique_booked_appointment_day;
+create unique index unique_appointment_day on appts.daily_appointments (appointment_date);
@@ -0,0 +1,15 @@
+-- cancelled appointments free up their date
+drop index appts.unique_appointment_day;
+create unique index unique_booked_appointment_day on appts.daily_appointments (appointment_date) where status = 'booked';
+
+create TABLE IF NOT EXISTS appts.manage_tokens (
+    ID uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    appointment_id integer NOT NULL REFERENCES appts.daily_appointments (ID) ON DELETE CASCADE,
+    expires_at timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+grant select, insert, update, delete on appts.manage_tokens TO appt_user;
+
+create index manage_tokens_appointment on appts.manage_tokens (appointment_id);
@@ -0,0 +1,3 @@
+drop index IF EXISTS appts.daily_appointments_public_id;
+
+alter table appts.daily_appointments drop column IF EXISTS public_id;
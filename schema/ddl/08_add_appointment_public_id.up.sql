@@ -0,0 +1,13 @@
+-- public_id is the identifier exposed outside the service, id stays an internal key. New rows get a UUIDv7 from the
+-- application; existing rows are backfilled with one built from created_at so they sort the same way.
+alter table appts.daily_appointments add column public_id uuid;
+
+update appts.daily_appointments
+set public_id = encode(
+    set_bit(set_bit(overlay(uuid_send(gen_random_uuid())
+        placing substring(int8send(floor(extract(epoch from created_at) * 1000)::bigint) from 3)
+        from 1 for 6), 52, 1), 53, 1), 'hex')::uuid;
+
+alter table appts.daily_appointments alter column public_id set not null;
+
+create unique index daily_appointments_public_id on appts.daily_appointments (public_id);
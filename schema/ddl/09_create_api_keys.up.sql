@@ -0,0 +1,10 @@
+create TABLE IF NOT EXISTS appts.api_keys (
+    ID integer GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    prefix varchar(20) NOT NULL,
+    key_hash bytea NOT NULL UNIQUE,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    revoked_at timestamp with time zone
+);
+
+grant select, insert, update, delete on appts.api_keys TO appt_user;
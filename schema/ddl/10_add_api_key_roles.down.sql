@@ -0,0 +1,4 @@
+-- without roles every key can do everything, so revoke the keys that were restricted rather than escalate them
+update appts.api_keys set revoked_at = now() where role <> 'admin' and revoked_at is null;
+
+alter table appts.api_keys drop column IF EXISTS role;
@@ -0,0 +1,3 @@
+-- keys issued before roles existed could do everything
+alter table appts.api_keys add column role varchar(20) NOT NULL DEFAULT 'admin';
+alter table appts.api_keys alter column role drop default;
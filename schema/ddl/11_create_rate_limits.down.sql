@@ -0,0 +1 @@
+drop TABLE IF EXISTS appts.rate_limits;
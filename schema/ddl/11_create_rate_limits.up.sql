@@ -0,0 +1,11 @@
+create TABLE IF NOT EXISTS appts.rate_limits (
+    bucket varchar(200) NOT NULL,
+    window_start timestamp with time zone NOT NULL,
+    hits integer NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (bucket, window_start)
+);
+
+create index rate_limits_expires_at on appts.rate_limits (expires_at);
+
+grant select, insert, update, delete on appts.rate_limits TO appt_user;
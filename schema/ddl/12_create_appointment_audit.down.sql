@@ -0,0 +1 @@
+drop TABLE IF EXISTS appts.appointment_audit;
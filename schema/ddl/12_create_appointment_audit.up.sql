@@ -0,0 +1,15 @@
+create TABLE IF NOT EXISTS appts.appointment_audit (
+    ID bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    appointment_id integer NOT NULL REFERENCES appts.daily_appointments (ID),
+    action varchar(20) NOT NULL,
+    actor varchar(200) NOT NULL,
+    request_id varchar(100) NOT NULL DEFAULT '',
+    before jsonb,
+    after jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+create index appointment_audit_appointment on appts.appointment_audit (appointment_id, ID);
+
+-- append-only, the service can never rewrite history
+grant select, insert on appts.appointment_audit TO appt_user;
@@ -0,0 +1 @@
+revoke select on public.schema_migrations from appt_user;
@@ -0,0 +1,2 @@
+-- lets the service's readiness check confirm the schema is at the version it was built for
+grant select on public.schema_migrations TO appt_user;
@@ -0,0 +1,3 @@
+alter table appts.daily_appointments
+    drop column IF EXISTS sequence,
+    drop column IF EXISTS updated_at;
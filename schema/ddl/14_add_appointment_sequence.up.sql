@@ -0,0 +1,8 @@
+-- sequence is the iCalendar SEQUENCE of the appointment, bumped whenever a change should replace the copy a calendar
+-- client already has
+alter table appts.daily_appointments
+    add column sequence integer NOT NULL DEFAULT 0,
+    add column updated_at timestamp with time zone NOT NULL DEFAULT now();
+
+update appts.daily_appointments
+set updated_at = created_at;
@@ -0,0 +1 @@
+drop TABLE IF EXISTS appts.confirmation_emails;
@@ -0,0 +1,16 @@
+create TABLE IF NOT EXISTS appts.confirmation_emails (
+    ID bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES appts.outbox_events (ID),
+    appointment_id integer NOT NULL REFERENCES appts.daily_appointments (ID) ON DELETE CASCADE,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone NOT NULL DEFAULT now(),
+    last_error text,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    UNIQUE (event_id)
+);
+
+grant select, insert, update, delete on appts.confirmation_emails TO appt_user;
+
+create index confirmation_emails_due on appts.confirmation_emails (next_attempt_at) where status = 'pending';
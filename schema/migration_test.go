@@ -1,22 +1,31 @@
 package schema_test
 
 import (
-	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
+	"github.com/jcooney/appts/repository/sqlite"
+	"github.com/jcooney/appts/schema"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 )
 
-func TestAppliesMigration(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping integration test in short mode")
-	}
+const (
+	latestVersion       = uint(15)
+	latestSQLiteVersion = uint(3)
+)
+
+// migrator returns a migrate instance for a fresh database with only the app role bootstrapped, along with the
+// superuser's url.
+func migrator(t *testing.T) (*migrate.Migrate, string) {
+	t.Helper()
 	ctx := t.Context()
 
 	dbName := "tabeo"
@@ -37,15 +46,170 @@ func TestAppliesMigration(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	connectionString, err := postgresContainer.ConnectionString(ctx)
+	connectionString, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
 	require.NoError(t, err)
 
-	connectionString = fmt.Sprintf("%s dbname=%s user=%s password=%s&sslmode=disable", connectionString, dbName, dbUser, dbPassword)
+	conn, err := pgx.Connect(ctx, connectionString)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	require.NoError(t, schema.Bootstrap(ctx, conn, schema.AppRole, "appt_password"))
 
-	m, err := migrate.New("file://ddl", connectionString)
+	m, err := schema.NewPostgresMigrate(connectionString)
 	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = m.Close() })
+	return m, connectionString
+}
+
+func TestAppliesMigration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	m, _ := migrator(t)
 	require.NoError(t, m.Up())
 
 	v, _, _ := m.Version()
-	require.Equal(t, v, uint(1))
+	require.Equal(t, v, latestVersion)
+}
+
+func TestEveryMigrationHasADownScript(t *testing.T) {
+	ups, err := filepath.Glob("ddl/*.up.sql")
+	require.NoError(t, err)
+	require.Len(t, ups, int(latestVersion))
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+		_, err := os.Stat(down)
+		require.NoError(t, err, "%s has no down script", up)
+	}
+}
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	m, _ := migrator(t)
+
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Down())
+	_, _, err := m.Version()
+	require.ErrorIs(t, err, migrate.ErrNilVersion, "every migration was rolled back")
+	require.NoError(t, m.Up())
+
+	v, dirty, err := m.Version()
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, latestVersion, v)
+}
+
+// TestEachMigrationRoundTrips rolls every migration back and forward again on top of the ones before it, so a down
+// script that only works from the latest schema is caught.
+func TestEachMigrationRoundTrips(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	m, _ := migrator(t)
+
+	for version := uint(1); version <= latestVersion; version++ {
+		require.NoError(t, m.Steps(1), "up to %d", version)
+		require.NoError(t, m.Steps(-1), "down from %d", version)
+		require.NoError(t, m.Steps(1), "up to %d again", version)
+
+		v, dirty, err := m.Version()
+		require.NoError(t, err)
+		require.False(t, dirty)
+		require.Equal(t, version, v)
+	}
+}
+
+// TestManageTokensDownKeepsOneAppointmentPerDay rolls back the migration that let cancelled appointments share a date,
+// with both a booked and several cancelled appointments on one.
+func TestManageTokensDownKeepsOneAppointmentPerDay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	m, connectionString := migrator(t)
+	require.NoError(t, m.Migrate(7))
+
+	conn, err := pgx.Connect(t.Context(), connectionString)
+	require.NoError(t, err)
+	defer conn.Close(t.Context())
+	_, err = conn.Exec(t.Context(), `insert into appts.daily_appointments (first_name, last_name, appointment_date, status)
+values ('cancelled', 'first', '2024-12-24', 'cancelled'),
+       ('booked', 'after', '2024-12-24', 'booked'),
+       ('cancelled', 'first', '2024-12-27', 'cancelled'),
+       ('cancelled', 'second', '2024-12-27', 'cancelled')`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Steps(-1))
+	rows, err := conn.Query(t.Context(), `select first_name || ' ' || last_name from appts.daily_appointments order by appointment_date`)
+	require.NoError(t, err)
+	kept, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+	require.Equal(t, []string{"booked after", "cancelled first"}, kept)
+}
+
+func TestEverySQLiteMigrationHasADownScript(t *testing.T) {
+	ups, err := filepath.Glob("sqlite/*.up.sql")
+	require.NoError(t, err)
+	require.Len(t, ups, int(latestSQLiteVersion))
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+		_, err := os.Stat(down)
+		require.NoError(t, err, "%s has no down script", up)
+	}
+}
+
+func TestSQLiteMigrationsRoundTrip(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "appts.db"))
+	require.NoError(t, err)
+	m, err := schema.NewSQLiteMigrate(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = m.Close() })
+
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Down())
+	_, _, err = m.Version()
+	require.ErrorIs(t, err, migrate.ErrNilVersion, "every migration was rolled back")
+	require.NoError(t, m.Up())
+
+	v, dirty, err := m.Version()
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, latestSQLiteVersion, v)
+}
+
+func TestBootstrap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	ctx := t.Context()
+	m, connectionString := migrator(t)
+	require.NoError(t, m.Up())
+	conn, err := pgx.Connect(ctx, connectionString)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+
+	// connectAs returns an error unless login can sign in with password and read the service's tables.
+	connectAs := func(login string, password string) error {
+		u, err := url.Parse(connectionString)
+		require.NoError(t, err)
+		u.User = url.UserPassword(login, password)
+		conn, err := pgx.Connect(ctx, u.String())
+		if err != nil {
+			return err
+		}
+		defer conn.Close(ctx)
+		_, err = conn.Exec(ctx, "SELECT count(*) FROM appts.daily_appointments")
+		return err
+	}
+	require.NoError(t, connectAs(schema.AppRole, "appt_password"))
+
+	require.NoError(t, schema.Bootstrap(ctx, conn, schema.AppRole, "rotated"), "rerunning rotates the password")
+	require.Error(t, connectAs(schema.AppRole, "appt_password"))
+	require.NoError(t, connectAs(schema.AppRole, "rotated"))
+
+	require.NoError(t, schema.Bootstrap(ctx, conn, "appts_blue", "it's blue"), "another login inherits the grants")
+	require.NoError(t, connectAs("appts_blue", "it's blue"))
+	require.NoError(t, connectAs(schema.AppRole, "rotated"), "the app role is left as it was")
+
+	require.EqualError(t, schema.Bootstrap(ctx, conn, "appts_blue", ""), "bootstrap needs a login and password")
 }
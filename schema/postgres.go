@@ -0,0 +1,39 @@
+package schema
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Postgres holds the migrations of the postgres backend, embedded so the binary migrates and checks the version of its
+// database wherever it is run from.
+//
+//go:embed ddl/*.sql
+var Postgres embed.FS
+
+// PostgresSource returns the embedded postgres migrations as a golang-migrate source.
+func PostgresSource() (source.Driver, error) {
+	src, err := iofs.New(Postgres, "ddl")
+	if err != nil {
+		return nil, fmt.Errorf("open postgres migrations: %w", err)
+	}
+	return src, nil
+}
+
+// NewPostgresMigrate returns a migrate instance applying the postgres migrations to the database at url.
+func NewPostgresMigrate(url string) (*migrate.Migrate, error) {
+	src, err := PostgresSource()
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, url)
+	if err != nil {
+		return nil, fmt.Errorf("postgres migrations: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,43 @@
+package schema
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// SQLite holds the migrations of the SQLite backend, embedded so a single binary can migrate its own database.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
+
+// SQLiteSource returns the embedded SQLite migrations as a golang-migrate source.
+func SQLiteSource() (source.Driver, error) {
+	src, err := iofs.New(SQLite, "sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite migrations: %w", err)
+	}
+	return src, nil
+}
+
+// NewSQLiteMigrate returns a migrate instance applying the SQLite migrations to db. Closing it closes db.
+func NewSQLiteMigrate(db *sql.DB) (*migrate.Migrate, error) {
+	src, err := SQLiteSource()
+	if err != nil {
+		return nil, err
+	}
+	driver, err := migratesqlite.WithInstance(db, &migratesqlite.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("sqlite migration driver: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite migrations: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,10 @@
+drop TABLE IF EXISTS appointment_audit;
+drop TABLE IF EXISTS rate_limits;
+drop TABLE IF EXISTS api_keys;
+drop TABLE IF EXISTS manage_tokens;
+drop TABLE IF EXISTS sent_reminders;
+drop TABLE IF EXISTS webhook_deliveries;
+drop TABLE IF EXISTS webhook_subscriptions;
+drop TABLE IF EXISTS outbox_events;
+drop TABLE IF EXISTS date_holds;
+drop TABLE IF EXISTS daily_appointments;
@@ -0,0 +1,124 @@
+-- The SQLite schema matches the postgres one as it stands after its migrations. Times are stored as microseconds since
+-- the unix epoch, which compare and sort exactly, and uuids as text.
+
+create TABLE IF NOT EXISTS daily_appointments (
+    ID integer PRIMARY KEY AUTOINCREMENT,
+    public_id text NOT NULL,
+    first_name text NOT NULL,
+    last_name text NOT NULL,
+    appointment_date integer NOT NULL,
+    email text NOT NULL DEFAULT '',
+    phone text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'booked',
+    created_at integer NOT NULL
+);
+
+create unique index unique_booked_appointment_day on daily_appointments (appointment_date) where status = 'booked';
+create unique index daily_appointments_public_id on daily_appointments (public_id);
+
+create TABLE IF NOT EXISTS date_holds (
+    token text PRIMARY KEY,
+    hold_date integer NOT NULL,
+    expires_at integer NOT NULL
+);
+
+create unique index unique_hold_day on date_holds (hold_date);
+
+create TABLE IF NOT EXISTS outbox_events (
+    ID integer PRIMARY KEY AUTOINCREMENT,
+    event_type text NOT NULL,
+    aggregate_id integer NOT NULL,
+    payload text NOT NULL,
+    created_at integer NOT NULL,
+    published_at integer
+);
+
+create index outbox_events_pending on outbox_events (ID) where published_at is null;
+
+create TABLE IF NOT EXISTS webhook_subscriptions (
+    ID integer PRIMARY KEY AUTOINCREMENT,
+    url text NOT NULL,
+    secret text NOT NULL,
+    event_types text NOT NULL DEFAULT '[]',
+    created_at integer NOT NULL
+);
+
+create TABLE IF NOT EXISTS webhook_deliveries (
+    ID integer PRIMARY KEY AUTOINCREMENT,
+    subscription_id integer NOT NULL REFERENCES webhook_subscriptions (ID) ON DELETE CASCADE,
+    event_id integer NOT NULL REFERENCES outbox_events (ID),
+    event_type text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at integer NOT NULL,
+    last_status_code integer,
+    last_error text,
+    created_at integer NOT NULL,
+    updated_at integer NOT NULL,
+    UNIQUE (subscription_id, event_id)
+);
+
+create index webhook_deliveries_due on webhook_deliveries (next_attempt_at) where status = 'pending';
+
+create TABLE IF NOT EXISTS sent_reminders (
+    appointment_id integer NOT NULL REFERENCES daily_appointments (ID) ON DELETE CASCADE,
+    lead_minutes integer NOT NULL,
+    channel text NOT NULL,
+    sent_at integer NOT NULL,
+    PRIMARY KEY (appointment_id, lead_minutes, channel)
+);
+
+create TABLE IF NOT EXISTS manage_tokens (
+    ID text PRIMARY KEY,
+    appointment_id integer NOT NULL REFERENCES daily_appointments (ID) ON DELETE CASCADE,
+    expires_at integer NOT NULL,
+    revoked_at integer,
+    created_at integer NOT NULL
+);
+
+create index manage_tokens_appointment on manage_tokens (appointment_id);
+
+create TABLE IF NOT EXISTS api_keys (
+    ID integer PRIMARY KEY AUTOINCREMENT,
+    name text NOT NULL,
+    role text NOT NULL,
+    prefix text NOT NULL,
+    key_hash blob NOT NULL UNIQUE,
+    created_at integer NOT NULL,
+    revoked_at integer
+);
+
+create TABLE IF NOT EXISTS rate_limits (
+    bucket text NOT NULL,
+    window_start integer NOT NULL,
+    hits integer NOT NULL,
+    expires_at integer NOT NULL,
+    PRIMARY KEY (bucket, window_start)
+);
+
+create index rate_limits_expires_at on rate_limits (expires_at);
+
+create TABLE IF NOT EXISTS appointment_audit (
+    ID integer PRIMARY KEY AUTOINCREMENT,
+    appointment_id integer NOT NULL REFERENCES daily_appointments (ID),
+    action text NOT NULL,
+    actor text NOT NULL,
+    request_id text NOT NULL DEFAULT '',
+    before text,
+    after text NOT NULL,
+    created_at integer NOT NULL
+);
+
+create index appointment_audit_appointment on appointment_audit (appointment_id, ID);
+
+-- append-only, as the service can never rewrite history
+create trigger appointment_audit_no_update before update on appointment_audit
+begin
+    select raise(abort, 'appointment_audit is append-only');
+end;
+
+create trigger appointment_audit_no_delete before delete on appointment_audit
+begin
+    select raise(abort, 'appointment_audit is append-only');
+end;
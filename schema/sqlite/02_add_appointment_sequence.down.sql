@@ -0,0 +1,2 @@
+alter table daily_appointments drop column sequence;
+alter table daily_appointments drop column updated_at;
@@ -0,0 +1,5 @@
+alter table daily_appointments add column sequence integer NOT NULL DEFAULT 0;
+alter table daily_appointments add column updated_at integer NOT NULL DEFAULT 0;
+
+update daily_appointments
+set updated_at = created_at;
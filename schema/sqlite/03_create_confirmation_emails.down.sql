@@ -0,0 +1 @@
+drop TABLE IF EXISTS confirmation_emails;
@@ -0,0 +1,14 @@
+create TABLE IF NOT EXISTS confirmation_emails (
+    ID integer PRIMARY KEY AUTOINCREMENT,
+    event_id integer NOT NULL REFERENCES outbox_events (ID),
+    appointment_id integer NOT NULL REFERENCES daily_appointments (ID) ON DELETE CASCADE,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at integer NOT NULL,
+    last_error text,
+    created_at integer NOT NULL,
+    updated_at integer NOT NULL,
+    UNIQUE (event_id)
+);
+
+create index confirmation_emails_due on confirmation_emails (next_attempt_at) where status = 'pending';
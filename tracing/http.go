@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for every request, continuing the caller's trace if it sent a traceparent header.
+// Spans are named after the chi route pattern once routing is done, so IDs in paths do not end up in span names.
+func Middleware(next http.Handler) http.Handler {
+	routed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+	})
+	return otelhttp.NewHandler(routed, "http.request", otelhttp.WithSpanNameFormatter(spanName))
+}
+
+// spanName is called as the request starts, and again once chi has set the matched pattern on the request.
+func spanName(_ string, r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Method + " " + r.Pattern
+	}
+	return r.Method
+}
+
+// Transport starts a client span for every outgoing request and propagates the trace context to the server.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
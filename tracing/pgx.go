@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/jcooney/appts/tracing"
+
+// QueryTracer implements pgx.QueryTracer, starting a client span for every query. Spans are named after the sqlc
+// query, e.g. "CreateDailyAppointment", taken from the "-- name:" comment sqlc leaves at the start of each statement.
+type QueryTracer struct{}
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system.name", "postgresql"),
+		attribute.String("db.query.text", data.SQL),
+	}
+	if conn != nil {
+		attrs = append(attrs, attribute.String("db.namespace", conn.Config().Database))
+	}
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, queryName(data.SQL), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.response.returned_rows", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+// queryName returns the sqlc query name, or the SQL verb for statements not written through sqlc such as BEGIN.
+func queryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if rest, ok := strings.CutPrefix(sql, "-- name: "); ok {
+		if name, _, ok := strings.Cut(rest, " "); ok {
+			return name
+		}
+	}
+	if verb, _, _ := strings.Cut(sql, " "); verb != "" {
+		return strings.ToUpper(verb)
+	}
+	return "query"
+}
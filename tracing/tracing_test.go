@@ -0,0 +1,98 @@
+package tracing_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jcooney/appts/tracing"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in memory for the rest of the test binary.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Get("/appts/{id}/history", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/appts/019b8e2a-6c00-7000-8000-000000000001/history", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /appts/{id}/history", spans[0].Name())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String(), "continues the caller's trace")
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestQueryTracer(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		err        error
+		wantName   string
+		wantStatus codes.Code
+	}{
+		{
+			name:     "sqlc query",
+			sql:      "-- name: CreateDailyAppointment :one\ninsert into appts.daily_appointments (first_name) values ($1)",
+			wantName: "CreateDailyAppointment",
+		},
+		{
+			name:     "statement without a name",
+			sql:      "begin",
+			wantName: "BEGIN",
+		},
+		{
+			name:       "failed query",
+			sql:        "-- name: GetDailyAppointment :one\nselect 1",
+			err:        errors.New("boom"),
+			wantName:   "GetDailyAppointment",
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			tracer := tracing.QueryTracer{}
+			ctx := tracer.TraceQueryStart(t.Context(), nil, pgx.TraceQueryStartData{SQL: tt.sql})
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: tt.err})
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			require.Equal(t, tt.wantName, spans[0].Name())
+			require.Equal(t, tt.wantStatus, spans[0].Status().Code)
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	_, err := tracing.Setup(t.Context(), "zipkin")
+	require.EqualError(t, err, `unknown trace exporter "zipkin"`)
+
+	for _, exporter := range []string{tracing.ExporterNone, tracing.ExporterConsole} {
+		shutdown, err := tracing.Setup(t.Context(), exporter)
+		require.NoError(t, err)
+		require.NoError(t, shutdown(t.Context()))
+	}
+}
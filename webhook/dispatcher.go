@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is dead-lettered.
+	MaxAttempts = 8
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+	// errorBodyLimit is how much of a failed response is kept in the delivery log.
+	errorBodyLimit = 512
+)
+
+// Store claims due deliveries and records how each attempt went.
+type Store interface {
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int32, lease time.Duration) ([]*domain.DueWebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
+
+// Envelope is the JSON body POSTed to subscribers.
+type Envelope struct {
+	ID   int64            `json:"id"` // the event ID, shared by every delivery and retry of the event
+	Type domain.EventType `json:"type"`
+	Data json.RawMessage  `json:"data"`
+}
+
+// Dispatcher POSTs due deliveries to their subscribers, retrying failures with exponential backoff until MaxAttempts
+// is reached and the delivery is marked dead.
+type Dispatcher struct {
+	store     Store
+	client    *http.Client
+	interval  time.Duration
+	batchSize int32
+	nowFunc   func() time.Time
+}
+
+// NewDispatcher polls store every interval. The client's timeout bounds each attempt and must be set.
+func NewDispatcher(store Store, client *http.Client, interval time.Duration, batchSize int32, nowFunc func() time.Time) *Dispatcher {
+	return &Dispatcher{
+		store:     store,
+		client:    client,
+		interval:  interval,
+		batchSize: batchSize,
+		nowFunc:   nowFunc,
+	}
+}
+
+// Run polls for due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		d.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain attempts full batches back to back until nothing is due.
+func (d *Dispatcher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := d.store.ClaimDueWebhookDeliveries(ctx, d.batchSize, d.lease())
+		if err != nil {
+			slog.Error("error claiming webhook deliveries", "error", err)
+			return
+		}
+		for _, delivery := range due {
+			d.attempt(ctx, delivery)
+			if err := d.store.RecordWebhookAttempt(ctx, delivery.WebhookDelivery); err != nil {
+				slog.Error("error recording webhook attempt", "delivery", delivery.ID, "error", err)
+			}
+		}
+		if len(due) < int(d.batchSize) {
+			return
+		}
+	}
+}
+
+// lease covers every attempt in a batch timing out, so a claim outlives the dispatcher working through it.
+func (d *Dispatcher) lease() time.Duration {
+	return time.Duration(d.batchSize)*d.client.Timeout + time.Minute
+}
+
+// attempt sends the delivery once and updates it with the outcome, any 2xx response counting as delivered.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *domain.DueWebhookDelivery) {
+	delivery.Attempts++
+	statusCode, err := d.send(ctx, delivery)
+	delivery.LastStatusCode = int32(statusCode)
+	if err == nil {
+		delivery.Status = domain.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= MaxAttempts {
+		delivery.Status = domain.WebhookDeliveryDead
+		slog.Warn("webhook delivery dead-lettered", "delivery", delivery.ID, "url", delivery.URL, "error", err)
+		return
+	}
+	delivery.NextAttemptAt = d.nowFunc().Add(Backoff(delivery.Attempts))
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *domain.DueWebhookDelivery) (int, error) {
+	body, err := json.Marshal(Envelope{ID: delivery.EventID, Type: delivery.EventType, Data: delivery.Payload})
+	if err != nil {
+		return 0, fmt.Errorf("marshal envelope: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+	now := d.nowFunc()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLimit))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, snippet)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff is the wait after the given number of failed attempts: 30s doubling each time, capped at an hour.
+func Backoff(attempts int32) time.Duration {
+	backoff := baseBackoff
+	for i := int32(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// FanoutStore queues an event for every interested subscription.
+type FanoutStore interface {
+	EnqueueWebhookDeliveries(ctx context.Context, event *domain.Event) (int64, error)
+}
+
+// Fanout is the domain.EventPublisher the outbox relay hands events to. It only queues deliveries, the Dispatcher
+// sends them, so a slow subscriber cannot hold up the outbox.
+type Fanout struct {
+	store FanoutStore
+}
+
+func NewFanout(store FanoutStore) *Fanout {
+	return &Fanout{store: store}
+}
+
+func (f *Fanout) Publish(ctx context.Context, event *domain.Event) error {
+	if _, err := f.store.EnqueueWebhookDeliveries(ctx, event); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery. Receivers should verify HeaderSignature with Verify and deduplicate on the event ID
+// in the body, which stays the same across retries.
+const (
+	HeaderDeliveryID = "Webhook-Delivery-Id"
+	HeaderEvent      = "Webhook-Event"
+	HeaderTimestamp  = "Webhook-Timestamp"
+	HeaderSignature  = "Webhook-Signature"
+)
+
+const signatureVersion = "v1="
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+var ErrStaleTimestamp = errors.New("webhook timestamp outside tolerance")
+
+// Sign returns the HeaderSignature value for body sent at timestamp: the hex HMAC-SHA256, keyed with the subscription
+// secret, of the unix timestamp, a dot and the body. Covering the timestamp stops old deliveries being replayed.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the HeaderTimestamp and HeaderSignature values received with body, rejecting deliveries signed more than
+// tolerance either side of now.
+func Verify(secret string, timestamp string, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	sentAt := time.Unix(unix, 0)
+	if sentAt.Before(now.Add(-tolerance)) || sentAt.After(now.Add(tolerance)) {
+		return ErrStaleTimestamp
+	}
+	if !strings.HasPrefix(signature, signatureVersion) || !hmac.Equal([]byte(signature), []byte(Sign(secret, sentAt, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
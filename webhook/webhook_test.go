@@ -0,0 +1,267 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcooney/appts/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":1}`)
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		wantErr   error
+	}{
+		{
+			name:      "accepts a matching signature",
+			secret:    "secret",
+			timestamp: "1767614400",
+			signature: Sign("secret", now, body),
+			body:      body,
+		},
+		{
+			name:      "rejects a different secret",
+			secret:    "other",
+			timestamp: "1767614400",
+			signature: Sign("secret", now, body),
+			body:      body,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "rejects a tampered body",
+			secret:    "secret",
+			timestamp: "1767614400",
+			signature: Sign("secret", now, body),
+			body:      []byte(`{"id":2}`),
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "rejects a signature for another timestamp",
+			secret:    "secret",
+			timestamp: "1767614401",
+			signature: Sign("secret", now, body),
+			body:      body,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "rejects a replay outside the tolerance",
+			secret:    "secret",
+			timestamp: "1767614000",
+			signature: Sign("secret", now.Add(-400*time.Second), body),
+			body:      body,
+			wantErr:   ErrStaleTimestamp,
+		},
+		{
+			name:      "rejects a malformed timestamp",
+			secret:    "secret",
+			timestamp: "yesterday",
+			signature: Sign("secret", now, body),
+			body:      body,
+			wantErr:   ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute, now)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: time.Hour},
+		{attempts: 50, want: time.Hour},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Backoff(tt.attempts), "attempts %d", tt.attempts)
+	}
+}
+
+func TestDispatcher_attempt(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		status        int
+		priorAttempts int32
+		want          domain.WebhookDelivery
+	}{
+		{
+			name:   "delivered on a 2xx",
+			status: http.StatusNoContent,
+			want:   domain.WebhookDelivery{Status: domain.WebhookDeliveryDelivered, Attempts: 1, LastStatusCode: 204},
+		},
+		{
+			name:          "retried with backoff on failure",
+			status:        http.StatusServiceUnavailable,
+			priorAttempts: 2,
+			want: domain.WebhookDelivery{
+				Status:         domain.WebhookDeliveryPending,
+				Attempts:       3,
+				NextAttemptAt:  now.Add(2 * time.Minute),
+				LastStatusCode: 503,
+				LastError:      "unexpected status 503: try later\n",
+			},
+		},
+		{
+			name:          "dead-lettered once attempts run out",
+			status:        http.StatusInternalServerError,
+			priorAttempts: MaxAttempts - 1,
+			want: domain.WebhookDelivery{
+				Status:         domain.WebhookDeliveryDead,
+				Attempts:       MaxAttempts,
+				LastStatusCode: 500,
+				LastError:      "unexpected status 500: try later\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &receiver{status: tt.status, secret: "whsec_test", now: now}
+			ts := httptest.NewServer(receiver)
+			defer ts.Close()
+
+			delivery := &domain.DueWebhookDelivery{
+				WebhookDelivery: &domain.WebhookDelivery{
+					ID:        7,
+					EventID:   3,
+					EventType: domain.EventAppointmentCreated,
+					Payload:   json.RawMessage(`{"id":1}`),
+					Status:    domain.WebhookDeliveryPending,
+					Attempts:  tt.priorAttempts,
+				},
+				URL:    ts.URL,
+				Secret: "whsec_test",
+			}
+			underTest := NewDispatcher(nil, ts.Client(), time.Second, 1, func() time.Time { return now })
+			underTest.attempt(t.Context(), delivery)
+
+			require.NoError(t, receiver.err)
+			require.Equal(t, "7", receiver.header.Get(HeaderDeliveryID))
+			require.Equal(t, "AppointmentCreated", receiver.header.Get(HeaderEvent))
+			require.JSONEq(t, `{"id":3,"type":"AppointmentCreated","data":{"id":1}}`, string(receiver.body))
+			require.Equal(t, tt.want.Status, delivery.Status)
+			require.Equal(t, tt.want.Attempts, delivery.Attempts)
+			require.Equal(t, tt.want.NextAttemptAt, delivery.NextAttemptAt)
+			require.Equal(t, tt.want.LastStatusCode, delivery.LastStatusCode)
+			require.Equal(t, tt.want.LastError, delivery.LastError)
+		})
+	}
+}
+
+func TestDispatcher_attemptUnreachable(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	delivery := &domain.DueWebhookDelivery{WebhookDelivery: &domain.WebhookDelivery{ID: 1}, URL: ts.URL}
+	NewDispatcher(nil, &http.Client{Timeout: time.Second}, time.Second, 1, func() time.Time { return now }).attempt(t.Context(), delivery)
+
+	require.Equal(t, domain.WebhookDelivery{ID: 1, Attempts: 1, NextAttemptAt: now.Add(30 * time.Second), LastError: delivery.LastError}, *delivery.WebhookDelivery)
+	require.Contains(t, delivery.LastError, "connection refused")
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, r.Header.Get(HeaderDeliveryID))
+	}))
+	defer ts.Close()
+
+	store := &fakeStore{}
+	for i := int64(1); i <= 3; i++ {
+		store.due = append(store.due, &domain.DueWebhookDelivery{WebhookDelivery: &domain.WebhookDelivery{ID: i}, URL: ts.URL})
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		NewDispatcher(store, ts.Client(), time.Millisecond, 2, time.Now).Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(store.recorded()) == 3
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	for _, delivery := range store.recorded() {
+		require.Equal(t, domain.WebhookDeliveryDelivered, delivery.Status)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"1", "2", "3"}, got)
+}
+
+// receiver is a partner endpoint checking the signature of what it is sent.
+type receiver struct {
+	status int
+	secret string
+	now    time.Time
+	header http.Header
+	body   []byte
+	err    error
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.header = r.Header
+	rc.body, rc.err = io.ReadAll(r.Body)
+	if rc.err == nil {
+		rc.err = Verify(rc.secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), rc.body, 5*time.Minute, rc.now)
+	}
+	if rc.status >= 300 {
+		http.Error(w, "try later", rc.status)
+		return
+	}
+	w.WriteHeader(rc.status)
+}
+
+type fakeStore struct {
+	mu     sync.Mutex
+	due    []*domain.DueWebhookDelivery
+	record []*domain.WebhookDelivery
+}
+
+func (f *fakeStore) ClaimDueWebhookDeliveries(_ context.Context, limit int32, _ time.Duration) ([]*domain.DueWebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := min(int(limit), len(f.due))
+	claimed := f.due[:n]
+	f.due = f.due[n:]
+	return claimed, nil
+}
+
+func (f *fakeStore) RecordWebhookAttempt(_ context.Context, delivery *domain.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record = append(f.record, delivery)
+	return nil
+}
+
+func (f *fakeStore) recorded() []*domain.WebhookDelivery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*domain.WebhookDelivery(nil), f.record...)
+}